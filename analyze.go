@@ -0,0 +1,137 @@
+package odatasql
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// FilterInfo describes the fields an OData filter references. See Converter.AnalyzeFilter.
+type FilterInfo struct {
+	// Fields lists the referenced fields in order of first reference.
+	Fields []FieldInfo
+}
+
+// FieldInfo describes how a filter uses a field.
+type FieldInfo struct {
+	// Field is the property path, as returned by the converter's Authorizer. Inside a lambda
+	// operator, it is the path from the root, like "items/price".
+	Field string
+	// Column is the unquoted SQL column the property maps to.
+	Column string
+	// Operators lists the comparison operators ("eq", "ne", "gt", "ge", "lt", "le", "in"),
+	// string functions ("contains", "startswith", "endswith") and lambda operators ("any",
+	// "all") applied to the field, in order of first use.
+	Operators []string
+	// Values lists the distinct literals the field is compared with, in order of first use:
+	// a bool, nil, an int64 or float64 number, or a string.
+	Values []any
+	// EqualityOnly reports whether every use of the field is an "eq" or "in" comparison with
+	// non-null values outside of a "not", which an index on the column serves with lookups.
+	EqualityOnly bool
+}
+
+// Field returns the information on a field, if the filter references it.
+func (i *FilterInfo) Field(field string) (FieldInfo, bool) {
+	for _, f := range i.Fields {
+		if f.Field == field {
+			return f, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// AnalyzeFilter analyzes an OData filter with the default converter. See
+// Converter.AnalyzeFilter.
+func AnalyzeFilter(filter string) (*FilterInfo, error) {
+	return defaultConverter.AnalyzeFilter(filter)
+}
+
+// AnalyzeFilter parses an OData filter with the converter's configuration and reports the
+// fields it references: their columns, the operators and literals used on each, and whether a
+// field is only compared for equality. It can be used to check that every filtered column is
+// indexed before running a query, or to log which fields users filter on. An empty filter
+// references no field.
+//
+// Example:
+//
+//	info, err := odatasql.AnalyzeFilter("age gt 18 and status in ('a', 'b')")
+//	f, _ := info.Field("status")
+//	// f.Operators == []string{"in"}, f.Values == []any{"a", "b"}, f.EqualityOnly == true
+func (c *Converter) AnalyzeFilter(filter string) (*FilterInfo, error) {
+	return c.AnalyzeFilterContext(context.Background(), filter)
+}
+
+// AnalyzeFilterContext is like AnalyzeFilter, passing ctx to the converter's Authorizer.
+func (c *Converter) AnalyzeFilterContext(ctx context.Context, filter string) (*FilterInfo, error) {
+	info := &FilterInfo{}
+	if strings.TrimSpace(filter) == "" {
+		return info, nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	a := analyzer{c: c, info: info, index: make(map[string]int)}
+	a.walk(node, false)
+	return info, nil
+}
+
+// analyzer collects the uses of fields in an AST.
+type analyzer struct {
+	c     *Converter
+	info  *FilterInfo
+	index map[string]int // position of each field in info.Fields
+}
+
+func (a analyzer) walk(node ast.Node, negated bool) {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		a.walk(n.Left, negated)
+		a.walk(n.Right, negated)
+	case *ast.NotNode:
+		a.walk(n.Child, !negated)
+	case *ast.ParenNode:
+		a.walk(n.Child, negated)
+	case *ast.ConditionNode:
+		equality := n.Op == ast.OpEq && n.Value.Kind != ast.KindNull && !negated
+		a.use(n.Field, n.Op, equality, n.Value)
+	case *ast.InNode:
+		a.use(n.Field, "in", !negated, n.Values...)
+	case *ast.FunctionNode:
+		a.use(n.Field, n.Name, false, n.Value)
+	case *ast.LambdaNode:
+		a.use(n.Field, n.Op, false)
+		if n.Predicate != nil {
+			a.walk(n.Predicate, negated)
+		}
+	}
+}
+
+// use records a use of a field with an operator and literals.
+func (a analyzer) use(field, op string, equality bool, values ...ast.Literal) {
+	i, ok := a.index[field]
+	if !ok {
+		i = len(a.info.Fields)
+		a.index[field] = i
+		a.info.Fields = append(a.info.Fields, FieldInfo{
+			Field:        field,
+			Column:       a.c.columnName(field),
+			EqualityOnly: true,
+		})
+	}
+
+	f := &a.info.Fields[i]
+	f.EqualityOnly = f.EqualityOnly && equality
+	if !slices.Contains(f.Operators, op) {
+		f.Operators = append(f.Operators, op)
+	}
+	for _, v := range values {
+		if value := literalValue(v); !slices.Contains(f.Values, value) {
+			f.Values = append(f.Values, value)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package odatasql
+
+import (
+	"context"
+	"errors"
+)
+
+// FieldAccess describes a field reference in a filter, as passed to an Authorizer.
+type FieldAccess struct {
+	// Field is the property path as written in the filter.
+	Field string
+	// Operator is the OData operator applied to the field, "eq", "ne", "gt", "ge", "lt", "le"
+	// or "in", or the string function it is passed to: "contains", "startswith" or "endswith".
+	Operator string
+}
+
+// FieldDecision is the outcome of authorizing a field reference. The zero value allows it.
+type FieldDecision struct {
+	denied  bool
+	reason  string
+	rewrite string
+}
+
+// AllowField allows a field reference unchanged.
+func AllowField() FieldDecision {
+	return FieldDecision{}
+}
+
+// DenyField rejects a field reference. The filter fails with ErrCodeFieldAccessDenied and a
+// message including reason, which Middleware reports as 403 Forbidden.
+func DenyField(reason string) FieldDecision {
+	return FieldDecision{denied: true, reason: reason}
+}
+
+// RewriteField replaces a field reference with another property, for instance a masked
+// variant of it. The replacement is validated against the schema, if any, and mapped to its
+// column like any other property.
+func RewriteField(field string) FieldDecision {
+	return FieldDecision{rewrite: field}
+}
+
+// Authorizer decides whether the caller may use a field in a filter. It is called for every
+// field reference while the filter is parsed, with the context passed to FilterToSQLContext
+// or ParseQueryOptionsContext. Implementations must be safe for concurrent use.
+type Authorizer interface {
+	AuthorizeField(ctx context.Context, access FieldAccess) FieldDecision
+}
+
+// AuthorizerFunc adapts an ordinary function to an Authorizer.
+type AuthorizerFunc func(ctx context.Context, access FieldAccess) FieldDecision
+
+// AuthorizeField calls f(ctx, access).
+func (f AuthorizerFunc) AuthorizeField(ctx context.Context, access FieldAccess) FieldDecision {
+	return f(ctx, access)
+}
+
+// WithAuthorizer sets the hook authorizing field references. Mandatory predicates are trusted
+// and not authorized.
+//
+// Example:
+//
+//	odatasql.WithAuthorizer(odatasql.AuthorizerFunc(func(ctx context.Context, a odatasql.FieldAccess) odatasql.FieldDecision {
+//	    if a.Field == "salary" && !isManager(ctx) {
+//	        return odatasql.DenyField("managers only")
+//	    }
+//	    return odatasql.AllowField()
+//	}))
+func WithAuthorizer(a Authorizer) Option {
+	return func(c *Converter) {
+		c.authorizer = a
+	}
+}
+
+// authorizeFunc adapts the converter's Authorizer to the parser, or returns nil if there is none.
+func (c *Converter) authorizeFunc(ctx context.Context) func(field, op string) (string, error) {
+	if c.authorizer == nil {
+		return nil
+	}
+	return func(field, op string) (string, error) {
+		d := c.authorizer.AuthorizeField(ctx, FieldAccess{Field: field, Operator: op})
+		if d.denied {
+			if d.reason == "" {
+				return "", errors.New("not authorized")
+			}
+			return "", errors.New(d.reason)
+		}
+		if d.rewrite != "" {
+			return d.rewrite, nil
+		}
+		return field, nil
+	}
+}
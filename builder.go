@@ -0,0 +1,361 @@
+package odatasql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+	"github.com/maxlambrecht/odatasql/internal/parser"
+)
+
+// Expr is a filter expression built in Go with Field, Not and the methods of Expr and FieldRef.
+// It holds the same AST the parser produces for its OData text, returned by String. The zero
+// Expr is empty and has no text.
+//
+// Building never fails: an invalid value, such as a channel given to Eq, is recorded and
+// returned by Err and by the conversions of the expression.
+//
+// Example:
+//
+//	expr := odatasql.Field("age").Gt(18).And(odatasql.Field("status").In("new", "open"))
+//	expr.String()                    // "age gt 18 and status in ('new', 'open')"
+//	sql, err := conv.ExprToSQL(expr) // "age > 18 AND status IN ('new', 'open')"
+type Expr struct {
+	node ast.Node
+	err  error
+}
+
+// FieldRef refers to a property in an Expr. See Field.
+type FieldRef struct {
+	path string
+	err  error
+}
+
+// Field refers to a property by its OData name or '/'-separated path, as written in filters.
+// The path follows the rules of field names in filters: an invalid path, such as one holding
+// spaces or a reserved SQL keyword, is recorded in the expressions built from it.
+//
+// Inside the predicate of Any or All, the elements of the collection are referred to by their
+// path from the root: Field("tags") for the elements of a primitive collection "tags", and
+// Field("lines/price") for the price of an element of "lines".
+func Field(path string) FieldRef {
+	return FieldRef{path: path, err: parser.ValidateField(path)}
+}
+
+// Eq returns the condition "field eq v". See FieldRef.In for the accepted values.
+func (f FieldRef) Eq(v any) Expr { return f.compare(ast.OpEq, v) }
+
+// Ne returns the condition "field ne v".
+func (f FieldRef) Ne(v any) Expr { return f.compare(ast.OpNe, v) }
+
+// Gt returns the condition "field gt v".
+func (f FieldRef) Gt(v any) Expr { return f.compare(ast.OpGt, v) }
+
+// Ge returns the condition "field ge v".
+func (f FieldRef) Ge(v any) Expr { return f.compare(ast.OpGe, v) }
+
+// Lt returns the condition "field lt v".
+func (f FieldRef) Lt(v any) Expr { return f.compare(ast.OpLt, v) }
+
+// Le returns the condition "field le v".
+func (f FieldRef) Le(v any) Expr { return f.compare(ast.OpLe, v) }
+
+func (f FieldRef) compare(op string, v any) Expr {
+	if f.err != nil {
+		return Expr{err: f.err}
+	}
+	lit, err := literalOf(v)
+	if err != nil {
+		return Expr{err: fmt.Errorf("%s %s: %w", f.path, op, err)}
+	}
+	return Expr{node: &ast.ConditionNode{Field: f.path, Op: op, Value: lit}}
+}
+
+// In returns the condition "field in (values)". Values may be strings, booleans, integers,
+// floating-point numbers, time.Time (written in RFC 3339 format), json.Number, types with one
+// of these underlying types, driver.Valuer implementations and 16-byte arrays such as
+// uuid.UUID. Null is only accepted by the comparison methods.
+func (f FieldRef) In(values ...any) Expr {
+	if f.err != nil {
+		return Expr{err: f.err}
+	}
+	if len(values) == 0 {
+		return Expr{err: fmt.Errorf("%s in: no values", f.path)}
+	}
+	lits := make([]ast.Literal, len(values))
+	for i, v := range values {
+		lit, err := literalOf(v)
+		if err == nil && lit.Kind == ast.KindNull {
+			err = errors.New("null is not allowed in an in list")
+		}
+		if err != nil {
+			return Expr{err: fmt.Errorf("%s in: %w", f.path, err)}
+		}
+		lits[i] = lit
+	}
+	return Expr{node: &ast.InNode{Field: f.path, Values: lits}}
+}
+
+// Contains returns the condition "contains(field, s)".
+func (f FieldRef) Contains(s string) Expr { return f.function(ast.FuncContains, s) }
+
+// StartsWith returns the condition "startswith(field, s)".
+func (f FieldRef) StartsWith(s string) Expr { return f.function(ast.FuncStartsWith, s) }
+
+// EndsWith returns the condition "endswith(field, s)".
+func (f FieldRef) EndsWith(s string) Expr { return f.function(ast.FuncEndsWith, s) }
+
+func (f FieldRef) function(name, s string) Expr {
+	if f.err != nil {
+		return Expr{err: f.err}
+	}
+	return Expr{node: &ast.FunctionNode{Name: name, Field: f.path, Value: ast.Literal{Kind: ast.KindString, Value: s}}}
+}
+
+// Any returns the lambda operator "field/any(x: predicates)", true when an element of the
+// collection satisfies all the predicates. Without predicates it is "field/any()", true when
+// the collection is not empty.
+func (f FieldRef) Any(predicates ...Expr) Expr { return f.lambda(ast.LambdaAny, predicates) }
+
+// All returns the lambda operator "field/all(x: predicates)", true when every element of the
+// collection satisfies all the predicates. At least one predicate is required.
+func (f FieldRef) All(predicates ...Expr) Expr { return f.lambda(ast.LambdaAll, predicates) }
+
+func (f FieldRef) lambda(op string, predicates []Expr) Expr {
+	if f.err != nil {
+		return Expr{err: f.err}
+	}
+	var predicate Expr
+	if len(predicates) > 0 {
+		predicate = predicates[0].And(predicates[1:]...)
+	}
+	switch {
+	case predicate.err != nil:
+		return predicate
+	case predicate.node == nil && op == ast.LambdaAll:
+		return Expr{err: fmt.Errorf("%s/all: no predicate", f.path)}
+	}
+	return Expr{node: &ast.LambdaNode{Op: op, Field: f.path, Predicate: predicate.node}}
+}
+
+// And returns "e and other and ...". Empty expressions are ignored.
+func (e Expr) And(others ...Expr) Expr { return e.combine(ast.OpAnd, others) }
+
+// Or returns "e or other or ...". Empty expressions are ignored.
+func (e Expr) Or(others ...Expr) Expr { return e.combine(ast.OpOr, others) }
+
+func (e Expr) combine(op string, others []Expr) Expr {
+	result := e
+	for _, other := range others {
+		switch {
+		case result.err != nil:
+			return result
+		case other.err != nil:
+			return other
+		case other.node == nil:
+		case result.node == nil:
+			result = other
+		default:
+			result = Expr{node: &ast.BinaryNode{
+				Op:    op,
+				Left:  group(result.node, precedence(op)),
+				Right: group(other.node, precedence(op)+1),
+			}}
+		}
+	}
+	return result
+}
+
+// And returns "e1 and e2 and ...", the expression matching the rows that match every one of
+// exprs. Empty expressions are ignored, so combining an empty user filter with a view filter
+// returns the view filter, and And() is empty.
+//
+// Example:
+//
+//	user, err := conv.ParseFilter(r.URL.Query().Get("$filter"))
+//	view, err := conv.ParseFilter("status ne 'archived'")
+//	expr := odatasql.And(user, view, odatasql.Field("tenantId").Eq(tenant))
+//	sql, err := conv.ExprToSQL(expr)
+func And(exprs ...Expr) Expr {
+	return Expr{}.And(exprs...)
+}
+
+// Or returns "e1 or e2 or ...", the expression matching the rows that match any of exprs.
+// Empty expressions are ignored; Or() is empty.
+func Or(exprs ...Expr) Expr {
+	return Expr{}.Or(exprs...)
+}
+
+// Not returns "not e". The negation of an empty expression is empty.
+func Not(e Expr) Expr {
+	if e.err != nil || e.node == nil {
+		return e
+	}
+	return Expr{node: &ast.NotNode{Child: group(e.node, precedence(ast.OpNot))}}
+}
+
+// String returns the canonical OData text of e, as written by FormatFilter, or "" if e is
+// empty or invalid.
+func (e Expr) String() string {
+	if e.err != nil || e.node == nil {
+		return ""
+	}
+	return ast.Format(e.node)
+}
+
+// Err returns the first error recorded while building e.
+func (e Expr) Err() error {
+	return e.err
+}
+
+// ParseFilter parses an OData filter into an expression with the default converter. See
+// Converter.ParseFilter.
+func ParseFilter(filter string) (Expr, error) {
+	return defaultConverter.ParseFilter(filter)
+}
+
+// ParseFilter parses an OData filter into an expression, to combine it with others with And,
+// Or and Not. Its text is checked against the schema and the limits of the converter, and
+// field references are kept as written: the Authorizer applies when the expression is
+// converted, like for expressions built with Field. An empty filter parses into an empty
+// expression.
+//
+// The text of the combination is returned by String and its SQL by ExprToSQL. Operands are
+// parenthesized where precedence requires it, so "a eq 1 or b eq 2" combined with And and
+// "c eq 3" is "(a eq 1 or b eq 2) and c eq 3".
+func (c *Converter) ParseFilter(filter string) (Expr, error) {
+	if strings.TrimSpace(filter) == "" {
+		return Expr{}, nil
+	}
+
+	unauthorized := c
+	if c.authorizer != nil {
+		copied := *c
+		copied.authorizer, copied.cache = nil, nil
+		unauthorized = &copied
+	}
+	node, err := unauthorized.parse(context.Background(), filter, nil, true)
+	if err != nil {
+		return Expr{}, err
+	}
+	return Expr{node: node}, nil
+}
+
+// ExprToSQL converts a filter expression into SQL with the default converter. See
+// Converter.ExprToSQL.
+func ExprToSQL(e Expr) (string, error) {
+	return defaultConverter.ExprToSQL(e)
+}
+
+// ExprToSQL converts a filter expression into a SQL WHERE clause. The expression is checked
+// like a filter given to FilterToSQL: against the schema, through the Authorizer, within the
+// limits and the cost budget of the converter. Its AST is rendered directly, never parsed back
+// from its text. An empty expression converts to "".
+func (c *Converter) ExprToSQL(e Expr) (string, error) {
+	return c.ExprToSQLContext(context.Background(), e)
+}
+
+// ExprToSQLContext is like ExprToSQL, passing ctx to the converter's Authorizer.
+func (c *Converter) ExprToSQLContext(ctx context.Context, e Expr) (string, error) {
+	if e.err != nil {
+		return "", fmt.Errorf("invalid filter expression: %w", e.err)
+	}
+	if c.err != nil {
+		return "", c.err
+	}
+	if e.node == nil {
+		return "", nil
+	}
+
+	node, err := c.check(ctx, e.node, false)
+	if err != nil {
+		return "", err
+	}
+	if err := c.checkCost(e.String(), node); err != nil {
+		return "", err
+	}
+	return c.render(node), nil
+}
+
+// precedence returns the precedence of a logical operator in OData, from loosest to tightest.
+func precedence(op string) int {
+	switch op {
+	case ast.OpOr:
+		return 1
+	case ast.OpAnd:
+		return 2
+	}
+	return 3
+}
+
+// group wraps node in a ParenNode when its precedence is below min, as the parser does for
+// the parentheses the OData text of the expression needs.
+func group(node ast.Node, min int) ast.Node {
+	if b, ok := node.(*ast.BinaryNode); ok && precedence(b.Op) < min {
+		return &ast.ParenNode{Child: node}
+	}
+	return node
+}
+
+// literalOf converts a Go value into a literal.
+func literalOf(v any) (ast.Literal, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return ast.Literal{Kind: ast.KindNull, Value: "null"}, nil
+		}
+		value, err := valuer.Value()
+		if err != nil {
+			return ast.Literal{}, err
+		}
+		v = value
+	}
+
+	switch x := v.(type) {
+	case nil:
+		return ast.Literal{Kind: ast.KindNull, Value: "null"}, nil
+	case time.Time:
+		return ast.Literal{Kind: ast.KindString, Value: x.Format(time.RFC3339Nano)}, nil
+	case json.Number:
+		if err := parser.ValidateNumber(x.String()); err != nil {
+			return ast.Literal{}, err
+		}
+		return ast.Literal{Kind: ast.KindNumber, Value: x.String()}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return ast.Literal{Kind: ast.KindNull, Value: "null"}, nil
+		}
+		return literalOf(rv.Elem().Interface())
+	case reflect.String:
+		return ast.Literal{Kind: ast.KindString, Value: rv.String()}, nil
+	case reflect.Bool:
+		return ast.Literal{Kind: ast.KindBoolean, Value: strconv.FormatBool(rv.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ast.Literal{Kind: ast.KindNumber, Value: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return ast.Literal{Kind: ast.KindNumber, Value: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return ast.Literal{}, fmt.Errorf("non-finite number %v", f)
+		}
+		return ast.Literal{Kind: ast.KindNumber, Value: strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())}, nil
+	case reflect.Array:
+		if rv.Len() == 16 && rv.Type().Elem().Kind() == reflect.Uint8 {
+			uuid, _ := normalize(v)
+			return ast.Literal{Kind: ast.KindString, Value: uuid.(string)}, nil
+		}
+	}
+	return ast.Literal{}, fmt.Errorf("unsupported value of type %T", v)
+}
@@ -0,0 +1,291 @@
+package odatasql
+
+import (
+	"container/list"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+	"github.com/maxlambrecht/odatasql/internal/parser"
+)
+
+// DefaultCacheSize is the number of filters held by a FilterCache created with a size of 0.
+const DefaultCacheSize = 1024
+
+// FilterCache is a least-recently-used cache of parsed filters and their SQL, for endpoints
+// that see the same filters over and over. Entries are keyed by the shape of a filter, its
+// tokens with string and number literals left as slots, and by the converter that parsed it,
+// so a cache may be shared by converters with different configurations. Filters differing
+// only in those literals, like "age gt 18" and "age gt 21", share an entry holding the parsed
+// filter and a SQL template with argument slots, filled with the escaped literals of each
+// filter. A FilterCache is safe for concurrent use.
+//
+// Example:
+//
+//	cache := odatasql.NewFilterCache(4096)
+//	conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithFilterCache(cache))
+//	sql, err := conv.FilterToSQL("age gt 18") // parsed and rendered once
+//	sql, err = conv.FilterToSQL("age gt 21")  // served from the same entry
+type FilterCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *cacheItem, most recently used first
+	items   map[cacheKey]*list.Element
+	metrics CacheStats
+}
+
+// CacheStats reports the activity of a FilterCache.
+type CacheStats struct {
+	// Hits is the number of lookups served from the cache.
+	Hits uint64
+	// Misses is the number of lookups of filters missing from the cache.
+	Misses uint64
+	// Evictions is the number of entries removed to make room for new ones.
+	Evictions uint64
+	// Entries is the number of entries in the cache.
+	Entries int
+}
+
+// cacheKey identifies the parse of a filter shape by a converter, with or without lambda
+// operators.
+type cacheKey struct {
+	conv    *Converter
+	shape   string
+	lambdas bool
+}
+
+// cacheEntry holds the parsed filter that created an entry, and its SQL template once
+// rendered.
+type cacheEntry struct {
+	node     ast.Node
+	literals []ast.Literal // slot literals of the filter, in order
+	// templated reports whether the slot literals are the string and number literals of node,
+	// in order, so that other filters of the shape parse into node with their own literals.
+	templated bool
+	sql       *sqlTemplate
+}
+
+// sqlTemplate is the SQL of a filter shape, split around its argument slots.
+type sqlTemplate struct {
+	parts []string // SQL before, between and after the slots: one more than slots
+	slots []sqlSlot
+}
+
+// sqlSlot is an argument slot of a SQL template.
+type sqlSlot struct {
+	literal  int    // index of the slot literal
+	function string // string function whose LIKE pattern holds the literal, or ""
+}
+
+type cacheItem struct {
+	key   cacheKey
+	entry cacheEntry
+}
+
+// NewFilterCache creates a cache holding up to size filters. A size of 0 or less uses
+// DefaultCacheSize.
+func NewFilterCache(size int) *FilterCache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &FilterCache{size: size, order: list.New(), items: make(map[cacheKey]*list.Element)}
+}
+
+// WithFilterCache caches the filters parsed by the converter, and the SQL rendered from them,
+// in fc. Parse errors are not cached, and the cache is bypassed by converters with an
+// Authorizer, whose decisions depend on the request context, and by filters using parameter
+// aliases. The literals of a filter served from an entry created by another filter are
+// checked against the schema and the limits like those of a parsed filter.
+func WithFilterCache(fc *FilterCache) Option {
+	return func(c *Converter) {
+		c.cache = fc
+	}
+}
+
+// Stats returns the cache's hit and miss counters and its number of entries.
+func (fc *FilterCache) Stats() CacheStats {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	stats := fc.metrics
+	stats.Entries = fc.order.Len()
+	return stats
+}
+
+// Purge removes every entry from the cache, keeping its counters.
+func (fc *FilterCache) Purge() {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.order.Init()
+	clear(fc.items)
+}
+
+// get returns the entry of a key, marking it as recently used. Lookups are counted by record,
+// once the entry is known to serve the filter.
+func (fc *FilterCache) get(key cacheKey) (cacheEntry, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	el, ok := fc.items[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	fc.order.MoveToFront(el)
+	return el.Value.(*cacheItem).entry, true
+}
+
+// record counts a lookup served from the cache, or not.
+func (fc *FilterCache) record(hit bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if hit {
+		fc.metrics.Hits++
+	} else {
+		fc.metrics.Misses++
+	}
+}
+
+// put stores the entry of a key, evicting the least recently used entries beyond the size.
+func (fc *FilterCache) put(key cacheKey, entry cacheEntry) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if el, ok := fc.items[key]; ok {
+		el.Value.(*cacheItem).entry = entry
+		fc.order.MoveToFront(el)
+		return
+	}
+	fc.items[key] = fc.order.PushFront(&cacheItem{key: key, entry: entry})
+	for fc.order.Len() > fc.size {
+		oldest := fc.order.Back()
+		fc.order.Remove(oldest)
+		delete(fc.items, oldest.Value.(*cacheItem).key)
+		fc.metrics.Evictions++
+	}
+}
+
+// cacheKey returns the key of a filter parsed by the converter and its slot literals, or false
+// if its parse cannot be cached. Filters that cannot be tokenized are not cached, and report
+// their error when parsed.
+func (c *Converter) cacheKey(filter string, aliases map[string]string, lambdas bool) (cacheKey, []ast.Literal, bool) {
+	if c.cache == nil || c.authorizer != nil || len(aliases) > 0 {
+		return cacheKey{}, nil, false
+	}
+	shape, literals, err := parser.Template(filter, c.limits)
+	if err != nil {
+		return cacheKey{}, nil, false
+	}
+	return cacheKey{conv: c, shape: shape, lambdas: lambdas}, literals, true
+}
+
+// lookup returns the cached entry of a key and the AST of the filter with the given slot
+// literals, or false if the entry cannot serve it. An entry created by another filter of the
+// shape serves it when templated, with the literals substituted and checked.
+func (c *Converter) lookup(key cacheKey, literals []ast.Literal) (cacheEntry, ast.Node, bool) {
+	e, ok := c.cache.get(key)
+	var node ast.Node
+	switch {
+	case !ok:
+	case slices.Equal(e.literals, literals):
+		node = e.node
+	case e.templated:
+		if replaced, err := c.withLiterals(e.node, literals); err == nil {
+			node = replaced
+		}
+	}
+	c.cache.record(node != nil)
+	return e, node, node != nil
+}
+
+// newCacheEntry returns the entry of a parsed filter with the given slot literals.
+func newCacheEntry(node ast.Node, literals []ast.Literal) cacheEntry {
+	var slots []ast.Literal
+	ast.MapLiterals(node, func(_ ast.Node, v ast.Literal) ast.Literal {
+		if isSlot(v) {
+			slots = append(slots, v)
+		}
+		return v
+	})
+	return cacheEntry{node: node, literals: literals, templated: slices.Equal(slots, literals)}
+}
+
+// isSlot reports whether a literal of a templated AST is a slot literal.
+func isSlot(v ast.Literal) bool {
+	return v.Kind == ast.KindString || v.Kind == ast.KindNumber
+}
+
+// withLiterals returns a copy of a templated AST with its slot literals replaced, checked
+// against the schema. The other checks of the parser apply to the shape of the filter, or to
+// its literals as tokenized by parser.Template, so they hold for every filter of the shape.
+func (c *Converter) withLiterals(node ast.Node, literals []ast.Literal) (ast.Node, error) {
+	i := 0
+	var err error
+	replaced := ast.MapLiterals(node, func(n ast.Node, v ast.Literal) ast.Literal {
+		if !isSlot(v) || err != nil {
+			return v
+		}
+		v, i = literals[i], i+1
+		if c.schema == nil {
+			return v
+		}
+		switch n := n.(type) {
+		case *ast.ConditionNode:
+			err = c.schema.checkField(n.Field, n.Op, []ast.Literal{v})
+		case *ast.InNode:
+			err = c.schema.checkField(n.Field, ast.OpIn, []ast.Literal{v})
+		case *ast.FunctionNode:
+			err = c.schema.checkField(n.Field, n.Name, []ast.Literal{v})
+		}
+		return v
+	})
+	return replaced, err
+}
+
+// slotMarker delimits the index of a slot literal in the SQL rendered for a template.
+const slotMarker = "\x00"
+
+// template renders the SQL template of an entry. A templated AST is rendered with the index of
+// each slot literal in place of the literal, and the SQL is split around them.
+func (c *Converter) template(e cacheEntry) *sqlTemplate {
+	if !e.templated {
+		return &sqlTemplate{parts: []string{c.render(e.node)}}
+	}
+
+	var functions []string
+	marked := ast.MapLiterals(e.node, func(n ast.Node, v ast.Literal) ast.Literal {
+		if !isSlot(v) {
+			return v
+		}
+		function := ""
+		if f, ok := n.(*ast.FunctionNode); ok {
+			function = f.Name
+		}
+		functions = append(functions, function)
+		return ast.Literal{Kind: v.Kind, Value: slotMarker + strconv.Itoa(len(functions)-1) + slotMarker}
+	})
+
+	t := &sqlTemplate{}
+	for i, part := range strings.Split(marked.ToSQL(sqlWriter{c: c, template: true}, 0), slotMarker) {
+		if i%2 == 0 {
+			t.parts = append(t.parts, part)
+			continue
+		}
+		index, _ := strconv.Atoi(part)
+		t.slots = append(t.slots, sqlSlot{literal: index, function: functions[index]})
+	}
+	return t
+}
+
+// fill renders the SQL of the filter with the given slot literals.
+func (t *sqlTemplate) fill(w sqlWriter, literals []ast.Literal) string {
+	var sb strings.Builder
+	for i, slot := range t.slots {
+		sb.WriteString(t.parts[i])
+		if slot.function != "" {
+			sb.WriteString(w.pattern(slot.function, literals[slot.literal]))
+		} else {
+			sb.WriteString(w.literal(literals[slot.literal]))
+		}
+	}
+	sb.WriteString(t.parts[len(t.slots)])
+	return sb.String()
+}
@@ -0,0 +1,371 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+	"github.com/maxlambrecht/odatasql/internal/parser"
+)
+
+// NullHandling controls how comparisons against null are rendered.
+type NullHandling int
+
+const (
+	// NullAsValue compares against null like any other value: "deleted_at = null".
+	// It is the default.
+	NullAsValue NullHandling = iota
+	// NullAsIsNull renders "eq null" as "IS NULL" and "ne null" as "IS NOT NULL".
+	NullAsIsNull
+)
+
+// Converter converts OData filters into SQL using a fixed configuration.
+// A Converter is immutable once created and safe for concurrent use, so it can be
+// created once (e.g. per endpoint) and shared across goroutines.
+type Converter struct {
+	dialect Dialect
+	schema  *Schema
+	limits  Limits
+	naming  NamingStrategy
+	nulls   NullHandling
+	cost    CostModel
+	cache   *FilterCache
+
+	predicates []Predicate
+	authorizer Authorizer
+
+	err error // configuration error found by NewConverter
+}
+
+// Option configures a Converter.
+type Option func(*Converter)
+
+// WithDialect sets the SQL dialect. Defaults to DialectGeneric.
+func WithDialect(d Dialect) Option {
+	return func(c *Converter) {
+		if d != nil {
+			c.dialect = d
+		}
+	}
+}
+
+// WithSchema restricts filters to the properties declared in s and maps them to their columns.
+// Without a schema, any field is accepted and mapped with the naming strategy.
+func WithSchema(s *Schema) Option {
+	return func(c *Converter) {
+		c.schema = s
+	}
+}
+
+// WithLimits sets the resource limits enforced while parsing. Defaults to DefaultLimits().
+func WithLimits(l Limits) Option {
+	return func(c *Converter) {
+		c.limits = l
+	}
+}
+
+// WithNamingStrategy sets how property names are mapped to columns when the schema does not
+// declare a column. Defaults to SnakeCase.
+func WithNamingStrategy(n NamingStrategy) Option {
+	return func(c *Converter) {
+		if n != nil {
+			c.naming = n
+		}
+	}
+}
+
+// WithNullHandling sets how comparisons against null are rendered. Defaults to NullAsValue.
+func WithNullHandling(h NullHandling) Option {
+	return func(c *Converter) {
+		c.nulls = h
+	}
+}
+
+// NewConverter creates a Converter with the given options.
+//
+// Example:
+//
+//	conv := NewConverter(WithDialect(DialectPostgres), WithNullHandling(NullAsIsNull))
+//	sql, err := conv.FilterToSQL("deletedAt eq null")
+//	// sql = `"deleted_at" IS NULL`
+func NewConverter(opts ...Option) *Converter {
+	c := &Converter{
+		dialect: DialectGeneric,
+		limits:  DefaultLimits(),
+		naming:  SnakeCase,
+		nulls:   NullAsValue,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.err = c.validate()
+	if c.err == nil {
+		c.predicates, c.err = c.resolvePredicates(c.predicates)
+	}
+	return c
+}
+
+// Err returns the configuration error found by NewConverter, if any, such as KebabCase naming
+// with a dialect that does not quote identifiers or an invalid mandatory predicate. A
+// misconfigured converter returns the error from every conversion, so checking Err at startup
+// is optional.
+func (c *Converter) Err() error {
+	return c.err
+}
+
+// validate checks the combination of the converter's options.
+func (c *Converter) validate() error {
+	if c.naming == KebabCase && c.dialect.QuoteIdentifier("a") == "a" {
+		return fmt.Errorf("invalid converter configuration: KebabCase naming requires a dialect that quotes identifiers")
+	}
+	return nil
+}
+
+// Schema returns the schema configured with WithSchema, or nil if there is none.
+func (c *Converter) Schema() *Schema {
+	return c.schema
+}
+
+// defaultConverter backs the package-level functions.
+var defaultConverter = NewConverter()
+
+// FilterToSQL transforms an OData filter string into a SQL WHERE clause using the
+// converter's configuration. An empty filter yields an empty clause.
+func (c *Converter) FilterToSQL(filter string) (string, error) {
+	return c.FilterToSQLContext(context.Background(), filter)
+}
+
+// FilterToSQLContext is like FilterToSQL, passing ctx to the converter's Authorizer.
+func (c *Converter) FilterToSQLContext(ctx context.Context, filter string) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	if strings.TrimSpace(filter) == "" {
+		return "", nil
+	}
+
+	return c.toSQL(ctx, filter, nil)
+}
+
+// toSQL parses a non-empty filter, checks its cost and renders it as SQL, through the
+// converter's cache.
+func (c *Converter) toSQL(ctx context.Context, filter string, aliases map[string]string) (string, error) {
+	key, literals, cacheable := c.cacheKey(filter, aliases, false)
+	if !cacheable {
+		node, err := c.build(ctx, filter, aliases, false)
+		if err != nil {
+			return "", err
+		}
+		if err := c.checkCost(filter, node); err != nil {
+			return "", err
+		}
+		return c.render(node), nil
+	}
+
+	e, node, ok := c.lookup(key, literals)
+	if !ok {
+		var err error
+		if node, err = c.build(ctx, filter, aliases, false); err != nil {
+			return "", err
+		}
+		e = newCacheEntry(node, literals)
+	}
+	if e.sql == nil {
+		// The cost depends on the shape of a filter, not on its literals, so it is checked
+		// once per entry.
+		if err := c.checkCost(filter, node); err != nil {
+			return "", err
+		}
+		e.sql = c.template(e)
+		c.cache.put(key, e)
+	}
+	return e.sql.fill(sqlWriter{c: c}, literals), nil
+}
+
+// parse builds the AST for a non-empty filter, through the converter's cache. Lambda operators
+// are accepted only when lambdas is set, since they cannot be rendered as SQL. Cached ASTs are
+// shared and must not be modified.
+func (c *Converter) parse(ctx context.Context, filter string, aliases map[string]string, lambdas bool) (ast.Node, error) {
+	key, literals, cacheable := c.cacheKey(filter, aliases, lambdas)
+	if cacheable {
+		if _, node, ok := c.lookup(key, literals); ok {
+			return node, nil
+		}
+	}
+
+	node, err := c.build(ctx, filter, aliases, lambdas)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		c.cache.put(key, newCacheEntry(node, literals))
+	}
+	return node, nil
+}
+
+// build parses a non-empty filter into an AST.
+func (c *Converter) build(ctx context.Context, filter string, aliases map[string]string, lambdas bool) (ast.Node, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	node, err := parser.BuildAST(filter, c.parserOptions(ctx, aliases, lambdas))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OData filter %q: %w", filter, err)
+	}
+	return node, nil
+}
+
+// check validates an AST built with the filter builder against the schema, the Authorizer
+// and the limits, like build does for the text of a filter, and returns it with its fields
+// rewritten by the Authorizer.
+func (c *Converter) check(ctx context.Context, node ast.Node, lambdas bool) (ast.Node, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	checked, err := parser.CheckAST(node, c.parserOptions(ctx, nil, lambdas))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", ast.Format(node), err)
+	}
+	return checked, nil
+}
+
+// parserOptions returns the options applying the converter's configuration while parsing.
+func (c *Converter) parserOptions(ctx context.Context, aliases map[string]string, lambdas bool) parser.Options {
+	opts := parser.Options{Aliases: aliases, Limits: c.limits, AuthorizeField: c.authorizeFunc(ctx), Lambdas: lambdas}
+	if c.schema != nil {
+		opts.CheckField = c.schema.checkField
+	}
+	return opts
+}
+
+// render converts an AST into SQL.
+func (c *Converter) render(node ast.Node) string {
+	return node.ToSQL(sqlWriter{c: c}, 0)
+}
+
+// sqlOperators maps OData comparison operators to SQL operators.
+var sqlOperators = map[string]string{
+	ast.OpEq: "=",
+	ast.OpNe: "!=",
+	ast.OpGt: ">",
+	ast.OpGe: ">=",
+	ast.OpLt: "<",
+	ast.OpLe: "<=",
+}
+
+// sqlWriter renders AST leaves according to a converter's configuration.
+type sqlWriter struct {
+	c *Converter
+	// template writes the string and number literals of an AST rendered for a SQL template,
+	// which hold slot markers, as is.
+	template bool
+}
+
+func (w sqlWriter) Condition(n *ast.ConditionNode) string {
+	column := w.column(n.Field)
+	if n.Value.Kind == ast.KindNull && w.c.nulls == NullAsIsNull {
+		switch n.Op {
+		case ast.OpEq:
+			return column + " IS NULL"
+		case ast.OpNe:
+			return column + " IS NOT NULL"
+		}
+	}
+	return fmt.Sprintf("%s %s %s", column, sqlOperators[n.Op], w.literal(n.Value))
+}
+
+func (w sqlWriter) In(n *ast.InNode) string {
+	values := make([]string, len(n.Values))
+	for i, v := range n.Values {
+		values[i] = w.literal(v)
+	}
+	return ast.JoinIn(w.column(n.Field), values)
+}
+
+// likeEscape is the escape character of the LIKE patterns generated for string functions.
+const likeEscape = `\`
+
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// Function renders a string function as a LIKE comparison whose pattern escapes the
+// wildcards of the argument, so only literal matches are found.
+func (w sqlWriter) Function(n *ast.FunctionNode) string {
+	return fmt.Sprintf("%s LIKE %s ESCAPE %s", w.column(n.Field), w.pattern(n.Name, n.Value), w.c.dialect.QuoteString(likeEscape))
+}
+
+// pattern returns the quoted LIKE pattern of a string function applied to v.
+func (w sqlWriter) pattern(function string, v ast.Literal) string {
+	if w.template {
+		return v.Value
+	}
+	pattern := likeEscaper.Replace(v.Value)
+	switch function {
+	case ast.FuncContains:
+		pattern = "%" + pattern + "%"
+	case ast.FuncStartsWith:
+		pattern += "%"
+	case ast.FuncEndsWith:
+		pattern = "%" + pattern
+	}
+	return w.c.dialect.QuoteString(pattern)
+}
+
+// isStringFunction reports whether op names a string function rather than an operator.
+func isStringFunction(op string) bool {
+	return op == ast.FuncContains || op == ast.FuncStartsWith || op == ast.FuncEndsWith
+}
+
+// column maps a property to its quoted SQL column.
+func (w sqlWriter) column(field string) string {
+	return w.c.quoteQualified(w.c.columnName(field))
+}
+
+// columnName returns the unquoted column of a property: its schema column, or its name mapped
+// with the naming strategy. Each segment of a path like "homeAddress/zipCode" is mapped
+// separately and the results are joined with '.', giving the qualified column
+// "home_address.zip_code" rather than a division. A schema ColumnPrefix replaces the mapping
+// of all but the last segment.
+func (c *Converter) columnName(field string) string {
+	segments := strings.Split(field, "/")
+	if c.schema != nil {
+		if p, ok := c.schema.Property(field); ok {
+			if p.Column != "" {
+				return p.Column
+			}
+			if p.ColumnPrefix != "" {
+				return p.ColumnPrefix + c.naming.ColumnName(segments[len(segments)-1])
+			}
+		}
+	}
+	for i, segment := range segments {
+		segments[i] = c.naming.ColumnName(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
+// quoteQualified quotes a possibly qualified identifier such as "addr.city" part by part.
+func (c *Converter) quoteQualified(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = c.dialect.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// literal renders a literal in the converter's dialect.
+func (w sqlWriter) literal(v ast.Literal) string {
+	if w.template && isSlot(v) {
+		return v.Value
+	}
+	switch v.Kind {
+	case ast.KindString:
+		return w.c.dialect.QuoteString(v.Value)
+	case ast.KindBoolean:
+		return w.c.dialect.FormatBool(v.Value == "true")
+	case ast.KindNull:
+		return "null"
+	default:
+		return v.Value
+	}
+}
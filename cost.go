@@ -0,0 +1,313 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// IndexKind is the kind of index on a property's column. See Property.Index.
+type IndexKind int
+
+const (
+	// NoIndex reports a column without an index. It is the default.
+	NoIndex IndexKind = iota
+	// BTreeIndex serves equality, "in", range comparisons, startswith and, with NullAsIsNull,
+	// "eq null".
+	BTreeIndex
+	// HashIndex serves equality and "in".
+	HashIndex
+	// TrigramIndex serves equality, "in" and all string functions, including contains and
+	// endswith, like a PostgreSQL GIN index with pg_trgm.
+	TrigramIndex
+)
+
+// ColumnStats holds statistics on a column, such as those collected by ANALYZE.
+type ColumnStats struct {
+	// Distinct is the number of distinct non-null values, or 0 if unknown.
+	Distinct int64
+	// NullFraction is the fraction of rows holding null, between 0 and 1.
+	NullFraction float64
+}
+
+// DefaultTableRows is the number of rows assumed by EstimateCost when CostModel.TableRows is 0.
+const DefaultTableRows = 1_000_000
+
+// Default selectivities of predicates on columns without statistics, as fractions of rows.
+const (
+	defaultEqSelectivity     = 0.005
+	defaultRangeSelectivity  = 1.0 / 3
+	defaultMatchSelectivity  = 0.05
+	defaultLambdaSelectivity = 0.5
+)
+
+// CostModel configures EstimateCost and the cost budget of SQL conversions.
+type CostModel struct {
+	// TableRows is the estimated number of rows in the table. Defaults to DefaultTableRows.
+	TableRows float64
+	// MaxCost is the largest estimated cost, in rows read, of the filters accepted by
+	// FilterToSQL, ExprToSQL and ParseQueryOptions. Filters above it are rejected with an
+	// *Error with code ErrCodeCostExceeded that explains the estimate. Zero disables the check.
+	MaxCost float64
+}
+
+// WithCostModel sets the table size used to estimate the cost of filters, and the cost budget
+// enforced when converting them to SQL. See EstimateCost.
+func WithCostModel(m CostModel) Option {
+	return func(c *Converter) {
+		c.cost = m
+	}
+}
+
+// CostEstimate is the estimated cost of running a filter. See Converter.EstimateCost.
+type CostEstimate struct {
+	// Selectivity is the estimated fraction of rows matching the filter.
+	Selectivity float64
+	// Rows is the estimated number of rows matching the filter.
+	Rows float64
+	// Cost is the estimated number of rows read: those found through indexes, or every row of
+	// the table for a scan.
+	Cost float64
+	// Scan reports whether the filter cannot be served by indexes and scans the table.
+	Scan bool
+	// NonSargable lists the predicates that cannot use an index, in filter order.
+	NonSargable []NonSargable
+}
+
+// NonSargable describes a predicate that cannot use an index.
+type NonSargable struct {
+	// Predicate is the predicate in canonical OData form, like "contains(name, 'x')".
+	Predicate string
+	// Field is the property the predicate applies to.
+	Field string
+	// Reason explains why no index serves the predicate.
+	Reason string
+}
+
+// EstimateCost estimates the cost of an OData filter with the default converter. See
+// Converter.EstimateCost.
+func EstimateCost(filter string) (*CostEstimate, error) {
+	return defaultConverter.EstimateCost(filter)
+}
+
+// EstimateCost estimates how many rows a database reads to run an OData filter, given the
+// Index and Stats of the schema's properties and the converter's CostModel, and lists the
+// predicates that cannot use an index, such as contains on a B-tree index (a leading
+// wildcard), "ne" comparisons, negations and comparisons of columns without an index.
+//
+// Selectivities come from the column statistics when available, and otherwise from fixed
+// defaults: "and" multiplies them and "or" adds them, assuming independent predicates. An
+// "and" is served by its cheapest indexed operand, and an "or" only when all of its operands
+// are indexed, by combining them; any other filter scans the table. The estimate is a rough
+// guide meant to reject pathological filters, not a query plan. An empty filter scans the
+// table.
+//
+// Example:
+//
+//	est, err := conv.EstimateCost("contains(name, 'x') and status eq 'a'")
+//	// est.Scan is false if status is indexed, and est.NonSargable lists contains(name, 'x')
+func (c *Converter) EstimateCost(filter string) (*CostEstimate, error) {
+	return c.EstimateCostContext(context.Background(), filter)
+}
+
+// EstimateCostContext is like EstimateCost, passing ctx to the converter's Authorizer.
+func (c *Converter) EstimateCostContext(ctx context.Context, filter string) (*CostEstimate, error) {
+	if strings.TrimSpace(filter) == "" {
+		rows := c.tableRows()
+		return &CostEstimate{Selectivity: 1, Rows: rows, Cost: rows, Scan: true}, nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.estimate(node), nil
+}
+
+// estimate computes the cost estimate of an AST.
+func (c *Converter) estimate(node ast.Node) *CostEstimate {
+	e := estimator{c: c, rows: c.tableRows(), est: &CostEstimate{}}
+	cost := e.walk(node, true)
+	e.est.Selectivity = cost.selectivity
+	e.est.Rows = cost.selectivity * e.rows
+	e.est.Cost = cost.rows
+	e.est.Scan = !cost.indexed
+	return e.est
+}
+
+// checkCost rejects an AST whose estimated cost exceeds the converter's budget.
+func (c *Converter) checkCost(filter string, node ast.Node) error {
+	if c.cost.MaxCost <= 0 {
+		return nil
+	}
+	est := c.estimate(node)
+	if est.Cost <= c.cost.MaxCost {
+		return nil
+	}
+
+	msg := fmt.Sprintf("estimated cost of %.0f rows exceeds the budget of %.0f", est.Cost, c.cost.MaxCost)
+	if est.Scan {
+		reasons := make([]string, len(est.NonSargable))
+		for i, n := range est.NonSargable {
+			reasons[i] = fmt.Sprintf("%s: %s", n.Predicate, n.Reason)
+		}
+		msg += "; the filter scans the table"
+		if len(reasons) > 0 {
+			msg += " (" + strings.Join(reasons, "; ") + ")"
+		}
+	}
+	return fmt.Errorf("invalid OData filter %q: %w", filter, &Error{Code: ErrCodeCostExceeded, Message: msg})
+}
+
+// tableRows returns the number of rows in the table assumed by the cost model.
+func (c *Converter) tableRows() float64 {
+	if c.cost.TableRows > 0 {
+		return c.cost.TableRows
+	}
+	return DefaultTableRows
+}
+
+// cost is the estimate of a subexpression: the fraction of rows it matches, and the rows read
+// to find them, through indexes when indexed is set.
+type cost struct {
+	selectivity float64
+	rows        float64
+	indexed     bool
+}
+
+// estimator computes cost estimates, recording non-sargable predicates.
+type estimator struct {
+	c    *Converter
+	rows float64
+	est  *CostEstimate
+}
+
+// walk estimates the cost of a node. Non-sargable predicates are recorded when record is set.
+func (e estimator) walk(node ast.Node, record bool) cost {
+	switch n := node.(type) {
+	case *ast.ParenNode:
+		return e.walk(n.Child, record)
+	case *ast.NotNode:
+		child := e.walk(n.Child, false)
+		if record {
+			e.nonSargable(n, "", "negated predicates cannot use an index")
+		}
+		return e.scan(1 - child.selectivity)
+	case *ast.BinaryNode:
+		left, right := e.walk(n.Left, record), e.walk(n.Right, record)
+		if n.Op == ast.OpAnd {
+			c := cost{selectivity: left.selectivity * right.selectivity, rows: e.rows}
+			for _, operand := range []cost{left, right} {
+				if operand.indexed && (!c.indexed || operand.rows < c.rows) {
+					c.rows, c.indexed = operand.rows, true
+				}
+			}
+			return c
+		}
+		selectivity := left.selectivity + right.selectivity - left.selectivity*right.selectivity
+		if !left.indexed || !right.indexed {
+			return e.scan(selectivity)
+		}
+		return cost{selectivity: selectivity, rows: math.Min(left.rows+right.rows, e.rows), indexed: true}
+	case *ast.ConditionNode:
+		return e.leaf(n, record, n.Field, n.Op, n.Value.Kind == ast.KindNull, 1)
+	case *ast.InNode:
+		return e.leaf(n, record, n.Field, ast.OpIn, false, len(n.Values))
+	case *ast.FunctionNode:
+		return e.leaf(n, record, n.Field, n.Name, false, 1)
+	case *ast.LambdaNode:
+		if record {
+			e.nonSargable(n, n.Field, "lambda operators scan the collection of every row")
+		}
+		return e.scan(defaultLambdaSelectivity)
+	}
+	return e.scan(1)
+}
+
+// leaf estimates a comparison or string function on a field with n values.
+func (e estimator) leaf(node ast.Node, record bool, field, op string, null bool, n int) cost {
+	var index IndexKind
+	stats := ColumnStats{}
+	if e.c.schema != nil {
+		if p, ok := e.c.schema.Property(field); ok {
+			index = p.Index
+			if p.Stats != nil {
+				stats = *p.Stats
+			}
+		}
+	}
+
+	eq := defaultEqSelectivity
+	if stats.Distinct > 0 {
+		eq = (1 - stats.NullFraction) / float64(stats.Distinct)
+	}
+	var selectivity float64
+	switch {
+	case null && e.c.nulls != NullAsIsNull:
+		selectivity = 0 // comparisons with null are never true
+	case null && op == ast.OpEq:
+		selectivity = stats.NullFraction
+	case null:
+		selectivity = 1 - stats.NullFraction
+	case op == ast.OpEq, op == ast.OpIn:
+		selectivity = math.Min(float64(n)*eq, 1-stats.NullFraction)
+	case op == ast.OpNe:
+		selectivity = math.Max(1-stats.NullFraction-eq, 0)
+	case isStringFunction(op):
+		selectivity = defaultMatchSelectivity
+	default:
+		selectivity = defaultRangeSelectivity * (1 - stats.NullFraction)
+	}
+
+	reason := unindexed(index, op, null, e.c.nulls)
+	if reason != "" {
+		if record {
+			e.nonSargable(node, field, reason)
+		}
+		return e.scan(selectivity)
+	}
+	return cost{selectivity: selectivity, rows: math.Max(selectivity*e.rows, 1), indexed: true}
+}
+
+// unindexed explains why an index cannot serve a predicate, or returns "" if it can.
+func unindexed(index IndexKind, op string, null bool, nulls NullHandling) string {
+	switch {
+	case index == NoIndex:
+		return "the column is not indexed"
+	case null && (op != ast.OpEq || nulls != NullAsIsNull):
+		return "the comparison with null cannot use an index"
+	case op == ast.OpNe:
+		return "ne comparisons cannot use an index"
+	case null && index != BTreeIndex:
+		return "only a B-tree index serves null checks"
+	}
+
+	switch index {
+	case BTreeIndex:
+		if op == ast.FuncContains || op == ast.FuncEndsWith {
+			return op + " matches with a leading wildcard, which a B-tree index cannot serve"
+		}
+	case HashIndex:
+		if op != ast.OpEq && op != ast.OpIn {
+			return "a hash index only serves eq and in"
+		}
+	case TrigramIndex:
+		if op != ast.OpEq && op != ast.OpIn && !isStringFunction(op) {
+			return "a trigram index cannot serve range comparisons"
+		}
+	}
+	return ""
+}
+
+// scan returns the cost of a subexpression that reads every row.
+func (e estimator) scan(selectivity float64) cost {
+	return cost{selectivity: selectivity, rows: e.rows}
+}
+
+// nonSargable records a predicate that cannot use an index.
+func (e estimator) nonSargable(node ast.Node, field, reason string) {
+	e.est.NonSargable = append(e.est.NonSargable, NonSargable{Predicate: ast.Format(node), Field: field, Reason: reason})
+}
@@ -0,0 +1,306 @@
+package csdl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/maxlambrecht/odatasql"
+)
+
+// Defaults used by FromSchema.
+const (
+	defaultNamespace  = "Default"
+	defaultEntityType = "Entity"
+	containerName     = "Container"
+)
+
+// WithNamespace sets the namespace of the generated types. It defaults to "Default".
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithEntityType sets the name of the generated entity type. It defaults to "Entity".
+func WithEntityType(name string) Option {
+	return func(o *options) {
+		o.entityType = name
+	}
+}
+
+// WithEntitySet sets the name of the generated entity set. It defaults to the entity type name.
+func WithEntitySet(name string) Option {
+	return func(o *options) {
+		o.entitySet = name
+	}
+}
+
+// WithKey sets the key properties of the generated entity type. By default the key is the
+// top-level property named "id" (in any case) or, failing that, the first top-level property.
+func WithKey(properties ...string) Option {
+	return func(o *options) {
+		o.key = properties
+	}
+}
+
+// FromSchema describes a schema as a CSDL model with one entity type, exposed through an
+// entity set in a container named "Container".
+//
+// Property paths are turned back into structured types: a path prefix whose columns are
+// qualified by a different table than its parent's ("customer/name" mapped to "customer.name")
+// becomes a navigation property to an entity type, and any other prefix ("address/city" mapped
+// to "address_city") becomes a complex type. Enum properties reference an enum type named
+// after EnumType.Name, and properties without a type are described as Edm.String. Key
+// properties are written as non-nullable, like NotNull properties and the structured
+// properties holding them.
+//
+// The entity set lists the properties that are not filterable or not sortable, which the
+// writers emit as Capabilities.FilterRestrictions and SortRestrictions annotations.
+//
+// Example:
+//
+//	model, err := csdl.FromSchema(conv.Schema(), csdl.WithNamespace("Sales"), csdl.WithEntityType("Customer"))
+//	if err != nil { ... }
+//	err = model.WriteXML(w)
+func FromSchema(schema *odatasql.Schema, opts ...Option) (*Model, error) {
+	if schema == nil {
+		return nil, errors.New("csdl: schema is nil")
+	}
+
+	o := options{namespace: defaultNamespace, entityType: defaultEntityType}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.entitySet == "" {
+		o.entitySet = o.entityType
+	}
+
+	root := &typeNode{}
+	set := EntitySet{Name: o.entitySet, EntityType: o.namespace + "." + o.entityType}
+	for _, p := range schema.Properties() {
+		root.add(strings.Split(p.Name, "/"), p)
+		if p.NonFilterable {
+			set.NonFilterable = append(set.NonFilterable, p.Name)
+		}
+		if p.NonSortable {
+			set.NonSortable = append(set.NonSortable, p.Name)
+		}
+	}
+
+	g := &generator{
+		model:      &Model{Namespaces: []Namespace{{Name: o.namespace}}},
+		namespace:  o.namespace,
+		typeNames:  map[string]bool{o.entityType: true},
+		enumsAdded: map[string]bool{},
+	}
+	if err := g.addEntityType(root, o.entityType, o.key); err != nil {
+		return nil, err
+	}
+	g.model.Container = &EntityContainer{Namespace: o.namespace, Name: containerName, Sets: []EntitySet{set}}
+	return g.model, nil
+}
+
+// typeNode groups the schema properties sharing a path prefix.
+type typeNode struct {
+	entries []typeEntry
+	index   map[string]*typeNode
+}
+
+// typeEntry is either a property (leaf) or a nested structured type (child), in schema order.
+type typeEntry struct {
+	name  string
+	leaf  *odatasql.Property
+	child *typeNode
+}
+
+func (n *typeNode) add(segments []string, p odatasql.Property) {
+	if len(segments) == 1 {
+		n.entries = append(n.entries, typeEntry{name: segments[0], leaf: &p})
+		return
+	}
+	child, ok := n.index[segments[0]]
+	if !ok {
+		child = &typeNode{}
+		if n.index == nil {
+			n.index = map[string]*typeNode{}
+		}
+		n.index[segments[0]] = child
+		n.entries = append(n.entries, typeEntry{name: segments[0], child: child})
+	}
+	child.add(segments[1:], p)
+}
+
+// table returns the table qualifying the columns of n: the part of the first column below n
+// before its last '.', or "" when that column is unqualified. Properties without a column are
+// qualified by their column prefix or, failing that, by their path like the converter does.
+func (n *typeNode) table() string {
+	for _, e := range n.entries {
+		if e.child != nil {
+			return e.child.table()
+		}
+		column := e.leaf.Column
+		if column == "" {
+			column = e.leaf.ColumnPrefix
+		}
+		if column == "" {
+			column = strings.ReplaceAll(e.leaf.Name, "/", ".")
+		}
+		if i := strings.LastIndex(column, "."); i >= 0 {
+			return column[:i]
+		}
+		return ""
+	}
+	return ""
+}
+
+// notNull reports whether some property below n is NotNull, which requires the structured
+// value holding it, and every one above, to be present.
+func (n *typeNode) notNull() bool {
+	for _, e := range n.entries {
+		if e.leaf != nil && e.leaf.NotNull || e.child != nil && e.child.notNull() {
+			return true
+		}
+	}
+	return false
+}
+
+type generator struct {
+	model      *Model
+	namespace  string
+	typeNames  map[string]bool
+	enumsAdded map[string]bool
+}
+
+// addEntityType adds an entity type for n, with the given key or the default one.
+func (g *generator) addEntityType(n *typeNode, name string, key []string) error {
+	t, err := g.structuredType(n, name)
+	if err != nil {
+		return err
+	}
+
+	if len(key) == 0 {
+		for _, p := range t.Properties {
+			if strings.EqualFold(p.Name, "id") {
+				key = []string{p.Name}
+				break
+			}
+		}
+	}
+	if len(key) == 0 {
+		for _, e := range n.entries {
+			if e.leaf != nil {
+				key = []string{e.name}
+				break
+			}
+		}
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("csdl: entity type %s has no property usable as key", name)
+	}
+
+	for _, k := range key {
+		found := false
+		for i := range t.Properties {
+			if t.Properties[i].Name == k && n.index[k] == nil {
+				t.Properties[i].Nullable = false
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("csdl: key property %q of entity type %s not found", k, name)
+		}
+	}
+	t.Key = key
+
+	ns := &g.model.Namespaces[0]
+	ns.EntityTypes = append(ns.EntityTypes, t)
+	return nil
+}
+
+// structuredType describes n, adding the types of its nested prefixes and enum properties.
+func (g *generator) structuredType(n *typeNode, name string) (StructuredType, error) {
+	t := StructuredType{Name: name}
+	table := n.table()
+
+	for _, e := range n.entries {
+		if e.leaf != nil {
+			t.Properties = append(t.Properties, Property{Name: e.name, Type: g.propertyType(e.leaf), Nullable: !e.leaf.NotNull})
+			continue
+		}
+
+		typeName := g.newTypeName(e.name)
+		qualified := g.namespace + "." + typeName
+		if e.child.table() != table {
+			if err := g.addEntityType(e.child, typeName, nil); err != nil {
+				return StructuredType{}, err
+			}
+			t.NavigationProperties = append(t.NavigationProperties, NavigationProperty{Name: e.name, Type: qualified, Nullable: !e.child.notNull()})
+			continue
+		}
+
+		complexType, err := g.structuredType(e.child, typeName)
+		if err != nil {
+			return StructuredType{}, err
+		}
+		ns := &g.model.Namespaces[0]
+		ns.ComplexTypes = append(ns.ComplexTypes, complexType)
+		t.Properties = append(t.Properties, Property{Name: e.name, Type: qualified, Nullable: !e.child.notNull()})
+	}
+	return t, nil
+}
+
+// propertyType returns the qualified type of a property, adding its enum type if needed.
+func (g *generator) propertyType(p *odatasql.Property) string {
+	if p.Enum == nil {
+		if p.Type == "" {
+			return string(odatasql.EdmString)
+		}
+		return string(p.Type)
+	}
+
+	namespace, name := g.namespace, p.Enum.Name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+	}
+	qualified := namespace + "." + name
+	if g.enumsAdded[qualified] {
+		return qualified
+	}
+	g.enumsAdded[qualified] = true
+
+	enum := EnumType{Name: name}
+	for i, m := range p.Enum.Members {
+		enum.Members = append(enum.Members, EnumMember{Name: m, Value: int64(i)})
+	}
+	ns := g.namespaceNamed(namespace)
+	ns.EnumTypes = append(ns.EnumTypes, enum)
+	return qualified
+}
+
+// namespaceNamed returns the namespace with the given name, adding it if needed.
+func (g *generator) namespaceNamed(name string) *Namespace {
+	for i := range g.model.Namespaces {
+		if g.model.Namespaces[i].Name == name {
+			return &g.model.Namespaces[i]
+		}
+	}
+	g.model.Namespaces = append(g.model.Namespaces, Namespace{Name: name})
+	return &g.model.Namespaces[len(g.model.Namespaces)-1]
+}
+
+// newTypeName derives a unique type name from a path segment: "homeAddress" becomes
+// "HomeAddress", then "HomeAddress2" if that name is taken.
+func (g *generator) newTypeName(segment string) string {
+	r, size := utf8.DecodeRuneInString(segment)
+	base := string(unicode.ToUpper(r)) + segment[size:]
+	name := base
+	for i := 2; g.typeNames[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.typeNames[name] = true
+	return name
+}
@@ -0,0 +1,418 @@
+package csdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	jsonKind       = "$Kind"
+	jsonType       = "$Type"
+	jsonCollection = "$Collection"
+	jsonNullable   = "$Nullable"
+	jsonKey        = "$Key"
+	jsonBaseType   = "$BaseType"
+	jsonAlias      = "$Alias"
+	jsonVersion    = "$Version"
+	jsonReference  = "$Reference"
+	jsonInclude    = "$Include"
+	jsonNamespace  = "$Namespace"
+	jsonContainer  = "$EntityContainer"
+
+	kindEntityType         = "EntityType"
+	kindComplexType        = "ComplexType"
+	kindEnumType           = "EnumType"
+	kindNavigationProperty = "NavigationProperty"
+	kindEntityContainer    = "EntityContainer"
+
+	defaultJSONType = "Edm.String"
+)
+
+// member is one name/value pair of a JSON object, kept in document order.
+type member struct {
+	name  string
+	value json.RawMessage
+}
+
+// ParseJSON reads a CSDL document in the JSON representation.
+func ParseJSON(r io.Reader) (*Model, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("csdl: %w", err)
+	}
+	root, err := decodeObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("csdl: invalid JSON document: %w", err)
+	}
+
+	m := &Model{}
+	for _, entry := range root {
+		if isReserved(entry.name) {
+			continue // $Version, $EntityContainer, $Reference, annotations
+		}
+		ns, container, err := parseJSONNamespace(entry.name, entry.value)
+		if err != nil {
+			return nil, err
+		}
+		if container != nil && m.Container == nil {
+			m.Container = container
+		}
+		m.Namespaces = append(m.Namespaces, ns)
+	}
+	return m, nil
+}
+
+func parseJSONNamespace(name string, data json.RawMessage) (Namespace, *EntityContainer, error) {
+	members, err := decodeObject(data)
+	if err != nil {
+		return Namespace{}, nil, fmt.Errorf("csdl: namespace %s: %w", name, err)
+	}
+
+	ns := Namespace{Name: name}
+	var container *EntityContainer
+	for _, m := range members {
+		if m.name == jsonAlias {
+			if err := json.Unmarshal(m.value, &ns.Alias); err != nil {
+				return Namespace{}, nil, fmt.Errorf("csdl: namespace %s: invalid %s: %w", name, jsonAlias, err)
+			}
+			continue
+		}
+		if isReserved(m.name) || !bytes.HasPrefix(bytes.TrimSpace(m.value), []byte("{")) {
+			continue
+		}
+
+		element, err := decodeObject(m.value)
+		if err != nil {
+			return Namespace{}, nil, fmt.Errorf("csdl: %s.%s: %w", name, m.name, err)
+		}
+		switch stringMember(element, jsonKind) {
+		case kindEntityType:
+			t, err := parseJSONStructure(m.name, element)
+			if err != nil {
+				return Namespace{}, nil, fmt.Errorf("csdl: %s.%s: %w", name, m.name, err)
+			}
+			ns.EntityTypes = append(ns.EntityTypes, t)
+		case kindComplexType:
+			t, err := parseJSONStructure(m.name, element)
+			if err != nil {
+				return Namespace{}, nil, fmt.Errorf("csdl: %s.%s: %w", name, m.name, err)
+			}
+			ns.ComplexTypes = append(ns.ComplexTypes, t)
+		case kindEnumType:
+			e, err := parseJSONEnum(m.name, element)
+			if err != nil {
+				return Namespace{}, nil, fmt.Errorf("csdl: %s.%s: %w", name, m.name, err)
+			}
+			ns.EnumTypes = append(ns.EnumTypes, e)
+		case kindEntityContainer:
+			if container == nil {
+				container = parseJSONContainer(name, m.name, element)
+			}
+		}
+	}
+	return ns, container, nil
+}
+
+// parseJSONContainer reads the entity sets of a container and their capability restrictions.
+func parseJSONContainer(namespace, name string, members []member) *EntityContainer {
+	c := &EntityContainer{Namespace: namespace, Name: name}
+	for _, m := range members {
+		if isReserved(m.name) {
+			continue
+		}
+		element, err := decodeObject(m.value)
+		if err != nil || !boolMember(element, jsonCollection) {
+			continue // singletons, function and action imports
+		}
+
+		set := EntitySet{Name: m.name, EntityType: stringMember(element, jsonType)}
+		for _, e := range element {
+			term, ok := strings.CutPrefix(e.name, "@")
+			if !ok {
+				continue
+			}
+			switch {
+			case isTerm(term, "FilterRestrictions"):
+				set.NonFilterable = append(set.NonFilterable, recordPaths(e.value, nonFilterableProperties)...)
+			case isTerm(term, "SortRestrictions"):
+				set.NonSortable = append(set.NonSortable, recordPaths(e.value, nonSortableProperties)...)
+			}
+		}
+		c.Sets = append(c.Sets, set)
+	}
+	return c
+}
+
+func parseJSONStructure(name string, members []member) (StructuredType, error) {
+	t := StructuredType{Name: name, BaseType: stringMember(members, jsonBaseType)}
+	for _, m := range members {
+		if m.name == jsonKey {
+			key, err := parseJSONKey(m.value)
+			if err != nil {
+				return StructuredType{}, err
+			}
+			t.Key = key
+			continue
+		}
+		if isReserved(m.name) {
+			continue
+		}
+
+		prop, err := decodeObject(m.value)
+		if err != nil {
+			return StructuredType{}, fmt.Errorf("property %s: %w", m.name, err)
+		}
+		typ := stringMember(prop, jsonType)
+		collection := boolMember(prop, jsonCollection)
+		nullable := boolMember(prop, jsonNullable) // JSON properties are not nullable by default
+
+		if stringMember(prop, jsonKind) == kindNavigationProperty {
+			t.NavigationProperties = append(t.NavigationProperties, NavigationProperty{
+				Name: m.name, Type: typ, Collection: collection, Nullable: nullable && !collection,
+			})
+			continue
+		}
+		if typ == "" {
+			typ = defaultJSONType
+		}
+		t.Properties = append(t.Properties, Property{Name: m.name, Type: typ, Collection: collection, Nullable: nullable})
+	}
+	return t, nil
+}
+
+// recordPaths returns the property paths listed by the given property of an annotation record.
+func recordPaths(data json.RawMessage, property string) []string {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil
+	}
+	var paths []string
+	_ = json.Unmarshal(record[property], &paths)
+	return paths
+}
+
+// parseJSONKey reads $Key, whose entries are property names or {"alias": "path"} objects.
+func parseJSONKey(data json.RawMessage) ([]string, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", jsonKey, err)
+	}
+
+	var key []string
+	for _, e := range entries {
+		var name string
+		if err := json.Unmarshal(e, &name); err == nil {
+			key = append(key, name)
+			continue
+		}
+		var aliased map[string]string
+		if err := json.Unmarshal(e, &aliased); err != nil {
+			return nil, fmt.Errorf("invalid %s entry %s", jsonKey, e)
+		}
+		for _, path := range aliased {
+			key = append(key, path)
+		}
+	}
+	return key, nil
+}
+
+func parseJSONEnum(name string, members []member) (EnumType, error) {
+	e := EnumType{Name: name}
+	for _, m := range members {
+		if isReserved(m.name) {
+			continue
+		}
+		var value int64
+		if err := json.Unmarshal(m.value, &value); err != nil {
+			return EnumType{}, fmt.Errorf("member %s has invalid value %s", m.name, m.value)
+		}
+		e.Members = append(e.Members, EnumMember{Name: m.name, Value: value})
+	}
+	return e, nil
+}
+
+// WriteJSON writes m as a CSDL JSON document, referencing the Capabilities vocabulary for the
+// restrictions of its entity sets.
+func (m *Model) WriteJSON(w io.Writer) error {
+	include := object{{jsonNamespace, capabilitiesNamespace}, {jsonAlias, capabilitiesAlias}}
+	doc := object{
+		{jsonVersion, odataVersion},
+		{jsonReference, object{{capabilitiesURI, object{{jsonInclude, []object{include}}}}}},
+	}
+	if c := m.Container; c != nil {
+		doc = append(doc, field{jsonContainer, c.Namespace + "." + c.Name})
+	}
+
+	for _, ns := range m.Namespaces {
+		schema := object{}
+		if ns.Alias != "" {
+			schema = append(schema, field{jsonAlias, ns.Alias})
+		}
+		for _, e := range ns.EnumTypes {
+			enum := object{{jsonKind, kindEnumType}}
+			for _, member := range e.Members {
+				enum = append(enum, field{member.Name, member.Value})
+			}
+			schema = append(schema, field{e.Name, enum})
+		}
+		for _, t := range ns.ComplexTypes {
+			schema = append(schema, field{t.Name, structureToJSON(kindComplexType, t)})
+		}
+		for _, t := range ns.EntityTypes {
+			schema = append(schema, field{t.Name, structureToJSON(kindEntityType, t)})
+		}
+		if c := m.Container; c != nil && c.Namespace == ns.Name {
+			schema = append(schema, field{c.Name, containerToJSON(c)})
+		}
+		doc = append(doc, field{ns.Name, schema})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("csdl: %w", err)
+	}
+	return nil
+}
+
+func structureToJSON(kind string, t StructuredType) object {
+	o := object{{jsonKind, kind}}
+	if t.BaseType != "" {
+		o = append(o, field{jsonBaseType, t.BaseType})
+	}
+	if len(t.Key) > 0 {
+		o = append(o, field{jsonKey, t.Key})
+	}
+	for _, p := range t.Properties {
+		o = append(o, field{p.Name, propertyToJSON("", p.Type, p.Collection, p.Nullable)})
+	}
+	for _, p := range t.NavigationProperties {
+		o = append(o, field{p.Name, propertyToJSON(kindNavigationProperty, p.Type, p.Collection, p.Nullable)})
+	}
+	return o
+}
+
+// propertyToJSON omits the members that have their default value: Edm.String for the type of
+// structural properties and false for $Collection and $Nullable.
+func propertyToJSON(kind, typ string, collection, nullable bool) object {
+	o := object{}
+	if kind != "" {
+		o = append(o, field{jsonKind, kind})
+	}
+	if typ != defaultJSONType || kind != "" {
+		o = append(o, field{jsonType, typ})
+	}
+	if collection {
+		o = append(o, field{jsonCollection, true})
+	} else if nullable {
+		o = append(o, field{jsonNullable, true})
+	}
+	return o
+}
+
+func containerToJSON(c *EntityContainer) object {
+	o := object{{jsonKind, kindEntityContainer}}
+	for _, set := range c.Sets {
+		s := object{{jsonCollection, true}, {jsonType, set.EntityType}}
+		if len(set.NonFilterable) > 0 {
+			s = append(s, field{"@" + filterRestrictions, object{{nonFilterableProperties, set.NonFilterable}}})
+		}
+		if len(set.NonSortable) > 0 {
+			s = append(s, field{"@" + sortRestrictions, object{{nonSortableProperties, set.NonSortable}}})
+		}
+		o = append(o, field{set.Name, s})
+	}
+	return o
+}
+
+// object is a JSON object that keeps its members in order when marshaled.
+type object []field
+
+type field struct {
+	name  string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeObject decodes a JSON object into its members, preserving their order.
+func decodeObject(data []byte) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	var members []member
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, member{name: name, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// isReserved reports whether a member name is a $-prefixed keyword or an annotation.
+func isReserved(name string) bool {
+	return strings.HasPrefix(name, "$") || strings.Contains(name, "@")
+}
+
+func stringMember(members []member, name string) string {
+	for _, m := range members {
+		if m.name == name {
+			var s string
+			_ = json.Unmarshal(m.value, &s)
+			return s
+		}
+	}
+	return ""
+}
+
+func boolMember(members []member, name string) bool {
+	for _, m := range members {
+		if m.name == name {
+			var b bool
+			_ = json.Unmarshal(m.value, &b)
+			return b
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+// Package csdl reads and writes OData CSDL ($metadata) documents and converts between them
+// and odatasql schemas.
+package csdl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Model is the subset of a CSDL document describing entity, complex and enum types.
+type Model struct {
+	Namespaces []Namespace
+	// Container is the entity container, if the document declares one.
+	Container *EntityContainer
+}
+
+// EntityContainer groups the entity sets exposed by a service.
+type EntityContainer struct {
+	Namespace string
+	Name      string
+	Sets      []EntitySet
+}
+
+// EntitySet is a collection of entities of one entity type, with its query capabilities.
+type EntitySet struct {
+	Name       string
+	EntityType string // qualified name of the entity type
+	// NonFilterable and NonSortable list property paths that cannot be used in $filter and
+	// $orderby. They are written as Capabilities.FilterRestrictions and SortRestrictions.
+	NonFilterable []string
+	NonSortable   []string
+}
+
+// Namespace is a CSDL Schema element: a namespace with its types.
+type Namespace struct {
+	Name         string
+	Alias        string
+	EntityTypes  []StructuredType
+	ComplexTypes []StructuredType
+	EnumTypes    []EnumType
+}
+
+// StructuredType is an EntityType or ComplexType.
+type StructuredType struct {
+	Name                 string
+	BaseType             string   // qualified name of the base type, if any
+	Key                  []string // names of the key properties (entity types only)
+	Properties           []Property
+	NavigationProperties []NavigationProperty
+}
+
+// Property is a structural property of a structured type.
+type Property struct {
+	Name       string
+	Type       string // qualified type name, e.g. "Edm.String"; for collections, the element type
+	Collection bool
+	Nullable   bool
+}
+
+// NavigationProperty relates an entity type to another entity type.
+type NavigationProperty struct {
+	Name       string
+	Type       string // qualified name of the target entity type; for collections, the element type
+	Collection bool
+	Nullable   bool
+}
+
+// EnumType is an enumeration type.
+type EnumType struct {
+	Name    string
+	Members []EnumMember
+}
+
+// EnumMember is a named member of an enumeration type.
+type EnumMember struct {
+	Name  string
+	Value int64
+}
+
+// Parse reads a CSDL document in either the XML or the JSON representation.
+func Parse(data []byte) (*Model, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return ParseXML(bytes.NewReader(trimmed))
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return ParseJSON(bytes.NewReader(trimmed))
+	default:
+		return nil, fmt.Errorf("csdl: document is neither XML nor JSON")
+	}
+}
+
+// LoadFile reads a CSDL document from a local file in either the XML or the JSON representation.
+func LoadFile(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("csdl: %w", err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w (in %s)", err, path)
+	}
+	return m, nil
+}
+
+// kind identifies what a qualified type name refers to.
+type kind int
+
+const (
+	kindUnknown kind = iota
+	kindEntity
+	kindComplex
+	kindEnum
+)
+
+// lookup resolves a qualified type name, which may use a namespace alias.
+func (m *Model) lookup(qualified string) (kind, *StructuredType, *EnumType, string) {
+	i := strings.LastIndex(qualified, ".")
+	if i < 0 {
+		return kindUnknown, nil, nil, ""
+	}
+	ns, name := qualified[:i], qualified[i+1:]
+
+	for n := range m.Namespaces {
+		namespace := &m.Namespaces[n]
+		if namespace.Name != ns && (namespace.Alias == "" || namespace.Alias != ns) {
+			continue
+		}
+		full := namespace.Name + "." + name
+		for i := range namespace.EntityTypes {
+			if namespace.EntityTypes[i].Name == name {
+				return kindEntity, &namespace.EntityTypes[i], nil, full
+			}
+		}
+		for i := range namespace.ComplexTypes {
+			if namespace.ComplexTypes[i].Name == name {
+				return kindComplex, &namespace.ComplexTypes[i], nil, full
+			}
+		}
+		for i := range namespace.EnumTypes {
+			if namespace.EnumTypes[i].Name == name {
+				return kindEnum, nil, &namespace.EnumTypes[i], full
+			}
+		}
+	}
+	return kindUnknown, nil, nil, ""
+}
+
+// findEntityType resolves an entity type by qualified name, or by simple name when it is
+// unique across namespaces.
+func (m *Model) findEntityType(name string) (*StructuredType, string, error) {
+	if k, t, _, full := m.lookup(name); k == kindEntity {
+		return t, full, nil
+	}
+
+	var found *StructuredType
+	var full string
+	for n := range m.Namespaces {
+		namespace := &m.Namespaces[n]
+		for i := range namespace.EntityTypes {
+			if namespace.EntityTypes[i].Name != name {
+				continue
+			}
+			if found != nil {
+				return nil, "", fmt.Errorf("csdl: entity type %q is ambiguous, use its qualified name", name)
+			}
+			found, full = &namespace.EntityTypes[i], namespace.Name+"."+name
+		}
+	}
+	if found == nil {
+		return nil, "", fmt.Errorf("csdl: entity type %q not found", name)
+	}
+	return found, full, nil
+}
@@ -0,0 +1,177 @@
+package csdl
+
+import (
+	"fmt"
+
+	"github.com/maxlambrecht/odatasql"
+)
+
+// ColumnMapper maps a property path, such as "name" or "address/city", to its SQL column.
+// Returning "" keeps the default mapping.
+type ColumnMapper func(path string) string
+
+// Option configures how a Model is converted into a schema, or a schema into a Model.
+type Option func(*options)
+
+type options struct {
+	columns    ColumnMapper
+	naming     odatasql.NamingStrategy
+	namespace  string
+	entityType string
+	entitySet  string
+	key        []string
+}
+
+// WithColumnMapper sets the hook mapping property paths to SQL columns.
+//
+// By default top-level properties have no explicit column, so the converter's naming strategy
+// applies; properties of complex types map to "<complex>_<property>" and properties reached
+// through single-valued navigation properties map to "<navigation>.<property>", qualified by
+// the aliases of every joined table along the path ("customer.account.id"). The names are
+// mapped with the strategy set by WithNamingStrategy.
+func WithColumnMapper(m ColumnMapper) Option {
+	return func(o *options) {
+		o.columns = m
+	}
+}
+
+// WithNamingStrategy sets the strategy mapping the names of complex types, navigation
+// properties and their properties to column prefixes, table aliases and columns. It should
+// match the converter's strategy and defaults to odatasql.SnakeCase.
+func WithNamingStrategy(n odatasql.NamingStrategy) Option {
+	return func(o *options) {
+		if n != nil {
+			o.naming = n
+		}
+	}
+}
+
+// LoadSchema reads the CSDL document at path and builds the schema of the given entity type.
+func LoadSchema(path, entityType string, opts ...Option) (*odatasql.Schema, error) {
+	m, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return m.Schema(entityType, opts...)
+}
+
+// Schema builds the schema of an entity type, given by qualified name ("Sales.Customer") or by
+// simple name when it is unique.
+//
+// Properties of complex types and of entities reached through single-valued navigation
+// properties are included as paths ("address/city", "customer/name"). Collection-valued
+// properties and properties of unsupported types (e.g. Edm.Binary or geography types) are
+// skipped, as they cannot be compared in a filter. Enum properties accept their member names.
+// Properties are NotNull when they and every complex or navigation property on their path are
+// declared non-nullable, since a null complex value or a missing related entity (a LEFT JOIN
+// without a match) makes them null.
+func (m *Model) Schema(entityType string, opts ...Option) (*odatasql.Schema, error) {
+	o := options{naming: odatasql.SnakeCase}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	t, full, err := m.findEntityType(entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &schemaBuilder{model: m, opts: o, visiting: map[string]bool{}}
+	if err := b.addStructured(t, full, "", "", "", false); err != nil {
+		return nil, err
+	}
+	return odatasql.NewSchema(b.properties...)
+}
+
+type schemaBuilder struct {
+	model      *Model
+	opts       options
+	visiting   map[string]bool
+	properties []odatasql.Property
+}
+
+// addStructured adds the properties of t (including inherited ones) below the path prefix.
+// Columns are prefixed with columnPrefix (complex types) and qualified with table (navigation).
+// nullable reports whether a property along the path prefix may be null.
+func (b *schemaBuilder) addStructured(t *StructuredType, full, pathPrefix, columnPrefix, table string, nullable bool) error {
+	if b.visiting[full] {
+		return nil // cyclic navigation or inheritance
+	}
+	b.visiting[full] = true
+	defer delete(b.visiting, full)
+
+	if t.BaseType != "" {
+		k, base, _, baseFull := b.model.lookup(t.BaseType)
+		if k != kindEntity && k != kindComplex {
+			return fmt.Errorf("csdl: base type %q of %s not found", t.BaseType, full)
+		}
+		if err := b.addStructured(base, baseFull, pathPrefix, columnPrefix, table, nullable); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range t.Properties {
+		if p.Collection {
+			continue
+		}
+		path := pathPrefix + p.Name
+		notNull := !nullable && !p.Nullable
+
+		if edm := odatasql.EdmType(p.Type); edm.Supported() {
+			b.add(odatasql.Property{Name: path, Type: edm, NotNull: notNull}, p.Name, pathPrefix, columnPrefix, table)
+			continue
+		}
+
+		switch k, complexType, enum, typeFull := b.model.lookup(p.Type); k {
+		case kindEnum:
+			members := make([]string, len(enum.Members))
+			for i, member := range enum.Members {
+				members[i] = member.Name
+			}
+			prop := odatasql.Property{
+				Name:    path,
+				Type:    odatasql.EdmString,
+				NotNull: notNull,
+				Enum:    &odatasql.EnumType{Name: typeFull, Members: members},
+			}
+			b.add(prop, p.Name, pathPrefix, columnPrefix, table)
+		case kindComplex:
+			prefix := columnPrefix + b.opts.naming.ColumnName(p.Name) + "_"
+			if err := b.addStructured(complexType, typeFull, path+"/", prefix, table, !notNull); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, nav := range t.NavigationProperties {
+		if nav.Collection {
+			continue
+		}
+		k, target, _, targetFull := b.model.lookup(nav.Type)
+		if k != kindEntity {
+			return fmt.Errorf("csdl: navigation property %s of %s has unknown type %q", nav.Name, full, nav.Type)
+		}
+		alias := b.opts.naming.ColumnName(nav.Name)
+		if table != "" {
+			alias = table + "." + alias
+		}
+		if err := b.addStructured(target, targetFull, pathPrefix+nav.Name+"/", "", alias, nullable || nav.Nullable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add appends a property, resolving its column through the mapper or the default mapping.
+func (b *schemaBuilder) add(p odatasql.Property, name, pathPrefix, columnPrefix, table string) {
+	if b.opts.columns != nil {
+		p.Column = b.opts.columns(p.Name)
+	}
+	if p.Column == "" && pathPrefix != "" {
+		p.Column = columnPrefix + b.opts.naming.ColumnName(name)
+		if table != "" {
+			p.Column = table + "." + p.Column
+		}
+	}
+	b.properties = append(b.properties, p)
+}
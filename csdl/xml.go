@@ -0,0 +1,323 @@
+package csdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const collectionPrefix = "Collection("
+
+// Namespaces and vocabulary references used when writing XML documents.
+const (
+	edmxNamespace         = "http://docs.oasis-open.org/odata/ns/edmx"
+	edmNamespace          = "http://docs.oasis-open.org/odata/ns/edm"
+	capabilitiesNamespace = "Org.OData.Capabilities.V1"
+	capabilitiesAlias     = "Capabilities"
+	capabilitiesURI       = "https://oasis-tcs.github.io/odata-vocabularies/vocabularies/Org.OData.Capabilities.V1.xml"
+	odataVersion          = "4.0"
+
+	filterRestrictions      = capabilitiesAlias + ".FilterRestrictions"
+	sortRestrictions        = capabilitiesAlias + ".SortRestrictions"
+	nonFilterableProperties = "NonFilterableProperties"
+	nonSortableProperties   = "NonSortableProperties"
+)
+
+// xmlEdmx mirrors the edmx:Edmx root element of a CSDL XML document.
+type xmlEdmx struct {
+	XMLName      xml.Name `xml:"Edmx"`
+	DataServices struct {
+		Schemas []xmlSchema `xml:"Schema"`
+	} `xml:"DataServices"`
+}
+
+// xmlEdmxOut is the edmx:Edmx root element written by WriteXML. encoding/xml cannot decode
+// prefixed names, so reading and writing use different root types.
+type xmlEdmxOut struct {
+	XMLName      xml.Name          `xml:"edmx:Edmx"`
+	Xmlns        string            `xml:"xmlns:edmx,attr"`
+	Version      string            `xml:"Version,attr"`
+	References   []xmlReferenceOut `xml:"edmx:Reference"`
+	DataServices struct {
+		Schemas []xmlSchema `xml:"Schema"`
+	} `xml:"edmx:DataServices"`
+}
+
+type xmlReferenceOut struct {
+	URI     string `xml:"Uri,attr"`
+	Include struct {
+		Namespace string `xml:"Namespace,attr"`
+		Alias     string `xml:"Alias,attr"`
+	} `xml:"edmx:Include"`
+}
+
+type xmlSchema struct {
+	Xmlns           string         `xml:"xmlns,attr,omitempty"`
+	Namespace       string         `xml:"Namespace,attr"`
+	Alias           string         `xml:"Alias,attr,omitempty"`
+	EnumTypes       []xmlEnumType  `xml:"EnumType"`
+	ComplexTypes    []xmlStructure `xml:"ComplexType"`
+	EntityTypes     []xmlStructure `xml:"EntityType"`
+	EntityContainer *xmlContainer  `xml:"EntityContainer"`
+}
+
+type xmlStructure struct {
+	Name                 string        `xml:"Name,attr"`
+	BaseType             string        `xml:"BaseType,attr,omitempty"`
+	Key                  *xmlKey       `xml:"Key"`
+	Properties           []xmlProperty `xml:"Property"`
+	NavigationProperties []xmlProperty `xml:"NavigationProperty"`
+}
+
+type xmlKey struct {
+	PropertyRefs []xmlPropertyRef `xml:"PropertyRef"`
+}
+
+type xmlPropertyRef struct {
+	Name string `xml:"Name,attr"`
+}
+
+type xmlProperty struct {
+	Name     string `xml:"Name,attr"`
+	Type     string `xml:"Type,attr"`
+	Nullable string `xml:"Nullable,attr,omitempty"`
+}
+
+type xmlEnumType struct {
+	Name    string          `xml:"Name,attr"`
+	Members []xmlEnumMember `xml:"Member"`
+}
+
+type xmlEnumMember struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+type xmlContainer struct {
+	Name       string         `xml:"Name,attr"`
+	EntitySets []xmlEntitySet `xml:"EntitySet"`
+}
+
+type xmlEntitySet struct {
+	Name        string          `xml:"Name,attr"`
+	EntityType  string          `xml:"EntityType,attr"`
+	Annotations []xmlAnnotation `xml:"Annotation"`
+}
+
+// xmlAnnotation is a capability annotation whose record lists property paths.
+type xmlAnnotation struct {
+	Term   string `xml:"Term,attr"`
+	Record struct {
+		PropertyValues []xmlPropertyValue `xml:"PropertyValue"`
+	} `xml:"Record"`
+}
+
+type xmlPropertyValue struct {
+	Property      string   `xml:"Property,attr"`
+	PropertyPaths []string `xml:"Collection>PropertyPath"`
+}
+
+// ParseXML reads a CSDL document in the XML representation (an edmx:Edmx element).
+func ParseXML(r io.Reader) (*Model, error) {
+	var doc xmlEdmx
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("csdl: invalid XML document: %w", err)
+	}
+
+	m := &Model{}
+	for _, s := range doc.DataServices.Schemas {
+		ns := Namespace{Name: s.Namespace, Alias: s.Alias}
+		for _, t := range s.EntityTypes {
+			ns.EntityTypes = append(ns.EntityTypes, t.toStructuredType())
+		}
+		for _, t := range s.ComplexTypes {
+			ns.ComplexTypes = append(ns.ComplexTypes, t.toStructuredType())
+		}
+		for _, e := range s.EnumTypes {
+			enum, err := e.toEnumType()
+			if err != nil {
+				return nil, err
+			}
+			ns.EnumTypes = append(ns.EnumTypes, enum)
+		}
+		if c := s.EntityContainer; c != nil && m.Container == nil {
+			m.Container = c.toEntityContainer(s.Namespace)
+		}
+		m.Namespaces = append(m.Namespaces, ns)
+	}
+	return m, nil
+}
+
+// WriteXML writes m as a CSDL XML document, referencing the Capabilities vocabulary for the
+// restrictions of its entity sets.
+func (m *Model) WriteXML(w io.Writer) error {
+	doc := xmlEdmxOut{Xmlns: edmxNamespace, Version: odataVersion}
+	ref := xmlReferenceOut{URI: capabilitiesURI}
+	ref.Include.Namespace, ref.Include.Alias = capabilitiesNamespace, capabilitiesAlias
+	doc.References = append(doc.References, ref)
+
+	for _, ns := range m.Namespaces {
+		s := xmlSchema{Xmlns: edmNamespace, Namespace: ns.Name, Alias: ns.Alias}
+		for _, e := range ns.EnumTypes {
+			s.EnumTypes = append(s.EnumTypes, enumToXML(e))
+		}
+		for _, t := range ns.ComplexTypes {
+			s.ComplexTypes = append(s.ComplexTypes, structureToXML(t))
+		}
+		for _, t := range ns.EntityTypes {
+			s.EntityTypes = append(s.EntityTypes, structureToXML(t))
+		}
+		if c := m.Container; c != nil && c.Namespace == ns.Name {
+			s.EntityContainer = containerToXML(c)
+		}
+		doc.DataServices.Schemas = append(doc.DataServices.Schemas, s)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("csdl: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("csdl: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func structureToXML(t StructuredType) xmlStructure {
+	x := xmlStructure{Name: t.Name, BaseType: t.BaseType}
+	if len(t.Key) > 0 {
+		x.Key = &xmlKey{}
+		for _, k := range t.Key {
+			x.Key.PropertyRefs = append(x.Key.PropertyRefs, xmlPropertyRef{Name: k})
+		}
+	}
+	for _, p := range t.Properties {
+		x.Properties = append(x.Properties, propertyToXML(p.Name, p.Type, p.Collection, p.Nullable))
+	}
+	for _, p := range t.NavigationProperties {
+		x.NavigationProperties = append(x.NavigationProperties, propertyToXML(p.Name, p.Type, p.Collection, p.Nullable))
+	}
+	return x
+}
+
+func propertyToXML(name, typ string, collection, nullable bool) xmlProperty {
+	p := xmlProperty{Name: name, Type: typ}
+	if collection {
+		p.Type = collectionPrefix + typ + ")"
+	} else if !nullable {
+		p.Nullable = "false"
+	}
+	return p
+}
+
+func enumToXML(e EnumType) xmlEnumType {
+	x := xmlEnumType{Name: e.Name}
+	for _, m := range e.Members {
+		x.Members = append(x.Members, xmlEnumMember{Name: m.Name, Value: strconv.FormatInt(m.Value, 10)})
+	}
+	return x
+}
+
+func containerToXML(c *EntityContainer) *xmlContainer {
+	x := &xmlContainer{Name: c.Name}
+	for _, set := range c.Sets {
+		xs := xmlEntitySet{Name: set.Name, EntityType: set.EntityType}
+		if len(set.NonFilterable) > 0 {
+			xs.Annotations = append(xs.Annotations, restrictionToXML(filterRestrictions, nonFilterableProperties, set.NonFilterable))
+		}
+		if len(set.NonSortable) > 0 {
+			xs.Annotations = append(xs.Annotations, restrictionToXML(sortRestrictions, nonSortableProperties, set.NonSortable))
+		}
+		x.EntitySets = append(x.EntitySets, xs)
+	}
+	return x
+}
+
+func restrictionToXML(term, property string, paths []string) xmlAnnotation {
+	a := xmlAnnotation{Term: term}
+	a.Record.PropertyValues = []xmlPropertyValue{{Property: property, PropertyPaths: paths}}
+	return a
+}
+
+// toEntityContainer reads the entity sets of a container and their capability restrictions.
+func (x xmlContainer) toEntityContainer(namespace string) *EntityContainer {
+	c := &EntityContainer{Namespace: namespace, Name: x.Name}
+	for _, xs := range x.EntitySets {
+		set := EntitySet{Name: xs.Name, EntityType: xs.EntityType}
+		for _, a := range xs.Annotations {
+			for _, pv := range a.Record.PropertyValues {
+				switch {
+				case isTerm(a.Term, "FilterRestrictions") && pv.Property == nonFilterableProperties:
+					set.NonFilterable = append(set.NonFilterable, pv.PropertyPaths...)
+				case isTerm(a.Term, "SortRestrictions") && pv.Property == nonSortableProperties:
+					set.NonSortable = append(set.NonSortable, pv.PropertyPaths...)
+				}
+			}
+		}
+		c.Sets = append(c.Sets, set)
+	}
+	return c
+}
+
+// isTerm reports whether term names the given Capabilities term, by alias or full namespace.
+func isTerm(term, name string) bool {
+	return term == capabilitiesAlias+"."+name || term == capabilitiesNamespace+"."+name
+}
+
+func (x xmlStructure) toStructuredType() StructuredType {
+	t := StructuredType{Name: x.Name, BaseType: x.BaseType}
+	if x.Key != nil {
+		for _, ref := range x.Key.PropertyRefs {
+			t.Key = append(t.Key, ref.Name)
+		}
+	}
+	for _, p := range x.Properties {
+		typ, collection := splitCollection(p.Type)
+		t.Properties = append(t.Properties, Property{
+			Name:       p.Name,
+			Type:       typ,
+			Collection: collection,
+			Nullable:   p.Nullable != "false", // XML properties are nullable by default
+		})
+	}
+	for _, p := range x.NavigationProperties {
+		typ, collection := splitCollection(p.Type)
+		t.NavigationProperties = append(t.NavigationProperties, NavigationProperty{
+			Name:       p.Name,
+			Type:       typ,
+			Collection: collection,
+			Nullable:   !collection && p.Nullable != "false",
+		})
+	}
+	return t
+}
+
+func (x xmlEnumType) toEnumType() (EnumType, error) {
+	e := EnumType{Name: x.Name}
+	next := int64(0)
+	for _, m := range x.Members {
+		value := next
+		if m.Value != "" {
+			v, err := strconv.ParseInt(m.Value, 10, 64)
+			if err != nil {
+				return EnumType{}, fmt.Errorf("csdl: enum %s member %s has invalid value %q", x.Name, m.Name, m.Value)
+			}
+			value = v
+		}
+		e.Members = append(e.Members, EnumMember{Name: m.Name, Value: value})
+		next = value + 1
+	}
+	return e, nil
+}
+
+// splitCollection turns "Collection(NS.Type)" into ("NS.Type", true).
+func splitCollection(typ string) (string, bool) {
+	if strings.HasPrefix(typ, collectionPrefix) && strings.HasSuffix(typ, ")") {
+		return typ[len(collectionPrefix) : len(typ)-1], true
+	}
+	return typ, false
+}
@@ -0,0 +1,114 @@
+package odatasql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect describes the SQL flavor generated by a Converter.
+//
+// A dialect whose bind parameters are not written "?" also implements
+// Placeholder(n int) string, returning the placeholder of the n-th (1-based) argument.
+type Dialect interface {
+	// QuoteIdentifier returns a column name quoted for safe use in SQL.
+	QuoteIdentifier(name string) string
+	// QuoteString returns a string literal quoted and escaped for safe use in SQL.
+	QuoteString(value string) string
+	// FormatBool returns the SQL literal for a boolean value.
+	FormatBool(value bool) string
+}
+
+// Built-in dialects.
+var (
+	// DialectGeneric leaves identifiers unquoted and uses ANSI string literals. It is the default.
+	DialectGeneric Dialect = genericDialect{}
+	// DialectPostgres quotes identifiers with double quotes.
+	DialectPostgres Dialect = postgresDialect{}
+	// DialectMySQL quotes identifiers with backticks and escapes backslashes in strings.
+	DialectMySQL Dialect = mysqlDialect{}
+	// DialectSQLite quotes identifiers with double quotes and writes booleans as 1 and 0.
+	DialectSQLite Dialect = sqliteDialect{}
+	// DialectSQLServer quotes identifiers with brackets and writes booleans as 1 and 0.
+	DialectSQLServer Dialect = sqlServerDialect{}
+)
+
+type genericDialect struct{}
+
+func (genericDialect) QuoteIdentifier(name string) string { return name }
+func (genericDialect) QuoteString(value string) string    { return quoteString(value) }
+func (genericDialect) FormatBool(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, `"`, `"`) }
+func (postgresDialect) QuoteString(value string) string    { return quoteString(value) }
+func (postgresDialect) FormatBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, "`", "`") }
+func (mysqlDialect) QuoteString(value string) string {
+	// MySQL treats backslashes in string literals as escape characters by default.
+	return quoteString(strings.ReplaceAll(value, `\`, `\\`))
+}
+func (mysqlDialect) FormatBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, `"`, `"`) }
+func (sqliteDialect) QuoteString(value string) string    { return quoteString(value) }
+func (sqliteDialect) FormatBool(value bool) string       { return formatBoolAsInt(value) }
+
+type sqlServerDialect struct{}
+
+func (sqlServerDialect) QuoteIdentifier(name string) string { return quoteIdentifier(name, "[", "]") }
+func (sqlServerDialect) QuoteString(value string) string    { return quoteString(value) }
+func (sqlServerDialect) FormatBool(value bool) string       { return formatBoolAsInt(value) }
+func (sqlServerDialect) Placeholder(n int) string           { return "@p" + strconv.Itoa(n) }
+
+// placeholderDialect is implemented by dialects with numbered bind parameters.
+type placeholderDialect interface {
+	Placeholder(n int) string
+}
+
+// placeholder returns the bind parameter of the n-th argument in dialect d.
+func placeholder(d Dialect, n int) string {
+	if p, ok := d.(placeholderDialect); ok {
+		return p.Placeholder(n)
+	}
+	return "?"
+}
+
+// quoteIdentifier wraps name in the given quotes, doubling any closing quote inside it.
+func quoteIdentifier(name, open, closing string) string {
+	return open + strings.ReplaceAll(name, closing, closing+closing) + closing
+}
+
+// quoteString wraps value in single quotes, doubling any single quote inside it.
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func formatBoolAsInt(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
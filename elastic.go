@@ -0,0 +1,357 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// FilterToElastic converts an OData filter into an Elasticsearch query with the default
+// converter. See Converter.FilterToElastic.
+func FilterToElastic(filter string) (map[string]any, error) {
+	return defaultConverter.FilterToElastic(filter)
+}
+
+// FilterToElastic converts an OData filter into an Elasticsearch (or OpenSearch) Query DSL
+// query, built from maps with string keys and slices of type []any so that it can be encoded
+// with encoding/json as the "query" of a search request. An empty filter returns a match_all
+// query.
+//
+// Logical operators become bool queries: "and" becomes filter clauses and "or" should clauses
+// with minimum_should_match 1. "not" matches the documents for which its operand is false, as
+// in SQL, where a comparison on a missing field is unknown rather than false: negations are
+// pushed down to the comparisons with De Morgan's laws, and each negated comparison becomes a
+// must_not clause next to an exists filter, so "not (d eq 'x')" does not match documents
+// without d. Leaves use term-level queries on keyword, numeric, boolean and date fields:
+//   - eq becomes term, ne a must_not term next to an exists filter (so that, like in SQL,
+//     documents without the field do not match), and gt, ge, lt and le become range;
+//   - in becomes terms;
+//   - eq null becomes a must_not exists, and ne null becomes exists;
+//   - startswith becomes prefix, and contains and endswith become wildcard with the argument
+//     escaped.
+//
+// Properties marked as FullText in the schema are analyzed text fields: eq and in become
+// match_phrase, contains becomes match_phrase and startswith match_phrase_prefix, while
+// range comparisons and endswith are rejected.
+//
+// Lambda operators over collections of objects become nested queries, all being expressed as
+// "no nested object fails the predicate"; the collection must be mapped with the nested type.
+// On collections of primitives, which Elasticsearch indexes as multi-valued fields, only any
+// with a single comparison of the element, other than ne or with null, is supported. Fields
+// are named like in FilterToMongo: after the schema's columns, or after their property paths
+// with '/' replaced by '.'.
+//
+// Example:
+//
+//	query, err := conv.FilterToElastic("status eq 'open' and startswith(title, 'Go')")
+//	// {"bool": {"filter": [{"term": {"status": "open"}}, {"prefix": {"title": "Go"}}]}}
+func (c *Converter) FilterToElastic(filter string) (map[string]any, error) {
+	return c.FilterToElasticContext(context.Background(), filter)
+}
+
+// FilterToElasticContext is like FilterToElastic, passing ctx to the converter's Authorizer.
+func (c *Converter) FilterToElasticContext(ctx context.Context, filter string) (map[string]any, error) {
+	if strings.TrimSpace(filter) == "" {
+		return map[string]any{"match_all": map[string]any{}}, nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	query, err := elasticWriter{c}.query(node)
+	if err != nil {
+		return nil, fmt.Errorf("converting OData filter %q to Elasticsearch: %w", filter, err)
+	}
+	return query, nil
+}
+
+// elasticRanges maps OData comparison operators to the parameters of range queries.
+var elasticRanges = map[string]string{
+	ast.OpGt: "gt",
+	ast.OpGe: "gte",
+	ast.OpLt: "lt",
+	ast.OpLe: "lte",
+}
+
+var wildcardEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`)
+
+// elasticWriter renders an AST as an Elasticsearch query.
+type elasticWriter struct {
+	c *Converter
+}
+
+func (w elasticWriter) query(node ast.Node) (map[string]any, error) {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		var clauses []any
+		for _, operand := range flatten(n, n.Op) {
+			q, err := w.query(operand)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, q)
+		}
+		if n.Op == ast.OpOr {
+			return should(clauses...), nil
+		}
+		return boolQuery("filter", clauses...), nil
+	case *ast.NotNode:
+		return w.negation(n.Child)
+	case *ast.ParenNode:
+		return w.query(n.Child)
+	case *ast.ConditionNode:
+		return w.condition(n)
+	case *ast.InNode:
+		return w.in(n)
+	case *ast.FunctionNode:
+		return w.function(n)
+	case *ast.LambdaNode:
+		return w.lambda(n)
+	}
+	return nil, fmt.Errorf("unsupported expression %T", node)
+}
+
+// negation returns the query matching the documents for which node is false. Comparisons on a
+// missing field are unknown, neither true nor false, so a bare must_not is only used for
+// lambda operators, which are never unknown.
+func (w elasticWriter) negation(node ast.Node) (map[string]any, error) {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		var clauses []any
+		for _, operand := range flatten(n, n.Op) {
+			q, err := w.negation(operand)
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, q)
+		}
+		if n.Op == ast.OpOr {
+			return boolQuery("filter", clauses...), nil
+		}
+		return should(clauses...), nil
+	case *ast.NotNode:
+		return w.query(n.Child)
+	case *ast.ParenNode:
+		return w.negation(n.Child)
+	case *ast.ConditionNode:
+		switch {
+		case n.Op == ast.OpEq && n.Value.Kind == ast.KindNull:
+			return leaf("exists", "field", documentField(w.c.schema, n.Field)), nil
+		case n.Op == ast.OpNe:
+			// Both are false exactly where the equality is true.
+			return w.condition(&ast.ConditionNode{Field: n.Field, Op: ast.OpEq, Value: n.Value})
+		}
+		q, err := w.condition(n)
+		if err != nil {
+			return nil, err
+		}
+		return existing(documentField(w.c.schema, n.Field), q), nil
+	case *ast.InNode:
+		q, err := w.in(n)
+		if err != nil {
+			return nil, err
+		}
+		return existing(documentField(w.c.schema, n.Field), q), nil
+	case *ast.FunctionNode:
+		q, err := w.function(n)
+		if err != nil {
+			return nil, err
+		}
+		return existing(documentField(w.c.schema, n.Field), q), nil
+	}
+	q, err := w.query(node)
+	if err != nil {
+		return nil, err
+	}
+	return boolQuery("must_not", q), nil
+}
+
+// existing returns the query matching documents that have field but do not match q.
+func existing(field string, q map[string]any) map[string]any {
+	return map[string]any{"bool": map[string]any{
+		"filter":   []any{leaf("exists", "field", field)},
+		"must_not": []any{q},
+	}}
+}
+
+func (w elasticWriter) condition(n *ast.ConditionNode) (map[string]any, error) {
+	field := documentField(w.c.schema, n.Field)
+	if n.Value.Kind == ast.KindNull {
+		exists := leaf("exists", "field", field)
+		switch n.Op {
+		case ast.OpEq:
+			return boolQuery("must_not", exists), nil
+		case ast.OpNe:
+			return exists, nil
+		}
+		return nil, fmt.Errorf("cannot compare property %q with null using %s", n.Field, n.Op)
+	}
+
+	value := literalValue(n.Value)
+	if rangeOp, ok := elasticRanges[n.Op]; ok {
+		if w.fullText(n.Field) {
+			return nil, fmt.Errorf("%s is not supported on full-text property %q", n.Op, n.Field)
+		}
+		return leaf("range", field, map[string]any{rangeOp: value}), nil
+	}
+
+	match := w.match(n.Field, field, value)
+	if n.Op == ast.OpNe {
+		// A bare must_not would also match documents without the field, which SQL and
+		// CompileFilter exclude since null is not different from any value.
+		return existing(field, match), nil
+	}
+	return match, nil
+}
+
+// match returns the query matching documents whose field equals value.
+func (w elasticWriter) match(property, field string, value any) map[string]any {
+	if w.fullText(property) {
+		return leaf("match_phrase", field, value)
+	}
+	return leaf("term", field, value)
+}
+
+func (w elasticWriter) in(n *ast.InNode) (map[string]any, error) {
+	field := documentField(w.c.schema, n.Field)
+	values := make([]any, len(n.Values))
+	for i, v := range n.Values {
+		values[i] = literalValue(v)
+	}
+	if !w.fullText(n.Field) {
+		return leaf("terms", field, values), nil
+	}
+
+	phrases := make([]any, len(values))
+	for i, v := range values {
+		phrases[i] = leaf("match_phrase", field, v)
+	}
+	return should(phrases...), nil
+}
+
+func (w elasticWriter) function(n *ast.FunctionNode) (map[string]any, error) {
+	field := documentField(w.c.schema, n.Field)
+	if w.fullText(n.Field) {
+		switch n.Name {
+		case ast.FuncContains:
+			return leaf("match_phrase", field, n.Value.Value), nil
+		case ast.FuncStartsWith:
+			return leaf("match_phrase_prefix", field, n.Value.Value), nil
+		}
+		return nil, fmt.Errorf("%s is not supported on full-text property %q", n.Name, n.Field)
+	}
+
+	pattern := wildcardEscaper.Replace(n.Value.Value)
+	switch n.Name {
+	case ast.FuncStartsWith:
+		return leaf("prefix", field, n.Value.Value), nil
+	case ast.FuncContains:
+		pattern = "*" + pattern + "*"
+	case ast.FuncEndsWith:
+		pattern = "*" + pattern
+	}
+	return leaf("wildcard", field, map[string]any{"value": pattern}), nil
+}
+
+// lambda renders an any or all operator: a nested query over a collection of objects, or the
+// query of its predicate over a multi-valued field for a collection of primitives.
+func (w elasticWriter) lambda(n *ast.LambdaNode) (map[string]any, error) {
+	path := documentField(w.c.schema, n.Field)
+	if w.primitiveCollection(n) {
+		if n.Predicate == nil {
+			return leaf("exists", "field", path), nil
+		}
+		predicate := n.Predicate
+		for paren, ok := predicate.(*ast.ParenNode); ok; paren, ok = predicate.(*ast.ParenNode) {
+			predicate = paren.Child
+		}
+		// A query on a multi-valued field matches when any value matches, except for the
+		// negations rendered for ne and null comparisons.
+		supported := false
+		switch p := predicate.(type) {
+		case *ast.ConditionNode:
+			supported = p.Op != ast.OpNe && p.Value.Kind != ast.KindNull
+		case *ast.InNode, *ast.FunctionNode:
+			supported = true
+		}
+		if !supported || n.Op != ast.LambdaAny {
+			return nil, fmt.Errorf("%s: only any with a single positive comparison is supported on the primitive collection %q", n.Op, n.Field)
+		}
+		return w.query(predicate)
+	}
+
+	if n.Predicate == nil {
+		return nested(path, map[string]any{"match_all": map[string]any{}}), nil
+	}
+	inner, err := w.query(n.Predicate)
+	if err != nil {
+		return nil, err
+	}
+	if n.Op == ast.LambdaAny {
+		return nested(path, inner), nil
+	}
+	// Every object matches when no object fails the predicate.
+	return boolQuery("must_not", nested(path, boolQuery("must_not", inner))), nil
+}
+
+// primitiveCollection reports whether the collection of a lambda operator holds primitives:
+// it is a schema property itself rather than the parent of element properties, or its
+// predicate compares the element itself.
+func (w elasticWriter) primitiveCollection(n *ast.LambdaNode) bool {
+	if w.c.schema != nil {
+		_, ok := w.c.schema.Property(n.Field)
+		return ok
+	}
+	return n.Predicate == nil || referencesField(n.Predicate, n.Field)
+}
+
+func (w elasticWriter) fullText(property string) bool {
+	if w.c.schema == nil {
+		return false
+	}
+	p, _ := w.c.schema.Property(property)
+	return p.FullText
+}
+
+// referencesField reports whether node compares field itself.
+func referencesField(node ast.Node, field string) bool {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		return referencesField(n.Left, field) || referencesField(n.Right, field)
+	case *ast.NotNode:
+		return referencesField(n.Child, field)
+	case *ast.ParenNode:
+		return referencesField(n.Child, field)
+	case *ast.ConditionNode:
+		return n.Field == field
+	case *ast.InNode:
+		return n.Field == field
+	case *ast.FunctionNode:
+		return n.Field == field
+	case *ast.LambdaNode:
+		return n.Field == field
+	}
+	return false
+}
+
+// leaf returns the query {kind: {key: value}}.
+func leaf(kind, key string, value any) map[string]any {
+	return map[string]any{kind: map[string]any{key: value}}
+}
+
+// boolQuery returns a bool query with a single occurrence type.
+func boolQuery(occur string, clauses ...any) map[string]any {
+	return map[string]any{"bool": map[string]any{occur: clauses}}
+}
+
+// should returns a bool query matching any of clauses.
+func should(clauses ...any) map[string]any {
+	return map[string]any{"bool": map[string]any{"should": clauses, "minimum_should_match": 1}}
+}
+
+func nested(path string, query map[string]any) map[string]any {
+	return map[string]any{"nested": map[string]any{"path": path, "query": query}}
+}
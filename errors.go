@@ -0,0 +1,36 @@
+package odatasql
+
+import "github.com/maxlambrecht/odatasql/internal/parser"
+
+// Error describes why a filter was rejected, including a machine-readable code and the
+// byte offset in the filter where the problem was detected. Use errors.As to retrieve it
+// from the errors returned by this package.
+type Error = parser.Error
+
+// Error codes reported in Error.Code.
+const (
+	// ErrCodeInvalidFilter reports a filter that is not syntactically or semantically valid.
+	ErrCodeInvalidFilter = parser.CodeInvalidFilter
+	// ErrCodeFilterTooLong reports a filter (or alias value) longer than Limits.MaxFilterLength.
+	ErrCodeFilterTooLong = parser.CodeFilterTooLong
+	// ErrCodeLiteralTooLong reports a string literal longer than Limits.MaxLiteralLength.
+	ErrCodeLiteralTooLong = parser.CodeLiteralTooLong
+	// ErrCodeMaxDepthExceeded reports parentheses nested deeper than Limits.MaxDepth.
+	ErrCodeMaxDepthExceeded = parser.CodeMaxDepthExceeded
+	// ErrCodeMaxNodesExceeded reports a filter with more AST nodes than Limits.MaxNodes.
+	ErrCodeMaxNodesExceeded = parser.CodeMaxNodesExceeded
+	// ErrCodeMaxInValuesExceeded reports an IN list with more values than Limits.MaxInValues.
+	ErrCodeMaxInValuesExceeded = parser.CodeMaxInValuesExceeded
+	// ErrCodeMaxClausesExceeded reports a normal form with more clauses than Limits.MaxClauses.
+	ErrCodeMaxClausesExceeded = parser.CodeMaxClausesExceeded
+	// ErrCodeMaxFunctionArgsExceeded reports a function call with more arguments than
+	// Limits.MaxFunctionArgs.
+	ErrCodeMaxFunctionArgsExceeded = parser.CodeMaxFunctionArgsExceeded
+	// ErrCodeFieldAccessDenied reports a field reference denied by the converter's Authorizer.
+	ErrCodeFieldAccessDenied = parser.CodeFieldAccessDenied
+	// ErrCodeCostExceeded reports a filter whose estimated cost exceeds CostModel.MaxCost.
+	ErrCodeCostExceeded = parser.CodeCostExceeded
+	// ErrCodeUnsupportedSQL reports a SQL condition given to SQLToExpr that is malformed or
+	// uses constructs outside the supported subset.
+	ErrCodeUnsupportedSQL = parser.CodeUnsupportedSQL
+)
@@ -0,0 +1,485 @@
+package odatasql
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// MatchFunc reports whether a value satisfies a compiled filter.
+type MatchFunc func(v any) (bool, error)
+
+// CompileFilter compiles an OData filter with the default converter. See
+// Converter.CompileFilter.
+func CompileFilter(filter string) (MatchFunc, error) {
+	return defaultConverter.CompileFilter(filter)
+}
+
+// CompileFilter parses an OData filter with the converter's configuration and compiles it into
+// a MatchFunc evaluating it in memory, for instance on cached data or on results from non-SQL
+// sources. An empty filter matches every value.
+//
+// The returned function accepts:
+//   - structs and pointers to structs, whose properties are named like in SchemaFromStruct
+//     (json tags, nested structs as "address/city", embedded structs flattened);
+//   - maps with string keys, such as map[string]any, where missing keys are null;
+//   - JSON documents given as []byte or json.RawMessage.
+//
+// Evaluation follows the SQL the converter renders, including its three-valued logic: a
+// comparison involving null is unknown, so neither it nor its negation matches. Comparisons
+// against the null literal behave like the converter's NullHandling: with NullAsIsNull,
+// "eq null" and "ne null" test for null; with NullAsValue they are unknown, like "= null" in
+// SQL. Strings compare byte-wise and string functions are case-sensitive, like a binary
+// collation. Numbers compare exactly whatever their Go type. time.Time values compare with
+// string literals in RFC 3339 format ("2024-01-31T10:00:00Z") or as dates ("2024-01-31",
+// midnight UTC). Comparing values of incompatible types is an error.
+//
+// The returned function is safe for concurrent use.
+//
+// Example:
+//
+//	match, err := conv.CompileFilter("age ge 18 and startswith(name, 'A')")
+//	ok, err := match(User{Name: "Alice", Age: 30}) // true
+func (c *Converter) CompileFilter(filter string) (MatchFunc, error) {
+	return c.CompileFilterContext(context.Background(), filter)
+}
+
+// CompileFilterContext is like CompileFilter, passing ctx to the converter's Authorizer.
+func (c *Converter) CompileFilterContext(ctx context.Context, filter string) (MatchFunc, error) {
+	if strings.TrimSpace(filter) == "" {
+		return func(any) (bool, error) { return true, nil }, nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	e := evaluator{nulls: c.nulls}
+	return func(v any) (bool, error) {
+		if doc, ok := jsonDocument(v); ok {
+			dec := json.NewDecoder(bytes.NewReader(doc))
+			dec.UseNumber()
+			if err := dec.Decode(&v); err != nil {
+				return false, fmt.Errorf("invalid JSON document: %w", err)
+			}
+		}
+		result, err := e.eval(node, v)
+		return result == truthTrue, err
+	}, nil
+}
+
+func jsonDocument(v any) ([]byte, bool) {
+	switch doc := v.(type) {
+	case json.RawMessage:
+		return doc, true
+	case []byte:
+		return doc, true
+	}
+	return nil, false
+}
+
+// truth is a value of SQL's three-valued logic.
+type truth int
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+type evaluator struct {
+	nulls  NullHandling
+	scopes []scope // collection elements bound by enclosing lambda operators, innermost last
+}
+
+// scope binds the path of a collection to the element a lambda predicate is evaluated on.
+type scope struct {
+	path    string
+	element any
+}
+
+func (e evaluator) eval(node ast.Node, v any) (truth, error) {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		left, err := e.eval(n.Left, v)
+		if err != nil {
+			return truthFalse, err
+		}
+		// Short-circuit when the left side decides the result.
+		if (n.Op == ast.OpAnd && left == truthFalse) || (n.Op == ast.OpOr && left == truthTrue) {
+			return left, nil
+		}
+		right, err := e.eval(n.Right, v)
+		if err != nil {
+			return truthFalse, err
+		}
+		switch {
+		case n.Op == ast.OpAnd && right == truthFalse, n.Op == ast.OpOr && right == truthTrue:
+			return right, nil
+		case left == truthUnknown || right == truthUnknown:
+			return truthUnknown, nil
+		}
+		return left, nil
+	case *ast.NotNode:
+		child, err := e.eval(n.Child, v)
+		if err != nil || child == truthUnknown {
+			return child, err
+		}
+		return truthOf(child == truthFalse), nil
+	case *ast.ParenNode:
+		return e.eval(n.Child, v)
+	case *ast.ConditionNode:
+		value, err := e.property(v, n.Field)
+		if err != nil {
+			return truthFalse, err
+		}
+		return e.compare(n.Field, value, n.Op, n.Value)
+	case *ast.InNode:
+		value, err := e.property(v, n.Field)
+		if err != nil {
+			return truthFalse, err
+		}
+		result := truthFalse
+		for _, lit := range n.Values {
+			t, err := e.compare(n.Field, value, ast.OpEq, lit)
+			if err != nil || t == truthTrue {
+				return t, err
+			}
+			if t == truthUnknown {
+				result = truthUnknown
+			}
+		}
+		return result, nil
+	case *ast.FunctionNode:
+		value, err := e.property(v, n.Field)
+		if err != nil || value == nil {
+			return truthUnknown, err
+		}
+		s, ok := value.(string)
+		if !ok {
+			return truthFalse, fmt.Errorf("%s: property %q is not a string", n.Name, n.Field)
+		}
+		switch n.Name {
+		case ast.FuncContains:
+			return truthOf(strings.Contains(s, n.Value.Value)), nil
+		case ast.FuncStartsWith:
+			return truthOf(strings.HasPrefix(s, n.Value.Value)), nil
+		default:
+			return truthOf(strings.HasSuffix(s, n.Value.Value)), nil
+		}
+	case *ast.LambdaNode:
+		return e.lambda(n, v)
+	}
+	return truthFalse, fmt.Errorf("unsupported expression %T", node)
+}
+
+// lambda evaluates an any or all operator by combining the results of its predicate on each
+// element of the collection like OR or AND. A null collection has no elements.
+func (e evaluator) lambda(n *ast.LambdaNode, v any) (truth, error) {
+	collection, err := e.lookup(v, n.Field)
+	if err != nil {
+		return truthFalse, err
+	}
+	rv := reflect.ValueOf(collection)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	length := 0
+	switch rv.Kind() {
+	case reflect.Invalid:
+	case reflect.Slice, reflect.Array:
+		length = rv.Len()
+	default:
+		return truthFalse, fmt.Errorf("%s: property %q is not a collection", n.Op, n.Field)
+	}
+	if n.Predicate == nil {
+		return truthOf(length > 0), nil
+	}
+
+	inner := e
+	inner.scopes = append(e.scopes[:len(e.scopes):len(e.scopes)], scope{path: n.Field})
+	result := truthOf(n.Op == ast.LambdaAll)
+	for i := 0; i < length; i++ {
+		inner.scopes[len(inner.scopes)-1].element = rv.Index(i).Interface()
+		t, err := inner.eval(n.Predicate, v)
+		switch {
+		case err != nil:
+			return truthFalse, err
+		case n.Op == ast.LambdaAny && t == truthTrue, n.Op == ast.LambdaAll && t == truthFalse:
+			return t, nil
+		case t == truthUnknown:
+			result = truthUnknown
+		}
+	}
+	return result, nil
+}
+
+// compare evaluates "value op lit", where value is a normalized property value.
+func (e evaluator) compare(field string, value any, op string, lit ast.Literal) (truth, error) {
+	if lit.Kind == ast.KindNull {
+		if e.nulls == NullAsIsNull && (op == ast.OpEq || op == ast.OpNe) {
+			return truthOf((value == nil) == (op == ast.OpEq)), nil
+		}
+		return truthUnknown, nil
+	}
+	if value == nil {
+		return truthUnknown, nil
+	}
+
+	var cmp int
+	switch v := value.(type) {
+	case string:
+		if lit.Kind != ast.KindString {
+			return truthFalse, fmt.Errorf("cannot compare string property %q with %s", field, lit.Value)
+		}
+		cmp = strings.Compare(v, lit.Value)
+	case bool:
+		if lit.Kind != ast.KindBoolean {
+			return truthFalse, fmt.Errorf("cannot compare boolean property %q with %s", field, lit.Value)
+		}
+		cmp = compareBools(v, lit.Value == "true")
+	case *big.Rat:
+		if lit.Kind != ast.KindNumber {
+			return truthFalse, fmt.Errorf("cannot compare numeric property %q with %q", field, lit.Value)
+		}
+		n, ok := new(big.Rat).SetString(lit.Value)
+		if !ok {
+			return truthFalse, fmt.Errorf("invalid number %q", lit.Value)
+		}
+		cmp = v.Cmp(n)
+	case time.Time:
+		t, err := parseTime(lit)
+		if err != nil {
+			return truthFalse, fmt.Errorf("cannot compare time property %q: %w", field, err)
+		}
+		cmp = v.Compare(t)
+	}
+
+	switch op {
+	case ast.OpEq:
+		return truthOf(cmp == 0), nil
+	case ast.OpNe:
+		return truthOf(cmp != 0), nil
+	case ast.OpGt:
+		return truthOf(cmp > 0), nil
+	case ast.OpGe:
+		return truthOf(cmp >= 0), nil
+	case ast.OpLt:
+		return truthOf(cmp < 0), nil
+	default:
+		return truthOf(cmp <= 0), nil
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// timeLayouts are the layouts accepted for literals compared with time.Time values.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", time.DateOnly}
+
+func parseTime(lit ast.Literal) (time.Time, error) {
+	if lit.Kind == ast.KindString {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, lit.Value); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date or time", lit.Value)
+}
+
+// property resolves a '/'-separated property path in v and normalizes the value found.
+func (e evaluator) property(v any, path string) (any, error) {
+	value, err := e.lookup(v, path)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(value)
+}
+
+// lookup resolves a '/'-separated property path in v, or in the element bound to the innermost
+// collection containing it inside a lambda predicate.
+func (e evaluator) lookup(v any, path string) (any, error) {
+	for i := len(e.scopes) - 1; i >= 0; i-- {
+		if path == e.scopes[i].path {
+			return e.scopes[i].element, nil
+		}
+		if rest, ok := strings.CutPrefix(path, e.scopes[i].path+"/"); ok {
+			v, path = e.scopes[i].element, rest
+			break
+		}
+	}
+	for _, name := range strings.Split(path, "/") {
+		next, err := member(v, name)
+		if err != nil || next == nil {
+			return nil, err
+		}
+		v = next
+	}
+	return v, nil
+}
+
+// member returns the property name of a struct or map, or nil if v is null.
+func member(v any, name string) (any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !value.IsValid() {
+			return nil, nil // missing keys are null
+		}
+		return value.Interface(), nil
+	case reflect.Struct:
+		index, ok := structFields(rv.Type())[name]
+		if !ok {
+			return nil, fmt.Errorf("%s has no property %q", rv.Type(), name)
+		}
+		field, err := rv.FieldByIndexErr(index)
+		if err != nil {
+			return nil, nil // through a nil embedded pointer
+		}
+		if !field.CanInterface() {
+			return nil, fmt.Errorf("%s property %q is not accessible", rv.Type(), name)
+		}
+		return field.Interface(), nil
+	}
+	return nil, fmt.Errorf("cannot read property %q of %s", name, rv.Type())
+}
+
+// normalize converts a property value into nil, string, bool, *big.Rat or time.Time.
+func normalize(v any) (any, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil, nil
+		}
+		value, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		v = value
+	}
+
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return x, nil
+	case json.Number:
+		n, ok := new(big.Rat).SetString(x.String())
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", x)
+		}
+		return n, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return normalize(rv.Elem().Interface())
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Rat).SetUint64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		n := new(big.Rat).SetFloat64(rv.Float())
+		if n == nil {
+			return nil, fmt.Errorf("cannot compare non-finite number %v", rv.Float())
+		}
+		return n, nil
+	case reflect.Array:
+		if rv.Len() == 16 && rv.Type().Elem().Kind() == reflect.Uint8 {
+			var b [16]byte
+			reflect.Copy(reflect.ValueOf(&b).Elem(), rv)
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot compare value of type %T", v)
+}
+
+// structFieldCache maps struct types to the index of their fields by property name.
+var structFieldCache sync.Map // map[reflect.Type]map[string][]int
+
+// structFields returns the fields of struct type t by property name, following the naming
+// rules of SchemaFromStruct. Fields of embedded structs are promoted unless shadowed.
+func structFields(t reflect.Type) map[string][]int {
+	if cached, ok := structFieldCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+
+	fields := map[string][]int{}
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !(f.Anonymous && indirect(f.Type).Kind() == reflect.Struct) {
+			continue
+		}
+		tags, skip, err := readFieldTags(f)
+		if skip || err != nil {
+			continue
+		}
+		if f.Anonymous && tags.name == "" && indirect(f.Type).Kind() == reflect.Struct {
+			if indirect(f.Type) != t { // a struct embedding itself has nothing to promote
+				embedded = append(embedded, f)
+			}
+			continue
+		}
+		if f.IsExported() {
+			fields[tags.nameOr(f.Name)] = f.Index
+		}
+	}
+	for _, f := range embedded {
+		for name, index := range structFields(indirect(f.Type)) {
+			if _, shadowed := fields[name]; !shadowed {
+				fields[name] = append(append([]int(nil), f.Index...), index...)
+			}
+		}
+	}
+
+	structFieldCache.Store(t, fields)
+	return fields
+}
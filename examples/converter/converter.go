@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/maxlambrecht/odatasql"
+)
+
+// Demonstrates a reusable Converter configured for PostgreSQL with a schema.
+func main() {
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "name", Type: odatasql.EdmString},
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "deletedAt", Column: "deleted_ts", Type: odatasql.EdmDateTimeOffset},
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conv := odatasql.NewConverter(
+		odatasql.WithDialect(odatasql.DialectPostgres),
+		odatasql.WithSchema(schema),
+		odatasql.WithNullHandling(odatasql.NullAsIsNull),
+	)
+
+	sql, err := conv.FilterToSQL("name eq 'Alice' and age gt 30 and deletedAt eq null")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(sql)
+}
@@ -0,0 +1,45 @@
+package odatasql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// FormatFilter normalizes an OData filter with the default converter. See
+// Converter.FormatFilter.
+func FormatFilter(filter string) (string, error) {
+	return defaultConverter.FormatFilter(filter)
+}
+
+// FormatFilter parses an OData filter with the converter's configuration and writes it back
+// in canonical form: lowercase operators and function names, single spaces, single-quoted
+// string literals, "in" lists in parentheses and parentheses only where precedence requires
+// them. Filters that differ only in such details have the same canonical form, which makes it
+// suitable for storing and comparing filters.
+//
+// The canonical form is stable: formatting it again returns it unchanged, and it converts to
+// the same SQL as the original filter up to redundant parentheses. Field references are
+// written as returned by the converter's Authorizer.
+//
+// Example:
+//
+//	s, err := odatasql.FormatFilter("(Age GT 18) AND ((name  eq  'Bob'))")
+//	// s == "Age gt 18 and name eq 'Bob'"
+func (c *Converter) FormatFilter(filter string) (string, error) {
+	return c.FormatFilterContext(context.Background(), filter)
+}
+
+// FormatFilterContext is like FormatFilter, passing ctx to the converter's Authorizer.
+func (c *Converter) FormatFilterContext(ctx context.Context, filter string) (string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return "", err
+	}
+	return ast.Format(node), nil
+}
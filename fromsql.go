@@ -0,0 +1,132 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+	"github.com/maxlambrecht/odatasql/internal/parser"
+)
+
+// SQLToExpr converts a SQL WHERE condition into a filter expression with the default
+// converter. See Converter.SQLToExpr.
+func SQLToExpr(condition string) (Expr, error) {
+	return defaultConverter.SQLToExpr(condition)
+}
+
+// SQLToExpr converts a SQL WHERE condition, such as a fragment stored by a legacy report,
+// into a filter expression. Its OData text is returned by Expr.String.
+//
+// Only a conservative subset of SQL is accepted:
+//
+//   - comparisons (=, <>, !=, <, <=, >, >=) between a column and a literal, in either order
+//   - AND, OR, NOT and parentheses
+//   - [NOT] IN with a list of literals
+//   - IS [NOT] NULL
+//   - [NOT] BETWEEN, converted to "ge" and "le"
+//   - [NOT] LIKE with an optional ESCAPE, when the pattern maps to a string function:
+//     'abc%' to startswith, '%abc' to endswith, '%abc%' to contains and 'abc' to eq
+//
+// Literals are single-quoted strings, numbers, TRUE, FALSE and NULL. NULL is only accepted in
+// IS [NOT] NULL: "age = NULL" is never true in SQL, unlike "age eq null" in OData. Anything
+// else, such as function calls, arithmetic, comparisons between columns or with NULL,
+// subqueries or placeholders, is rejected with an *Error with code ErrCodeUnsupportedSQL and
+// the position of the construct.
+//
+// With a schema, columns are matched case-insensitively against the column of each property,
+// declared or derived with the naming strategy, and may be qualified by a table alias
+// ("u.age"). Unknown columns are rejected. Numeric literals 0 and 1 compared with a boolean
+// property become false and true. Without a schema, column names are used as property names,
+// and qualified columns are rejected, since "u.age" may name the column age of the table u
+// as well as the property path "u/age".
+//
+// The result is then checked exactly like a filter given to FilterToSQL: against the schema,
+// through the Authorizer and within the limits of the converter. An empty condition converts
+// to an empty expression.
+//
+// Example:
+//
+//	expr, err := odatasql.SQLToExpr("age BETWEEN 18 AND 65 AND name LIKE 'A%'")
+//	expr.String() // "age ge 18 and age le 65 and startswith(name, 'A')"
+func (c *Converter) SQLToExpr(condition string) (Expr, error) {
+	return c.SQLToExprContext(context.Background(), condition)
+}
+
+// SQLToExprContext is like SQLToExpr, passing ctx to the converter's Authorizer.
+func (c *Converter) SQLToExprContext(ctx context.Context, condition string) (Expr, error) {
+	if strings.TrimSpace(condition) == "" {
+		return Expr{}, nil
+	}
+
+	opts := parser.SQLOptions{Limits: c.limits}
+	if c.schema != nil {
+		opts.Property = c.columnProperties()
+	}
+	node, err := parser.ParseSQL(condition, opts)
+	if err != nil {
+		return Expr{}, fmt.Errorf("invalid SQL condition %q: %w", condition, err)
+	}
+	if c.schema != nil {
+		c.booleanLiterals(node)
+	}
+
+	// Parsing the OData text applies the same checks as FilterToSQL and yields the AST the
+	// parser builds for it.
+	parsed, err := c.parse(ctx, ast.Format(node), nil, false)
+	if err != nil {
+		return Expr{}, fmt.Errorf("converting SQL condition %q: %w", condition, err)
+	}
+	return Expr{node: parsed}, nil
+}
+
+// columnProperties returns a function mapping the columns of the schema's properties to the
+// properties' names.
+func (c *Converter) columnProperties() func(column string) (string, error) {
+	properties := make(map[string]string)
+	for _, p := range c.schema.Properties() {
+		properties[strings.ToLower(c.columnName(p.Name))] = p.Name
+	}
+
+	return func(column string) (string, error) {
+		if name, ok := properties[strings.ToLower(column)]; ok {
+			return name, nil
+		}
+		// The column may be qualified by a table alias.
+		if _, unqualified, ok := strings.Cut(column, "."); ok {
+			if name, ok := properties[strings.ToLower(unqualified)]; ok {
+				return name, nil
+			}
+		}
+		return "", fmt.Errorf("unknown column")
+	}
+}
+
+// booleanLiterals rewrites the numeric literals 0 and 1 compared with boolean properties into
+// false and true, as stored by databases without a boolean type.
+func (c *Converter) booleanLiterals(node ast.Node) {
+	isBoolean := func(field string) bool {
+		p, ok := c.schema.Property(field)
+		return ok && p.Type == EdmBoolean
+	}
+
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		c.booleanLiterals(n.Left)
+		c.booleanLiterals(n.Right)
+	case *ast.NotNode:
+		c.booleanLiterals(n.Child)
+	case *ast.ParenNode:
+		c.booleanLiterals(n.Child)
+	case *ast.ConditionNode:
+		if !isBoolean(n.Field) || n.Value.Kind != ast.KindNumber {
+			return
+		}
+		switch n.Value.Value {
+		case "0":
+			n.Value = ast.Literal{Kind: ast.KindBoolean, Value: "false"}
+		case "1":
+			n.Value = ast.Literal{Kind: ast.KindBoolean, Value: "true"}
+		}
+	}
+}
@@ -12,11 +12,59 @@ const (
 	OpIn  = "IN"
 )
 
+// OData comparison operators used by ConditionNode.
+const (
+	OpEq = "eq"
+	OpNe = "ne"
+	OpGt = "gt"
+	OpGe = "ge"
+	OpLt = "lt"
+	OpLe = "le"
+)
+
+// OData string functions used by FunctionNode.
+const (
+	FuncContains   = "contains"
+	FuncStartsWith = "startswith"
+	FuncEndsWith   = "endswith"
+)
+
+// OData lambda operators used by LambdaNode.
+const (
+	LambdaAny = "any"
+	LambdaAll = "all"
+)
+
+// LiteralKind identifies the type of a literal value.
+type LiteralKind int
+
+const (
+	KindString LiteralKind = iota
+	KindNumber
+	KindBoolean
+	KindNull
+)
+
+// Literal is a constant value appearing in a filter.
+type Literal struct {
+	Kind LiteralKind
+	// Value holds the unquoted, unescaped text of the literal: "O'Brien", "42", "true" or "null".
+	Value string
+}
+
+// SQLWriter renders the leaves of an AST. It decides how fields are mapped to columns and how
+// literals and operators are written, while nodes take care of grouping and precedence.
+type SQLWriter interface {
+	Condition(c *ConditionNode) string
+	In(i *InNode) string
+	Function(f *FunctionNode) string
+}
+
 // Node represents any part of the parsed expression.
 type Node interface {
 	// ToSQL generates the SQL snippet for the node.
 	// The level parameter indicates nesting for internal use.
-	ToSQL(level int) string
+	ToSQL(w SQLWriter, level int) string
 }
 
 // BinaryNode represents an expression combining two subexpressions with "AND" or "OR".
@@ -26,9 +74,9 @@ type BinaryNode struct {
 }
 
 // ToSQL converts a BinaryNode to its SQL representation.
-func (b *BinaryNode) ToSQL(level int) string {
-	left := b.Left.ToSQL(level + 1)
-	right := b.Right.ToSQL(level + 1)
+func (b *BinaryNode) ToSQL(w SQLWriter, level int) string {
+	left := b.Left.ToSQL(w, level+1)
+	right := b.Right.ToSQL(w, level+1)
 	// For binary nodes, if not wrapped explicitly then add parentheses for nested expressions.
 	if level > 0 {
 		return fmt.Sprintf("(%s %s %s)", left, b.Op, right)
@@ -41,8 +89,8 @@ type NotNode struct {
 	Child Node
 }
 
-func (n *NotNode) ToSQL(level int) string {
-	child := n.Child.ToSQL(level + 1)
+func (n *NotNode) ToSQL(w SQLWriter, level int) string {
+	child := n.Child.ToSQL(w, level+1)
 	// For a NOT node, always add parentheses for nested expressions.
 	if level > 0 {
 		return fmt.Sprintf("(%s %s)", OpNot, child)
@@ -50,23 +98,58 @@ func (n *NotNode) ToSQL(level int) string {
 	return fmt.Sprintf("%s %s", OpNot, child)
 }
 
-// ConditionNode represents a simple binary condition like "field = value".
+// ConditionNode represents a simple comparison like "field eq value".
 type ConditionNode struct {
-	Field, Op, Value string
+	Field string  // OData property name as written in the filter
+	Op    string  // OData comparison operator, one of OpEq, OpNe, OpGt, OpGe, OpLt or OpLe
+	Value Literal // right-hand side of the comparison
 }
 
-func (c *ConditionNode) ToSQL(_ int) string {
-	return fmt.Sprintf("%s %s %s", c.Field, c.Op, c.Value)
+func (c *ConditionNode) ToSQL(w SQLWriter, _ int) string {
+	return w.Condition(c)
 }
 
 // InNode represents an IN operator condition.
 type InNode struct {
 	Field  string
-	Values []string
+	Values []Literal
+}
+
+func (i *InNode) ToSQL(w SQLWriter, _ int) string {
+	return w.In(i)
+}
+
+// FunctionNode represents a boolean string function call like "contains(field, 'value')".
+type FunctionNode struct {
+	Name  string  // one of FuncContains, FuncStartsWith or FuncEndsWith
+	Field string  // OData property name as written in the filter
+	Value Literal // string argument
+}
+
+func (f *FunctionNode) ToSQL(w SQLWriter, _ int) string {
+	return w.Function(f)
 }
 
-func (i *InNode) ToSQL(int) string {
-	return fmt.Sprintf("%s %s (%s)", i.Field, OpIn, strings.Join(i.Values, ", "))
+// LambdaNode represents a lambda operator applied to a collection, like
+// "tags/any(t: t eq 'red')". Fields inside the predicate are property paths from the root, with
+// the lambda variable replaced by the collection path: "t" becomes "tags" and "i/price" becomes
+// "items/price". Renderers resolve them relative to each element of the collection.
+type LambdaNode struct {
+	Op        string // LambdaAny or LambdaAll
+	Field     string // collection property path
+	Variable  string // lambda variable as written in the filter, or "" to let Format pick one
+	Predicate Node   // nil for "any()" without an argument
+}
+
+// ToSQL panics: lambda operators have no SQL rendering, and the parser only accepts them when
+// Options.Lambdas is set.
+func (l *LambdaNode) ToSQL(SQLWriter, int) string {
+	panic("ast: lambda operators cannot be rendered as SQL")
+}
+
+// JoinIn formats the rendered values of an IN list as "field IN (v1, v2)".
+func JoinIn(column string, values []string) string {
+	return fmt.Sprintf("%s %s (%s)", column, OpIn, strings.Join(values, ", "))
 }
 
 // ParenNode represents an expression that was explicitly parenthesized in the input.
@@ -74,8 +157,40 @@ type ParenNode struct {
 	Child Node
 }
 
-func (p *ParenNode) ToSQL(int) string {
+func (p *ParenNode) ToSQL(w SQLWriter, _ int) string {
 	// Always emit the surrounding parentheses regardless of level.
 	// We call Child.ToSQL with level 0 so that inner nodes don't remove their grouping.
-	return fmt.Sprintf("(%s)", p.Child.ToSQL(0))
+	return fmt.Sprintf("(%s)", p.Child.ToSQL(w, 0))
+}
+
+// MapLiterals returns a copy of node with each literal replaced by f(n, v), where n is the
+// node holding the literal v. Literals are visited in the order they appear in the filter;
+// node itself is not modified.
+func MapLiterals(node Node, f func(n Node, v Literal) Literal) Node {
+	switch n := node.(type) {
+	case *BinaryNode:
+		left := MapLiterals(n.Left, f)
+		return &BinaryNode{Op: n.Op, Left: left, Right: MapLiterals(n.Right, f)}
+	case *NotNode:
+		return &NotNode{Child: MapLiterals(n.Child, f)}
+	case *ParenNode:
+		return &ParenNode{Child: MapLiterals(n.Child, f)}
+	case *ConditionNode:
+		return &ConditionNode{Field: n.Field, Op: n.Op, Value: f(n, n.Value)}
+	case *InNode:
+		values := make([]Literal, len(n.Values))
+		for i, v := range n.Values {
+			values[i] = f(n, v)
+		}
+		return &InNode{Field: n.Field, Values: values}
+	case *FunctionNode:
+		return &FunctionNode{Name: n.Name, Field: n.Field, Value: f(n, n.Value)}
+	case *LambdaNode:
+		mapped := &LambdaNode{Op: n.Op, Field: n.Field, Variable: n.Variable}
+		if n.Predicate != nil {
+			mapped.Predicate = MapLiterals(n.Predicate, f)
+		}
+		return mapped
+	}
+	return node
 }
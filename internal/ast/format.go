@@ -0,0 +1,135 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Operator precedence in OData filters, from loosest to tightest.
+const (
+	precOr = iota + 1
+	precAnd
+	precUnary // not, and conditions which never need parentheses
+)
+
+// Format renders an AST as canonical OData $filter text: lowercase operators and functions,
+// single spaces, single-quoted strings and parentheses only where precedence requires them.
+// Explicit ParenNodes are not preserved, so parsing the result yields an AST equal to node up
+// to redundant parentheses, and formatting that AST yields the same text again.
+func Format(node Node) string {
+	f := formatter{}
+	return f.format(node, 0)
+}
+
+// formatter renders nodes, tracking the enclosing lambda operators to write fields through
+// their variables.
+type formatter struct {
+	scopes []scope // innermost last
+}
+
+// scope binds a lambda variable to the path of its collection.
+type scope struct {
+	variable string
+	path     string
+}
+
+// format renders node, adding parentheses when its precedence is below min.
+func (f *formatter) format(node Node, min int) string {
+	node = unwrap(node)
+	text, prec := f.text(node)
+	if prec < min {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func (f *formatter) text(node Node) (string, int) {
+	switch n := node.(type) {
+	case *BinaryNode:
+		prec, op := precAnd, "and"
+		if n.Op == OpOr {
+			prec, op = precOr, "or"
+		}
+		// Operators are left-associative: an operand on the right with the same precedence
+		// needs parentheses.
+		return f.format(n.Left, prec) + " " + op + " " + f.format(n.Right, prec+1), prec
+	case *NotNode:
+		return "not " + f.format(n.Child, precUnary), precUnary
+	case *ConditionNode:
+		return f.field(n.Field) + " " + n.Op + " " + FormatLiteral(n.Value), precUnary
+	case *InNode:
+		values := make([]string, len(n.Values))
+		for i, v := range n.Values {
+			values[i] = FormatLiteral(v)
+		}
+		return f.field(n.Field) + " in (" + strings.Join(values, ", ") + ")", precUnary
+	case *FunctionNode:
+		return n.Name + "(" + f.field(n.Field) + ", " + FormatLiteral(n.Value) + ")", precUnary
+	case *LambdaNode:
+		prefix := f.field(n.Field) + "/" + n.Op + "("
+		if n.Predicate == nil {
+			return prefix + ")", precUnary
+		}
+		variable := n.Variable
+		if variable == "" {
+			variable = f.newVariable()
+		}
+		f.scopes = append(f.scopes, scope{variable: variable, path: n.Field})
+		predicate := f.format(n.Predicate, 0)
+		f.scopes = f.scopes[:len(f.scopes)-1]
+		return prefix + variable + ": " + predicate + ")", precUnary
+	}
+	return "", precUnary
+}
+
+// field writes a property path inside the innermost lambda operator containing it through
+// the operator's variable.
+func (f *formatter) field(path string) string {
+	for i := len(f.scopes) - 1; i >= 0; i-- {
+		s := f.scopes[i]
+		if path == s.path {
+			return s.variable
+		}
+		if rest, ok := strings.CutPrefix(path, s.path+"/"); ok {
+			return s.variable + "/" + rest
+		}
+	}
+	return path
+}
+
+// newVariable returns a lambda variable for a LambdaNode without one, distinct from the
+// variables of the enclosing lambda operators: "x", then "x1", "x2" and so on.
+func (f *formatter) newVariable() string {
+	for i := 0; ; i++ {
+		name := "x"
+		if i > 0 {
+			name += strconv.Itoa(i)
+		}
+		taken := false
+		for _, s := range f.scopes {
+			taken = taken || s.variable == name
+		}
+		if !taken {
+			return name
+		}
+	}
+}
+
+// FormatLiteral renders a literal in OData syntax.
+func FormatLiteral(v Literal) string {
+	if v.Kind == KindString {
+		return "'" + strings.ReplaceAll(v.Value, "'", "''") + "'"
+	}
+	return v.Value
+}
+
+// unwrap returns the expression inside any number of ParenNodes.
+func unwrap(node Node) Node {
+	for {
+		p, ok := node.(*ParenNode)
+		if !ok {
+			return node
+		}
+		node = p.Child
+	}
+}
@@ -0,0 +1,121 @@
+package ast
+
+// DNF returns the disjunctive normal form of node, which must be in negation normal form as
+// returned by Simplify: a list of clauses, each a list of literals combined with AND, the
+// clauses combined with OR. Literals are the leaves of node, possibly under a NotNode;
+// lambda predicates are not rewritten. Duplicate literals and clauses absorbed by a smaller
+// clause are removed.
+//
+// The conversion may produce exponentially many clauses. It stops and returns false as soon
+// as there are more than max clauses; zero or less means unlimited.
+func DNF(node Node, max int) ([][]Node, bool) {
+	return normalForm(node, OpOr, max)
+}
+
+// CNF returns the conjunctive normal form of node like DNF, as clauses of literals combined
+// with OR, the clauses combined with AND.
+func CNF(node Node, max int) ([][]Node, bool) {
+	return normalForm(node, OpAnd, max)
+}
+
+// JoinClauses builds the AST of a normal form returned by DNF, with outer OpOr, or by CNF,
+// with outer OpAnd.
+func JoinClauses(clauses [][]Node, outer string) Node {
+	var result Node
+	for _, clause := range clauses {
+		var joined Node
+		for _, literal := range clause {
+			joined = join(joined, literal, dual(outer))
+		}
+		result = join(result, joined, outer)
+	}
+	return result
+}
+
+func join(left, right Node, op string) Node {
+	if left == nil {
+		return right
+	}
+	return &BinaryNode{Op: op, Left: left, Right: right}
+}
+
+// normalForm returns the clauses of node, combined with outer, whose literals are combined
+// with the dual operator.
+func normalForm(node Node, outer string, max int) ([][]Node, bool) {
+	switch n := node.(type) {
+	case *ParenNode:
+		return normalForm(n.Child, outer, max)
+	case *BinaryNode:
+		left, ok := normalForm(n.Left, outer, max)
+		if !ok {
+			return nil, false
+		}
+		right, ok := normalForm(n.Right, outer, max)
+		if !ok {
+			return nil, false
+		}
+
+		var clauses [][]Node
+		if n.Op == outer {
+			clauses = append(left, right...)
+		} else {
+			// Distribute: every clause of the left operand with every clause of the right one.
+			for _, l := range left {
+				for _, r := range right {
+					clause := append(append([]Node{}, l...), r...)
+					clauses = append(clauses, clause)
+					if max > 0 && len(clauses) > max {
+						return nil, false
+					}
+				}
+			}
+		}
+		clauses = absorb(clauses)
+		if max > 0 && len(clauses) > max {
+			return nil, false
+		}
+		return clauses, true
+	}
+	return [][]Node{{node}}, true
+}
+
+// absorb removes duplicate literals within clauses, then clauses containing all the literals
+// of another clause, keeping the first of equal clauses.
+func absorb(clauses [][]Node) [][]Node {
+	keys := make([]map[string]bool, len(clauses))
+	for i, clause := range clauses {
+		clauses[i] = dedupe(clause)
+		keys[i] = make(map[string]bool, len(clauses[i]))
+		for _, literal := range clauses[i] {
+			keys[i][Format(literal)] = true
+		}
+	}
+
+	subset := func(a, b map[string]bool) bool {
+		for key := range a {
+			if !b[key] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var result [][]Node
+	for i, clause := range clauses {
+		absorbed := false
+		for j := range clauses {
+			if i == j || !subset(keys[j], keys[i]) {
+				continue
+			}
+			// Of two equal clauses, the first one is kept.
+			if len(keys[j]) < len(keys[i]) || j < i {
+				absorbed = true
+				break
+			}
+		}
+		if !absorbed {
+			result = append(result, clause)
+		}
+	}
+	return result
+}
@@ -0,0 +1,226 @@
+package ast
+
+// SimplifyOptions configures Simplify.
+type SimplifyOptions struct {
+	// NonNull reports whether a field never holds null. Negated comparisons on such fields are
+	// rewritten by flipping their operator. A nil NonNull treats every field as nullable.
+	NonNull func(field string) bool
+	// MaxInValues bounds the IN lists built from equalities. Zero or less means unlimited.
+	MaxInValues int
+}
+
+// negatedOperators maps comparison operators to their negation on non-null values.
+var negatedOperators = map[string]string{
+	OpEq: OpNe,
+	OpNe: OpEq,
+	OpGt: OpLe,
+	OpGe: OpLt,
+	OpLt: OpGe,
+	OpLe: OpGt,
+}
+
+// Simplify returns an AST equivalent to node without redundancies. It:
+//
+//   - removes ParenNodes, whose grouping the tree already encodes;
+//   - removes double negations and pushes NOT inward with De Morgan's laws, negating
+//     comparisons against null and, with NonNull, comparisons on non-null fields by flipping
+//     their operator; other leaves keep a NOT;
+//   - flattens chains of the same operator into left-deep trees and removes duplicate terms;
+//   - collapses equalities and IN lists on the same field within an OR into one IN list,
+//     without duplicate values, and their negations within an AND into a negated IN list;
+//     IN lists with a single value become equalities.
+//
+// The rewrites hold under SQL's three-valued logic as well as two-valued logic, so the result
+// matches the same rows in every rendering. Lambda predicates are simplified on their own.
+// Simplify does not modify node.
+func Simplify(node Node, opts SimplifyOptions) Node {
+	s := simplifier{opts}
+	return s.simplify(node, false)
+}
+
+type simplifier struct {
+	SimplifyOptions
+}
+
+// simplify returns the simplified form of node, or of its negation when negate is set.
+func (s simplifier) simplify(node Node, negate bool) Node {
+	switch n := node.(type) {
+	case *ParenNode:
+		return s.simplify(n.Child, negate)
+	case *NotNode:
+		return s.simplify(n.Child, !negate)
+	case *BinaryNode:
+		op := n.Op
+		if negate {
+			op = dual(op)
+		}
+		terms := append(s.terms(n.Left, op, negate), s.terms(n.Right, op, negate)...)
+		terms = s.collapseEqualities(dedupe(terms), op)
+		result := terms[0]
+		for _, term := range terms[1:] {
+			result = &BinaryNode{Op: op, Left: result, Right: term}
+		}
+		return result
+	case *ConditionNode:
+		if !negate {
+			return &ConditionNode{Field: n.Field, Op: n.Op, Value: n.Value}
+		}
+		nullCheck := n.Value.Kind == KindNull && (n.Op == OpEq || n.Op == OpNe)
+		nonNull := n.Value.Kind != KindNull && s.NonNull != nil && s.NonNull(n.Field)
+		if nullCheck || nonNull {
+			return &ConditionNode{Field: n.Field, Op: negatedOperators[n.Op], Value: n.Value}
+		}
+		return &NotNode{Child: &ConditionNode{Field: n.Field, Op: n.Op, Value: n.Value}}
+	case *InNode:
+		values := uniqueValues(n.Values)
+		if len(values) == 1 {
+			return s.simplify(&ConditionNode{Field: n.Field, Op: OpEq, Value: values[0]}, negate)
+		}
+		return negated(&InNode{Field: n.Field, Values: values}, negate)
+	case *FunctionNode:
+		return negated(&FunctionNode{Name: n.Name, Field: n.Field, Value: n.Value}, negate)
+	case *LambdaNode:
+		lambda := &LambdaNode{Op: n.Op, Field: n.Field, Variable: n.Variable}
+		if n.Predicate != nil {
+			lambda.Predicate = s.simplify(n.Predicate, false)
+		}
+		return negated(lambda, negate)
+	}
+	return node
+}
+
+// terms simplifies an operand of a BinaryNode with operator op, or of its negation, and
+// returns it as a list of terms, splicing in the terms of operands that are chains of op.
+func (s simplifier) terms(node Node, op string, negate bool) []Node {
+	switch n := node.(type) {
+	case *ParenNode:
+		return s.terms(n.Child, op, negate)
+	case *NotNode:
+		return s.terms(n.Child, op, !negate)
+	case *BinaryNode:
+		if n.Op == op && !negate || dual(n.Op) == op && negate {
+			return append(s.terms(n.Left, op, negate), s.terms(n.Right, op, negate)...)
+		}
+	}
+
+	// A chain of another operator may reduce to a chain of op once its duplicates are removed.
+	var terms []Node
+	var walk func(Node)
+	walk = func(n Node) {
+		if b, ok := n.(*BinaryNode); ok && b.Op == op {
+			walk(b.Left)
+			walk(b.Right)
+			return
+		}
+		terms = append(terms, n)
+	}
+	walk(s.simplify(node, negate))
+	return terms
+}
+
+// collapseEqualities merges the equalities and IN lists of an OR chain that share a field
+// into a single IN list at the position of the first one. In an AND chain, it merges their
+// negations into a negated IN list instead.
+func (s simplifier) collapseEqualities(terms []Node, op string) []Node {
+	equality := equality
+	if op == OpAnd {
+		equality = inequality
+	}
+
+	values := make(map[string][]Literal)
+	counts := make(map[string]int)
+	for _, term := range terms {
+		if field, vs, ok := equality(term); ok {
+			values[field] = append(values[field], vs...)
+			counts[field]++
+		}
+	}
+
+	var result []Node
+	for _, term := range terms {
+		field, _, ok := equality(term)
+		if !ok || counts[field] < 2 {
+			result = append(result, term)
+			continue
+		}
+		merged := uniqueValues(values[field])
+		if s.MaxInValues > 0 && len(merged) > s.MaxInValues {
+			result = append(result, term)
+			continue
+		}
+		if merged != nil {
+			result = append(result, negated(&InNode{Field: field, Values: merged}, op == OpAnd))
+			values[field] = nil // later terms on the field are merged
+		}
+	}
+	return result
+}
+
+// equality returns the field and values of an equality with a non-null literal or an IN list.
+func equality(node Node) (string, []Literal, bool) {
+	switch n := node.(type) {
+	case *ConditionNode:
+		if n.Op == OpEq && n.Value.Kind != KindNull {
+			return n.Field, []Literal{n.Value}, true
+		}
+	case *InNode:
+		return n.Field, n.Values, true
+	}
+	return "", nil, false
+}
+
+// inequality returns the field and values of the negation of an equality or IN list, or of an
+// inequality with a non-null literal, which SQL evaluates like a negated equality.
+func inequality(node Node) (string, []Literal, bool) {
+	switch n := node.(type) {
+	case *NotNode:
+		return equality(n.Child)
+	case *ConditionNode:
+		if n.Op == OpNe && n.Value.Kind != KindNull {
+			return n.Field, []Literal{n.Value}, true
+		}
+	}
+	return "", nil, false
+}
+
+// uniqueValues returns values without duplicates, in order of first occurrence.
+func uniqueValues(values []Literal) []Literal {
+	seen := make(map[Literal]bool, len(values))
+	var unique []Literal
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// dedupe returns terms without structurally identical duplicates, in order of first occurrence.
+func dedupe(terms []Node) []Node {
+	seen := make(map[string]bool, len(terms))
+	var unique []Node
+	for _, term := range terms {
+		key := Format(term)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+// dual returns the operator De Morgan's laws turn op into under negation.
+func dual(op string) string {
+	if op == OpAnd {
+		return OpOr
+	}
+	return OpAnd
+}
+
+func negated(node Node, negate bool) Node {
+	if negate {
+		return &NotNode{Child: node}
+	}
+	return node
+}
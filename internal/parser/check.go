@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// ValidateField reports whether name is a valid field reference: '/'-separated identifiers
+// that are not a reserved SQL keyword, as accepted in filters.
+func ValidateField(name string) error {
+	if !fieldRegex.MatchString(name) {
+		return fmt.Errorf("invalid field name: %q", name)
+	}
+	if isReservedSQLKeyword(name) {
+		return fmt.Errorf("invalid field name: %q is a reserved SQL keyword", name)
+	}
+	return nil
+}
+
+// ValidateNumber reports whether s is a number literal as accepted in filters: an integer or a
+// decimal with an optional exponent, such as "-1.5e3". Spellings like "NaN", "+1", "0x1p3"
+// or "1_000" are rejected.
+func ValidateNumber(s string) error {
+	if !numberRegex.MatchString(s) {
+		return fmt.Errorf("invalid number %q", s)
+	}
+	return nil
+}
+
+// CheckAST validates an AST built outside the parser, such as by the filter builder, with the
+// rules BuildAST applies while parsing: field names, literal values, lambda scopes,
+// opts.Limits, opts.Lambdas and the opts field hooks. Aliases do not apply. The result has the fields rewritten by
+// AuthorizeField; node itself is not modified. Errors have no position in a text, and report 0.
+func CheckAST(node ast.Node, opts Options) (ast.Node, error) {
+	limits := opts.Limits.withDefaults()
+	if n := len(ast.Format(node)); exceeds(n, limits.MaxFilterLength) {
+		return nil, newError(CodeFilterTooLong, 0, "filter length %d exceeds the maximum of %d bytes", n, limits.MaxFilterLength)
+	}
+	p := &parser{
+		limits:     limits,
+		nodes:      new(int),
+		fieldCheck: opts.CheckField,
+		authorize:  opts.AuthorizeField,
+		lambdas:    opts.Lambdas,
+	}
+	return p.checkNode(node, 0)
+}
+
+// checkNode validates node at the given nesting depth and returns it with its fields resolved.
+func (p *parser) checkNode(node ast.Node, depth int) (ast.Node, error) {
+	if exceeds(depth, p.limits.MaxDepth) {
+		return nil, newError(CodeMaxDepthExceeded, 0, "exceeded maximum nesting depth of %d", p.limits.MaxDepth)
+	}
+	if err := p.countNode(); err != nil {
+		return nil, err
+	}
+
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		left, err := p.checkNode(n.Left, depth)
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.checkNode(n.Right, depth)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.BinaryNode{Op: n.Op, Left: left, Right: right}, nil
+	case *ast.NotNode:
+		child, err := p.checkNode(n.Child, depth)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.NotNode{Child: child}, nil
+	case *ast.ParenNode:
+		child, err := p.checkNode(n.Child, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ParenNode{Child: child}, nil
+	case *ast.ConditionNode:
+		if err := p.checkLiterals(n.Value); err != nil {
+			return nil, err
+		}
+		field, err := p.checkField(n.Field, n.Op, []ast.Literal{n.Value})
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ConditionNode{Field: field, Op: n.Op, Value: n.Value}, nil
+	case *ast.InNode:
+		if exceeds(len(n.Values), p.limits.MaxInValues) {
+			return nil, newError(CodeMaxInValuesExceeded, 0, "IN list exceeds the maximum of %d values", p.limits.MaxInValues)
+		}
+		if err := p.checkLiterals(n.Values...); err != nil {
+			return nil, err
+		}
+		field, err := p.checkField(n.Field, opIn, n.Values)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.InNode{Field: field, Values: n.Values}, nil
+	case *ast.FunctionNode:
+		if err := p.checkLiterals(n.Value); err != nil {
+			return nil, err
+		}
+		field, err := p.checkField(n.Field, n.Name, []ast.Literal{n.Value})
+		if err != nil {
+			return nil, err
+		}
+		return &ast.FunctionNode{Name: n.Name, Field: field, Value: n.Value}, nil
+	case *ast.LambdaNode:
+		if !p.lambdas {
+			return nil, newError(CodeInvalidFilter, 0, "lambda operator %q is not supported in SQL filters", n.Op)
+		}
+		field, err := p.checkField(n.Field, n.Op, nil)
+		if err != nil {
+			return nil, err
+		}
+		checked := &ast.LambdaNode{Op: n.Op, Field: field, Variable: n.Variable}
+		if n.Predicate == nil {
+			return checked, nil
+		}
+		p.scopes = append(p.scopes, lambdaScope{variable: n.Variable, path: field})
+		checked.Predicate, err = p.checkNode(n.Predicate, depth+1)
+		p.scopes = p.scopes[:len(p.scopes)-1]
+		if err != nil {
+			return nil, err
+		}
+		return checked, nil
+	}
+	return nil, newError(CodeInvalidFilter, 0, "unsupported expression %T", node)
+}
+
+// checkField validates a field reference and resolves it like resolveField. Inside a lambda
+// predicate, the field must be a path below the collection.
+func (p *parser) checkField(field, op string, values []ast.Literal) (string, error) {
+	if err := ValidateField(field); err != nil {
+		return "", newError(CodeInvalidFilter, 0, "%s", err)
+	}
+	if n := len(p.scopes); n > 0 && !withinPath(field, p.scopes[n-1].path) {
+		return "", newError(CodeInvalidFilter, 0, "invalid field name: %q is outside of collection %q", field, p.scopes[n-1].path)
+	}
+	return p.resolveField(token{val: field}, op, values)
+}
+
+// checkLiterals validates literals like validateValue and enforces Limits.MaxLiteralLength on
+// string literals.
+func (p *parser) checkLiterals(values ...ast.Literal) error {
+	for _, v := range values {
+		switch v.Kind {
+		case ast.KindNumber:
+			if err := ValidateNumber(v.Value); err != nil {
+				return newError(CodeInvalidFilter, 0, "%s", err)
+			}
+		case ast.KindString:
+			if err := validateText(v.Value); err != nil {
+				return newError(CodeInvalidFilter, 0, "%s", err)
+			}
+			if exceeds(len(v.Value), p.limits.MaxLiteralLength) {
+				return newError(CodeLiteralTooLong, 0,
+					"string literal length %d exceeds the maximum of %d bytes", len(v.Value), p.limits.MaxLiteralLength)
+			}
+		}
+	}
+	return nil
+}
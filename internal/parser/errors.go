@@ -0,0 +1,50 @@
+package parser
+
+import "fmt"
+
+// Error codes identifying why a filter was rejected.
+const (
+	// CodeInvalidFilter reports a filter that is not syntactically or semantically valid.
+	CodeInvalidFilter = "InvalidFilter"
+	// CodeFilterTooLong reports a filter (or alias value) longer than Limits.MaxFilterLength.
+	CodeFilterTooLong = "FilterTooLong"
+	// CodeLiteralTooLong reports a string literal longer than Limits.MaxLiteralLength.
+	CodeLiteralTooLong = "LiteralTooLong"
+	// CodeMaxDepthExceeded reports parentheses nested deeper than Limits.MaxDepth.
+	CodeMaxDepthExceeded = "MaxDepthExceeded"
+	// CodeMaxNodesExceeded reports a filter producing more than Limits.MaxNodes AST nodes.
+	CodeMaxNodesExceeded = "MaxNodesExceeded"
+	// CodeMaxInValuesExceeded reports an IN list with more than Limits.MaxInValues values.
+	CodeMaxInValuesExceeded = "MaxInValuesExceeded"
+	// CodeMaxClausesExceeded reports a normal form with more than Limits.MaxClauses clauses.
+	CodeMaxClausesExceeded = "MaxClausesExceeded"
+	// CodeMaxFunctionArgsExceeded reports a function call with more than Limits.MaxFunctionArgs
+	// arguments.
+	CodeMaxFunctionArgsExceeded = "MaxFunctionArgsExceeded"
+	// CodeFieldAccessDenied reports a field reference rejected by Options.AuthorizeField.
+	CodeFieldAccessDenied = "FieldAccessDenied"
+	// CodeCostExceeded reports a filter whose estimated cost exceeds the caller's budget.
+	CodeCostExceeded = "CostExceeded"
+	// CodeUnsupportedSQL reports a SQL condition that is malformed or outside the subset
+	// ParseSQL converts.
+	CodeUnsupportedSQL = "UnsupportedSQL"
+)
+
+// Error describes why a filter could not be tokenized or parsed.
+type Error struct {
+	// Code is one of the Code* constants.
+	Code string
+	// Position is the byte offset in the filter where the problem was detected.
+	Position int
+	// Message is a human-readable description of the problem.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (at position %d)", e.Message, e.Position)
+}
+
+// newError creates an Error with a formatted message.
+func newError(code string, pos int, format string, args ...any) *Error {
+	return &Error{Code: code, Position: pos, Message: fmt.Sprintf(format, args...)}
+}
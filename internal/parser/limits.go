@@ -0,0 +1,76 @@
+package parser
+
+// Default resource limits applied when a Limits field is left at zero.
+const (
+	defaultMaxDepth         = 10
+	defaultMaxNodes         = 500
+	defaultMaxInValues      = 1000
+	defaultMaxLiteralLength = 1024
+	defaultMaxFilterLength  = 8192
+	defaultMaxClauses       = 1024
+	defaultMaxFunctionArgs  = 8
+)
+
+// Limits bounds the resources a single filter may consume while it is tokenized and parsed.
+// A zero field uses the default limit; a negative field disables that check.
+type Limits struct {
+	// MaxDepth is the maximum nesting depth of parenthesized expressions.
+	MaxDepth int
+	// MaxNodes is the maximum number of nodes in the resulting AST.
+	MaxNodes int
+	// MaxInValues is the maximum number of values in a single IN list.
+	MaxInValues int
+	// MaxLiteralLength is the maximum length in bytes of a string literal, after unescaping.
+	MaxLiteralLength int
+	// MaxFilterLength is the maximum length in bytes of the filter and of each alias value.
+	MaxFilterLength int
+	// MaxClauses is the maximum number of clauses in a normal form computed from the filter.
+	MaxClauses int
+	// MaxFunctionArgs is the maximum number of arguments in a function call.
+	MaxFunctionArgs int
+}
+
+// DefaultLimits returns the limits used when none are configured.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxDepth:         defaultMaxDepth,
+		MaxNodes:         defaultMaxNodes,
+		MaxInValues:      defaultMaxInValues,
+		MaxLiteralLength: defaultMaxLiteralLength,
+		MaxFilterLength:  defaultMaxFilterLength,
+		MaxClauses:       defaultMaxClauses,
+		MaxFunctionArgs:  defaultMaxFunctionArgs,
+	}
+}
+
+// withDefaults returns a copy of l with zero fields replaced by their defaults.
+func (l Limits) withDefaults() Limits {
+	d := DefaultLimits()
+	if l.MaxDepth == 0 {
+		l.MaxDepth = d.MaxDepth
+	}
+	if l.MaxNodes == 0 {
+		l.MaxNodes = d.MaxNodes
+	}
+	if l.MaxInValues == 0 {
+		l.MaxInValues = d.MaxInValues
+	}
+	if l.MaxLiteralLength == 0 {
+		l.MaxLiteralLength = d.MaxLiteralLength
+	}
+	if l.MaxFilterLength == 0 {
+		l.MaxFilterLength = d.MaxFilterLength
+	}
+	if l.MaxClauses == 0 {
+		l.MaxClauses = d.MaxClauses
+	}
+	if l.MaxFunctionArgs == 0 {
+		l.MaxFunctionArgs = d.MaxFunctionArgs
+	}
+	return l
+}
+
+// exceeds reports whether n is over limit, treating negative limits as unlimited.
+func exceeds(n, limit int) bool {
+	return limit >= 0 && n > limit
+}
@@ -1,26 +1,17 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/maxlambrecht/odatasql/internal/ast"
 )
 
-const (
-	sqlEq = "="
-	sqlNe = "!="
-	sqlGt = ">"
-	sqlGe = ">="
-	sqlLt = "<"
-	sqlLe = "<="
-)
-
-const maxNestingDepth = 10
-
-var camelToSnakeRegex = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+// fieldRegex matches property names and '/'-separated property paths. Naming strategies may
+// pass names through unchanged, so anything else is rejected before it can reach the SQL.
+var fieldRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(/[A-Za-z_][A-Za-z0-9_]*)*$`)
 
 var reservedSQLKeywords = map[string]struct{}{
 	"select": {}, "insert": {}, "update": {}, "delete": {}, "drop": {}, "alter": {},
@@ -28,41 +19,101 @@ var reservedSQLKeywords = map[string]struct{}{
 	"limit": {}, "offset": {}, "union": {}, "except": {}, "intersect": {},
 }
 
-// opMapping maps OData operators to SQL operators.
-var opMapping = map[string]string{
-	"eq": sqlEq,
-	"ne": sqlNe,
-	"gt": sqlGt,
-	"ge": sqlGe,
-	"lt": sqlLt,
-	"le": sqlLe,
+// opIn is the operator reported to Options.AuthorizeField for IN conditions.
+const opIn = "in"
+
+// stringFunctions lists the supported boolean string functions.
+var stringFunctions = map[string]bool{
+	ast.FuncContains:   true,
+	ast.FuncStartsWith: true,
+	ast.FuncEndsWith:   true,
+}
+
+// comparisonOperators maps comparison operator tokens to their AST operators.
+var comparisonOperators = map[tokenType]string{
+	tOpEq: ast.OpEq,
+	tOpNe: ast.OpNe,
+	tOpGt: ast.OpGt,
+	tOpGe: ast.OpGe,
+	tOpLt: ast.OpLt,
+	tOpLe: ast.OpLe,
+}
+
+// Options configures how a filter is parsed.
+type Options struct {
+	// Aliases maps parameter alias names (without '@') to their raw values.
+	// Every alias in the map must be referenced by the filter.
+	Aliases map[string]string
+	// Limits bounds the resources the filter may consume.
+	Limits Limits
+	// CheckField validates a field reference together with the operator or function applied to
+	// it and the literals it is compared against. A nil CheckField accepts any field.
+	CheckField func(field, op string, values []ast.Literal) error
+	// AuthorizeField is called for every field reference with the operator or function applied to it,
+	// before CheckField. It returns the field to use in place of the reference, or an error
+	// denying access, reported with CodeFieldAccessDenied. A nil AuthorizeField allows any field.
+	AuthorizeField func(field, op string) (string, error)
+	// Lambdas enables the any and all lambda operators. Targets that cannot evaluate them,
+	// such as SQL, leave it unset so that they are rejected with a position.
+	Lambdas bool
 }
 
 // BuildAST converts an OData filter string into an AST by tokenizing and parsing it.
-func BuildAST(filter string) (ast.Node, error) {
-	tokens, err := tokenize(filter)
+func BuildAST(filter string, opts Options) (ast.Node, error) {
+	limits := opts.Limits.withDefaults()
+	tokens, err := tokenize(filter, limits)
 	if err != nil {
 		return nil, fmt.Errorf("tokenization failed: %w", err)
 	}
-	return parse(tokens)
+	return parse(tokens, len(filter), opts, limits)
 }
 
 // --- Parser Struct & Entry Point ---
 
 type parser struct {
-	tokens []token
-	pos    int
+	tokens     []token
+	pos        int
+	end        int // position reported for errors at the end of input
+	aliases    map[string]string
+	used       map[string]bool
+	limits     Limits
+	nodes      *int // AST nodes created so far, shared with alias sub-parsers
+	fieldCheck func(field, op string, values []ast.Literal) error
+	authorize  func(field, op string) (string, error)
+	lambdas    bool
+	scopes     []lambdaScope // enclosing lambda operators, innermost last
+}
+
+// lambdaScope binds a lambda variable to the path of the collection it ranges over.
+type lambdaScope struct {
+	variable string
+	path     string
 }
 
 // parse starts the parsing process and returns the root node of the AST.
-func parse(tokens []token) (ast.Node, error) {
-	p := &parser{tokens: tokens}
+func parse(tokens []token, end int, opts Options, limits Limits) (ast.Node, error) {
+	p := &parser{
+		tokens:     tokens,
+		end:        end,
+		aliases:    opts.Aliases,
+		used:       make(map[string]bool),
+		limits:     limits,
+		nodes:      new(int),
+		fieldCheck: opts.CheckField,
+		authorize:  opts.AuthorizeField,
+		lambdas:    opts.Lambdas,
+	}
 	node, err := p.parseExpression(0)
 	if err != nil {
 		return nil, err
 	}
 	if !p.isAtEnd() {
-		return nil, fmt.Errorf("unexpected extra tokens: %v", p.current())
+		return nil, p.errorf("unexpected extra tokens: %v", p.current())
+	}
+	for name := range p.aliases {
+		if !p.used[name] {
+			return nil, newError(CodeInvalidFilter, 0, "parameter alias @%s is defined but not used", name)
+		}
 	}
 	return node, nil
 }
@@ -70,18 +121,15 @@ func parse(tokens []token) (ast.Node, error) {
 // --- Recursive Descent Parsing ---
 
 func (p *parser) parseExpression(depth int) (ast.Node, error) {
-	if depth > maxNestingDepth {
-		return nil, fmt.Errorf("exceeded maximum nesting depth of %d", maxNestingDepth)
+	if exceeds(depth, p.limits.MaxDepth) {
+		return nil, newError(CodeMaxDepthExceeded, p.position(),
+			"exceeded maximum nesting depth of %d", p.limits.MaxDepth)
 	}
 	return p.parseOr(depth)
 }
 
 // parseOr handles OR expressions: `<andExpr> OR <andExpr>`.
 func (p *parser) parseOr(depth int) (ast.Node, error) {
-	if depth > maxNestingDepth {
-		return nil, fmt.Errorf("exceeded maximum nesting depth of %d", maxNestingDepth)
-	}
-
 	left, err := p.parseAnd(depth)
 	if err != nil {
 		return nil, err
@@ -89,12 +137,15 @@ func (p *parser) parseOr(depth int) (ast.Node, error) {
 
 	for p.match(tOpOr) {
 		if p.isAtEnd() {
-			return nil, fmt.Errorf("expected expression after OR, but found end of input")
+			return nil, p.errorf("expected expression after OR, but found end of input")
 		}
 		right, err := p.parseAnd(depth)
 		if err != nil {
 			return nil, err
 		}
+		if err := p.countNode(); err != nil {
+			return nil, err
+		}
 		left = &ast.BinaryNode{Op: ast.OpOr, Left: left, Right: right}
 	}
 	return left, nil
@@ -102,23 +153,22 @@ func (p *parser) parseOr(depth int) (ast.Node, error) {
 
 // parseAnd handles AND expressions: `<notExpr> AND <notExpr>`.
 func (p *parser) parseAnd(depth int) (ast.Node, error) {
-	if depth > maxNestingDepth {
-		return nil, fmt.Errorf("exceeded maximum nesting depth of %d", maxNestingDepth)
-	}
-
 	left, err := p.parseNot(depth)
 	if err != nil {
 		return nil, err
 	}
 	for p.match(tOpAnd) {
 		if p.isAtEnd() {
-			return nil, fmt.Errorf("expected expression after AND, but found end of input")
+			return nil, p.errorf("expected expression after AND, but found end of input")
 		}
 
 		right, err := p.parseNot(depth)
 		if err != nil {
 			return nil, err
 		}
+		if err := p.countNode(); err != nil {
+			return nil, err
+		}
 		left = &ast.BinaryNode{Op: ast.OpAnd, Left: left, Right: right}
 	}
 	return left, nil
@@ -126,13 +176,12 @@ func (p *parser) parseAnd(depth int) (ast.Node, error) {
 
 // parseNot handles NOT expressions: `NOT <primaryExpr>`.
 func (p *parser) parseNot(depth int) (ast.Node, error) {
-	if depth > maxNestingDepth {
-		return nil, fmt.Errorf("exceeded maximum nesting depth of %d", maxNestingDepth)
-	}
-
 	if p.match(tOpNot) {
 		if p.isAtEnd() {
-			return nil, fmt.Errorf("invalid use of NOT: missing expression")
+			return nil, p.errorf("invalid use of NOT: missing expression")
+		}
+		if err := p.countNode(); err != nil {
+			return nil, err
 		}
 		child, err := p.parseNot(depth)
 		if err != nil {
@@ -145,110 +194,490 @@ func (p *parser) parseNot(depth int) (ast.Node, error) {
 
 // parsePrimary handles parenthesized expressions and simple conditions.
 func (p *parser) parsePrimary(depth int) (ast.Node, error) {
-	if depth > maxNestingDepth {
-		return nil, fmt.Errorf("exceeded maximum nesting depth of %d", maxNestingDepth)
-	}
-
 	if p.match(tParenOpen) {
+		if err := p.countNode(); err != nil {
+			return nil, err
+		}
 		node, err := p.parseExpression(depth + 1)
 		if err != nil {
 			return nil, err
 		}
 		if !p.expect(tParenClose) {
-			return nil, fmt.Errorf("missing closing parenthesis")
+			return nil, p.errorf("missing closing parenthesis")
 		}
 		return &ast.ParenNode{Child: node}, nil
 	}
-	return p.parseConditionOrIn()
+	return p.parseConditionOrIn(depth)
 }
 
-// parseConditionOrIn parses conditions like `field eq value` or `field in (value1, value2)`.
-func (p *parser) parseConditionOrIn() (ast.Node, error) {
+// parseConditionOrIn parses conditions like `field eq value`, `field in (value1, value2)`
+// or `field in ["value1", "value2"]`, string function calls and lambda operators.
+func (p *parser) parseConditionOrIn(depth int) (ast.Node, error) {
 	if !p.check(tIdentifier) {
-		return nil, fmt.Errorf("expected field name, got %v", p.current())
+		return nil, p.errorf("expected field name, got %s", p.describeCurrent())
+	}
+	if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tParenOpen {
+		if lambdaOperator(p.current().val) != "" {
+			return p.parseLambda(depth)
+		}
+		return p.parseFunction()
 	}
 
-	// Extract field name
-	fieldTok := p.current()
-	field := toSnakeCase(fieldTok.val)
-	p.advance()
+	fieldTok, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	field := fieldTok.val
 
-	if isReservedSQLKeyword(field) {
-		return nil, fmt.Errorf("invalid field name: %q is a reserved SQL keyword", field)
+	if err := p.countNode(); err != nil {
+		return nil, err
 	}
 
 	// --- Handle IN Operator ---
 	if p.match(tOpIn) {
-		if !p.expect(tParenOpen) {
-			return nil, fmt.Errorf("expected '(' after 'IN'")
+		var values []ast.Literal
+		switch {
+		case p.check(tAlias):
+			values, err = p.parseAliasList()
+		case p.match(tParenOpen):
+			values, err = p.parseInList(tParenClose)
+		case p.match(tBracketOpen):
+			values, err = p.parseInList(tBracketClose)
+		default:
+			return nil, p.errorf("expected '(' or '[' after 'IN'")
 		}
-
-		var values []string
-		if p.check(tParenClose) {
-			return nil, fmt.Errorf("IN operator must have at least one value")
+		if err != nil {
+			return nil, err
 		}
+		field, err = p.resolveField(fieldTok, opIn, values)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.InNode{Field: field, Values: values}, nil
+	}
 
-		for {
-			if p.check(tParenClose) {
-				break
-			}
-			if p.isAtEnd() {
-				return nil, fmt.Errorf("unclosed IN list")
-			}
+	// --- Handle Simple Binary Condition ---
+	if p.isAtEnd() {
+		return nil, p.errorf("expected operator after field %q", fieldTok.val)
+	}
 
-			tok := p.current()
-			if tok.typ != tString && tok.typ != tNumber && tok.typ != tIdentifier {
-				return nil, fmt.Errorf("invalid value in IN list: %v", tok)
-			}
+	opTok := p.current()
+	op, ok := comparisonOperators[opTok.typ]
+	if !ok {
+		return nil, p.errorf("unsupported operator: %s", opTok.val)
+	}
+	p.advance()
 
-			sanitizedValue, err := validateAndSanitizeValue(tok.val)
-			if err != nil {
-				return nil, fmt.Errorf("invalid value in condition: %w", err)
-			}
+	if p.isAtEnd() {
+		return nil, p.errorf("missing value after operator %q", opTok.val)
+	}
+
+	var value ast.Literal
+	if p.check(tAlias) {
+		value, err = p.parseAliasValue()
+	} else {
+		value, err = p.parseValue()
+	}
+	if err != nil {
+		return nil, err
+	}
+	field, err = p.resolveField(fieldTok, op, []ast.Literal{value})
+	if err != nil {
+		return nil, err
+	}
 
-			values = append(values, sanitizedValue)
-			p.advance()
+	return &ast.ConditionNode{Field: field, Op: op, Value: value}, nil
+}
 
-			if !p.match(tComma) {
-				break
+// parseField consumes and validates a field reference.
+func (p *parser) parseField() (token, error) {
+	fieldTok := p.current()
+	p.advance()
+
+	if !fieldRegex.MatchString(fieldTok.val) {
+		return fieldTok, newError(CodeInvalidFilter, fieldTok.pos, "invalid field name: %q", fieldTok.val)
+	}
+	if isReservedSQLKeyword(fieldTok.val) {
+		return fieldTok, newError(CodeInvalidFilter, fieldTok.pos, "invalid field name: %q is a reserved SQL keyword", fieldTok.val)
+	}
+	return p.scopeField(fieldTok)
+}
+
+// scopeField replaces the lambda variable starting a field reference inside a lambda predicate
+// with the path of its collection. Inside a predicate, properties must be reached through a
+// lambda variable so that every renderer can resolve them relative to the collection elements.
+func (p *parser) scopeField(fieldTok token) (token, error) {
+	if len(p.scopes) == 0 {
+		return fieldTok, nil
+	}
+	head, rest, _ := strings.Cut(fieldTok.val, "/")
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if p.scopes[i].variable == head {
+			fieldTok.val = p.scopes[i].path
+			if rest != "" {
+				fieldTok.val += "/" + rest
 			}
+			return fieldTok, nil
 		}
+	}
+	return fieldTok, newError(CodeInvalidFilter, fieldTok.pos,
+		"invalid field name: %q must start with the lambda variable %s", fieldTok.val, p.scopes[len(p.scopes)-1].variable)
+}
 
-		if !p.expect(tParenClose) {
-			return nil, fmt.Errorf("missing closing parenthesis in IN list")
+// lambdaOperator returns the lambda operator ending a field reference like "tags/any", or "".
+func lambdaOperator(name string) string {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return ""
+	}
+	switch op := name[i+1:]; op {
+	case ast.LambdaAny, ast.LambdaAll:
+		return op
+	}
+	return ""
+}
+
+// parseLambda parses a lambda operator: `tags/any(t: t eq 'red')`, `items/all(i: i/price gt 10)`
+// or `tags/any()`.
+func (p *parser) parseLambda(depth int) (ast.Node, error) {
+	nameTok := p.current()
+	op := lambdaOperator(nameTok.val)
+	if !p.lambdas {
+		return nil, newError(CodeInvalidFilter, nameTok.pos, "lambda operator %q is not supported in SQL filters", op)
+	}
+	if err := p.countNode(); err != nil {
+		return nil, err
+	}
+
+	p.tokens[p.pos].val = strings.TrimSuffix(nameTok.val, "/"+op)
+	collTok, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	p.advance() // '('
+
+	field, err := p.resolveField(collTok, op, nil)
+	if err != nil {
+		return nil, err
+	}
+	node := &ast.LambdaNode{Op: op, Field: field}
+	if p.match(tParenClose) {
+		if op == ast.LambdaAll {
+			return nil, p.errorf("all requires a lambda predicate")
 		}
+		return node, nil
+	}
 
-		return &ast.InNode{Field: field, Values: values}, nil
+	if node.Variable, err = p.lambdaVariable(); err != nil {
+		return nil, err
 	}
+	p.scopes = append(p.scopes, lambdaScope{variable: node.Variable, path: field})
+	node.Predicate, err = p.parseExpression(depth + 1)
+	p.scopes = p.scopes[:len(p.scopes)-1]
+	if err != nil {
+		return nil, err
+	}
+	if !p.expect(tParenClose) {
+		return nil, p.errorf("missing closing parenthesis after predicate of %s", op)
+	}
+	return node, nil
+}
 
-	// --- Handle Simple Binary Condition ---
-	if p.isAtEnd() {
-		return nil, fmt.Errorf("expected operator after field %q", fieldTok.val)
+// lambdaVariable consumes the `t:` declaring a lambda variable. The tokenizer does not split on
+// ':', which also appears in unquoted date-time literals, so the colon is found inside the
+// identifier tokens: "t:t", "t:" followed by "t", or "t" followed by ":" or ":t".
+func (p *parser) lambdaVariable() (string, error) {
+	if !p.check(tIdentifier) {
+		return "", p.errorf("expected lambda variable, got %s", p.describeCurrent())
+	}
+	tok := p.current()
+	name, rest, found := strings.Cut(tok.val, ":")
+	restPos := tok.pos + len(name) + 1
+	if !found {
+		p.advance()
+		if !p.check(tIdentifier) || !strings.HasPrefix(p.current().val, ":") {
+			return "", p.errorf("expected ':' after lambda variable %s", name)
+		}
+		tok = p.current()
+		rest, restPos = tok.val[1:], tok.pos+1
+	}
+	if !isValidAliasName(name) || isReservedSQLKeyword(name) {
+		return "", newError(CodeInvalidFilter, tok.pos, "invalid lambda variable: %q", name)
+	}
+	for _, scope := range p.scopes {
+		if scope.variable == name {
+			return "", newError(CodeInvalidFilter, tok.pos, "lambda variable %s is already defined", name)
+		}
 	}
 
-	opTok := p.current()
-	if !isValidOperator(opTok.val) {
-		return nil, fmt.Errorf("unsupported operator: %s", opTok.val)
+	// Continue with the rest of the token, if any, as the start of the predicate.
+	if rest == "" {
+		p.advance()
+	} else {
+		next := classifyWord(rest)
+		next.pos = restPos
+		p.tokens[p.pos] = next
 	}
-	p.advance()
+	return name, nil
+}
 
-	sqlOp := opMapping[opTok.val]
+// parseFunction parses a boolean string function call: `contains(field, 'value')`.
+func (p *parser) parseFunction() (ast.Node, error) {
+	nameTok := p.current()
+	name := strings.ToLower(nameTok.val)
+	if !stringFunctions[name] {
+		return nil, newError(CodeInvalidFilter, nameTok.pos, "unsupported function: %q", nameTok.val)
+	}
+	p.advance()
+	p.advance() // '('
 
+	if err := p.countNode(); err != nil {
+		return nil, err
+	}
+	if err := p.checkArity(name); err != nil {
+		return nil, err
+	}
+	if !p.check(tIdentifier) {
+		return nil, p.errorf("expected field name as first argument of %s, got %s", name, p.describeCurrent())
+	}
+	fieldTok, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	if !p.match(tComma) {
+		return nil, p.errorf("expected ',' after first argument of %s", name)
+	}
 	if p.isAtEnd() {
-		return nil, fmt.Errorf("missing value after operator %q", opTok.val)
+		return nil, p.errorf("missing second argument of %s", name)
+	}
+
+	valueTok := p.current()
+	var value ast.Literal
+	if p.check(tAlias) {
+		value, err = p.parseAliasValue()
+	} else {
+		value, err = p.parseValue()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if value.Kind != ast.KindString {
+		return nil, newError(CodeInvalidFilter, valueTok.pos, "second argument of %s must be a string", name)
+	}
+	if !p.match(tParenClose) {
+		return nil, p.errorf("missing closing parenthesis after arguments of %s", name)
 	}
+
+	field, err := p.resolveField(fieldTok, name, []ast.Literal{value})
+	if err != nil {
+		return nil, err
+	}
+	return &ast.FunctionNode{Name: name, Field: field, Value: value}, nil
+}
+
+// checkArity enforces Limits.MaxFunctionArgs on the arguments of a function call, counted up
+// to its closing parenthesis before they are parsed. The opening parenthesis must already have
+// been consumed. Errors are reported at the comma starting the first extra argument.
+func (p *parser) checkArity(name string) error {
+	args, depth := 1, 0
+	for _, tok := range p.tokens[p.pos:] {
+		switch tok.typ {
+		case tParenOpen, tBracketOpen:
+			depth++
+		case tBracketClose:
+			depth--
+		case tParenClose:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		case tComma:
+			if depth > 0 {
+				continue
+			}
+			if args++; exceeds(args, p.limits.MaxFunctionArgs) {
+				return newError(CodeMaxFunctionArgsExceeded, tok.pos,
+					"%s exceeds the maximum of %d function arguments", name, p.limits.MaxFunctionArgs)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveField runs the configured authorization and field check on a field reference, and
+// returns the field to use in its place. Failures are reported at the field reference.
+func (p *parser) resolveField(fieldTok token, op string, values []ast.Literal) (string, error) {
+	field := fieldTok.val
+	if p.authorize != nil {
+		rewritten, err := p.authorize(field, op)
+		if err != nil {
+			return "", newError(CodeFieldAccessDenied, fieldTok.pos, "access to field %q denied: %s", field, err)
+		}
+		if !fieldRegex.MatchString(rewritten) {
+			return "", newError(CodeInvalidFilter, fieldTok.pos, "field %q rewritten to invalid field name %q", field, rewritten)
+		}
+		if isReservedSQLKeyword(rewritten) {
+			return "", newError(CodeInvalidFilter, fieldTok.pos, "field %q rewritten to %q, a reserved SQL keyword", field, rewritten)
+		}
+		if n := len(p.scopes); n > 0 && !withinPath(rewritten, p.scopes[n-1].path) {
+			return "", newError(CodeInvalidFilter, fieldTok.pos, "field %q rewritten to %q outside of collection %q", field, rewritten, p.scopes[n-1].path)
+		}
+		field = rewritten
+	}
+	if p.fieldCheck != nil {
+		if err := p.fieldCheck(field, op, values); err != nil {
+			return "", newError(CodeInvalidFilter, fieldTok.pos, "invalid field %q: %s", fieldTok.val, err)
+		}
+	}
+	return field, nil
+}
+
+// withinPath reports whether field is path or one of its sub-properties.
+func withinPath(field, path string) bool {
+	return field == path || strings.HasPrefix(field, path+"/")
+}
+
+// parseValue parses a single literal on the right-hand side of a comparison.
+func (p *parser) parseValue() (ast.Literal, error) {
 	valTok := p.current()
 	if valTok.typ != tString && valTok.typ != tNumber && valTok.typ != tIdentifier && valTok.typ != tLiteral {
-		return nil, fmt.Errorf("invalid value: %v", valTok)
+		return ast.Literal{}, p.errorf("invalid value: %v", valTok)
+	}
+	p.advance()
+
+	value, err := validateValue(valTok)
+	if err != nil {
+		return ast.Literal{}, newError(CodeInvalidFilter, valTok.pos, "invalid value in condition: %s", err)
+	}
+	return value, nil
+}
+
+// parseInList parses the values of an IN list up to and including the closing token.
+// The opening token must already have been consumed. Lists closed by ']' are OData 4.01
+// JSON collections and additionally accept double-quoted JSON strings.
+func (p *parser) parseInList(closing tokenType) ([]ast.Literal, error) {
+	var values []ast.Literal
+	if p.check(closing) {
+		return nil, p.errorf("IN operator must have at least one value")
+	}
+
+	for {
+		if p.check(closing) {
+			break
+		}
+		if p.isAtEnd() {
+			return nil, p.errorf("unclosed IN list")
+		}
+
+		tok := p.current()
+		isJSONString := tok.typ == tJSONString && closing == tBracketClose
+		if tok.typ != tString && tok.typ != tNumber && tok.typ != tIdentifier && !isJSONString {
+			return nil, p.errorf("invalid value in IN list: %v", tok)
+		}
+
+		value, err := validateValue(tok)
+		if err != nil {
+			return nil, newError(CodeInvalidFilter, tok.pos, "invalid value in condition: %s", err)
+		}
+
+		if exceeds(len(values)+1, p.limits.MaxInValues) {
+			return nil, newError(CodeMaxInValuesExceeded, tok.pos,
+				"IN list exceeds the maximum of %d values", p.limits.MaxInValues)
+		}
+		values = append(values, value)
+		p.advance()
+
+		if !p.match(tComma) {
+			break
+		}
+	}
+
+	if !p.expect(closing) {
+		if closing == tBracketClose {
+			return nil, p.errorf("missing closing bracket in IN list")
+		}
+		return nil, p.errorf("missing closing parenthesis in IN list")
 	}
+	return values, nil
+}
+
+// --- Parameter Aliases ---
+
+// aliasParser consumes the current alias token and returns a parser over the tokens of its value.
+func (p *parser) aliasParser() (*parser, token, error) {
+	aliasTok := p.current()
+	name := aliasTok.val
 	p.advance()
 
-	sanitizedValue, err := validateAndSanitizeValue(valTok.val)
+	raw, ok := p.aliases[name]
+	if !ok {
+		return nil, aliasTok, newError(CodeInvalidFilter, aliasTok.pos, "parameter alias @%s is not defined", name)
+	}
+	p.used[name] = true
+
+	tokens, err := tokenize(raw, p.limits)
+	if err != nil {
+		return nil, aliasTok, aliasError(aliasTok, err)
+	}
+	if len(tokens) == 0 {
+		return nil, aliasTok, newError(CodeInvalidFilter, aliasTok.pos, "parameter alias @%s has an empty value", name)
+	}
+	sub := &parser{tokens: tokens, end: len(raw), limits: p.limits, nodes: p.nodes}
+	return sub, aliasTok, nil
+}
+
+// parseAliasValue resolves an alias used as the right-hand side of a comparison.
+func (p *parser) parseAliasValue() (ast.Literal, error) {
+	sub, aliasTok, err := p.aliasParser()
+	if err != nil {
+		return ast.Literal{}, err
+	}
+	value, err := sub.parseValue()
 	if err != nil {
-		return nil, fmt.Errorf("invalid value in condition: %w", err)
+		return ast.Literal{}, aliasError(aliasTok, err)
+	}
+	if !sub.isAtEnd() {
+		return ast.Literal{}, aliasError(aliasTok, sub.errorf("parameter alias value must be a single literal, got extra tokens: %v", sub.current()))
 	}
+	return value, nil
+}
 
-	return &ast.ConditionNode{Field: field, Op: sqlOp, Value: sanitizedValue}, nil
+// parseAliasList resolves an alias used as the collection of an IN operator.
+// The alias value may be written either as ('a','b') or as a JSON-style array ['a','b'].
+func (p *parser) parseAliasList() ([]ast.Literal, error) {
+	sub, aliasTok, err := p.aliasParser()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []ast.Literal
+	switch {
+	case sub.match(tParenOpen):
+		values, err = sub.parseInList(tParenClose)
+	case sub.match(tBracketOpen):
+		values, err = sub.parseInList(tBracketClose)
+	default:
+		err = sub.errorf("parameter alias value for IN must be a collection, got %v", sub.current())
+	}
+	if err != nil {
+		return nil, aliasError(aliasTok, err)
+	}
+	if !sub.isAtEnd() {
+		return nil, aliasError(aliasTok, sub.errorf("unexpected extra tokens in parameter alias value: %v", sub.current()))
+	}
+	return values, nil
+}
+
+// aliasError relocates an error found inside an alias value to the alias reference in the
+// filter, keeping its code.
+func aliasError(aliasTok token, err error) error {
+	code := CodeInvalidFilter
+	msg := err.Error()
+	var perr *Error
+	if errors.As(err, &perr) {
+		code, msg = perr.Code, perr.Message
+	}
+	return newError(code, aliasTok.pos, "invalid value for parameter alias @%s: %s", aliasTok.val, msg)
 }
 
 // --- Parser Helper Functions ---
@@ -277,6 +706,37 @@ func (p *parser) isAtEnd() bool {
 	return p.pos >= len(p.tokens)
 }
 
+// position returns the position of the current token, or of the end of input.
+func (p *parser) position() int {
+	if p.isAtEnd() {
+		return p.end
+	}
+	return p.current().pos
+}
+
+// describeCurrent describes the current token for error messages.
+func (p *parser) describeCurrent() string {
+	if p.isAtEnd() {
+		return "end of input"
+	}
+	return p.current().String()
+}
+
+// errorf creates an InvalidFilter error located at the current token.
+func (p *parser) errorf(format string, args ...any) *Error {
+	return newError(CodeInvalidFilter, p.position(), format, args...)
+}
+
+// countNode records the creation of an AST node, enforcing Limits.MaxNodes.
+func (p *parser) countNode() error {
+	*p.nodes++
+	if exceeds(*p.nodes, p.limits.MaxNodes) {
+		return newError(CodeMaxNodesExceeded, p.position(),
+			"filter exceeds the maximum of %d AST nodes", p.limits.MaxNodes)
+	}
+	return nil
+}
+
 // advance moves to the next token.
 func (p *parser) advance() {
 	p.pos++
@@ -291,55 +751,50 @@ func (p *parser) expect(tt tokenType) bool {
 	return false
 }
 
-// validateAndSanitizeValue returns a properly formatted SQL literal for a value.
-func validateAndSanitizeValue(value string) (string, error) {
-	value = strings.TrimSpace(value)
+// validateValue converts a value token into a literal, rejecting values that look like SQL
+// injection attempts.
+func validateValue(tok token) (ast.Literal, error) {
+	if tok.typ == tNumber {
+		return ast.Literal{Kind: ast.KindNumber, Value: tok.val}, nil
+	}
 
-	// Allow valid numeric values without quotes
-	if _, err := strconv.Atoi(value); err == nil {
-		return value, nil
+	value := tok.val
+	if tok.typ == tString || tok.typ == tJSONString {
+		value = value[1 : len(value)-1] // Remove surrounding single quotes
 	}
-	if _, err := strconv.ParseFloat(value, 64); err == nil {
-		return value, nil
+	lower := strings.ToLower(value)
+	if err := validateText(value); err != nil {
+		return ast.Literal{}, err
 	}
 
-	lower := strings.ToLower(value)
+	switch {
+	case tok.typ == tLiteral && lower == "null":
+		return ast.Literal{Kind: ast.KindNull, Value: lower}, nil
+	case tok.typ == tLiteral:
+		return ast.Literal{Kind: ast.KindBoolean, Value: lower}, nil
+	default:
+		return ast.Literal{Kind: ast.KindString, Value: value}, nil
+	}
+}
 
-	// Prevent SQL injection attempts by blocking dangerous SQL characters and keywords
+// validateText rejects the text of a literal containing dangerous SQL characters or reserved
+// keywords, to prevent SQL injection attempts.
+func validateText(value string) error {
+	lower := strings.ToLower(value)
 	bannedPatterns := []string{";", "--", "/*", "*/"}
 	for _, pattern := range bannedPatterns {
 		if strings.Contains(lower, pattern) {
-			return "", fmt.Errorf("invalid input detected: %q", value)
+			return fmt.Errorf("invalid input detected: %q", value)
 		}
 	}
 
 	if isReservedSQLKeyword(lower) {
-		return "", fmt.Errorf("invalid input detected: %q is a reserved SQL keyword", value)
-	}
-
-	// Allow SQL booleans and NULL as-is (without quotes)
-	if lower == "true" || lower == "false" || lower == "null" {
-		return lower, nil
+		return fmt.Errorf("invalid input detected: %q is a reserved SQL keyword", value)
 	}
-
-	// Sanitize string values: Escape single quotes inside the value
-	value = strings.Trim(value, "'")             // Remove surrounding single quotes
-	value = strings.ReplaceAll(value, "'", "''") // Escape inner single quotes
-
-	// Wrap in single quotes for safe SQL usage
-	return fmt.Sprintf("'%s'", value), nil
-}
-
-func toSnakeCase(s string) string {
-	return strings.ToLower(camelToSnakeRegex.ReplaceAllString(s, "${1}_${2}"))
+	return nil
 }
 
 func isReservedSQLKeyword(s string) bool {
 	_, exists := reservedSQLKeywords[strings.ToLower(s)]
 	return exists
 }
-
-func isValidOperator(op string) bool {
-	_, exists := opMapping[op]
-	return exists
-}
@@ -0,0 +1,588 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// SQLOptions configures how a SQL condition is converted.
+type SQLOptions struct {
+	// Limits bounds the length and nesting of the condition and the size of IN lists.
+	Limits Limits
+	// Property maps a column, with its qualifiers joined by '.' and without quotes, to the
+	// property path it holds. A nil Property uses unqualified columns as property names and
+	// rejects qualified ones.
+	Property func(column string) (string, error)
+}
+
+type sqlTokenType int
+
+const (
+	sqlIdentifier sqlTokenType = iota // bare or quoted identifier
+	sqlString
+	sqlNumber
+	sqlOperator
+	sqlParenOpen
+	sqlParenClose
+	sqlComma
+	sqlDot
+)
+
+type sqlToken struct {
+	typ    sqlTokenType
+	val    string // unquoted value of identifiers and strings
+	quoted bool   // identifier written in quotes, never a keyword
+	pos    int
+}
+
+// sqlComparisons maps SQL comparison operators to OData operators.
+var sqlComparisons = map[string]string{
+	"=":  ast.OpEq,
+	"<>": ast.OpNe,
+	"!=": ast.OpNe,
+	">":  ast.OpGt,
+	">=": ast.OpGe,
+	"<":  ast.OpLt,
+	"<=": ast.OpLe,
+}
+
+// flippedComparisons maps operators to their counterparts with swapped operands.
+var flippedComparisons = map[string]string{
+	ast.OpEq: ast.OpEq,
+	ast.OpNe: ast.OpNe,
+	ast.OpGt: ast.OpLt,
+	ast.OpGe: ast.OpLe,
+	ast.OpLt: ast.OpGt,
+	ast.OpLe: ast.OpGe,
+}
+
+// ParseSQL converts a SQL WHERE condition into an AST. It accepts a conservative subset of SQL:
+// comparisons between a column and a literal, AND, OR, NOT and parentheses, IN lists, IS [NOT]
+// NULL (other comparisons with NULL are rejected), BETWEEN, and LIKE with patterns that map to
+// string functions ('abc%', '%abc', '%abc%' or a pattern without wildcards). Everything else,
+// such as functions, arithmetic, subqueries or placeholders, is reported with
+// CodeUnsupportedSQL.
+func ParseSQL(input string, opts SQLOptions) (ast.Node, error) {
+	limits := opts.Limits.withDefaults()
+	if exceeds(len(input), limits.MaxFilterLength) {
+		return nil, newError(CodeFilterTooLong, limits.MaxFilterLength,
+			"condition length %d exceeds the maximum of %d bytes", len(input), limits.MaxFilterLength)
+	}
+	tokens, err := tokenizeSQL(input, limits)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{tokens: tokens, end: len(input), limits: limits, property: opts.Property}
+	if len(tokens) == 0 {
+		return nil, p.errorf("empty condition")
+	}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isAtEnd() {
+		return nil, p.errorf("unexpected %s", p.describeCurrent())
+	}
+	return node, nil
+}
+
+func tokenizeSQL(s string, limits Limits) ([]sqlToken, error) {
+	var tokens []sqlToken
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case isWhitespace(ch):
+			i++
+		case ch == '(':
+			tokens = append(tokens, sqlToken{typ: sqlParenOpen, val: "(", pos: i})
+			i++
+		case ch == ')':
+			tokens = append(tokens, sqlToken{typ: sqlParenClose, val: ")", pos: i})
+			i++
+		case ch == ',':
+			tokens = append(tokens, sqlToken{typ: sqlComma, val: ",", pos: i})
+			i++
+		case ch == '.' && !(i+1 < len(s) && isDigit(s[i+1])):
+			tokens = append(tokens, sqlToken{typ: sqlDot, val: ".", pos: i})
+			i++
+		case strings.HasPrefix(s[i:], "--") || strings.HasPrefix(s[i:], "/*") || ch == ';':
+			return nil, newError(CodeUnsupportedSQL, i, "comments and statement separators are not supported")
+		case ch == '\'' || ch == '"' || ch == '`' || ch == '[':
+			closing := ch
+			if ch == '[' {
+				closing = ']'
+			}
+			value, n, ok := readSQLQuoted(s[i:], closing)
+			if !ok {
+				return nil, newError(CodeUnsupportedSQL, i, "unclosed quote")
+			}
+			if ch == '\'' {
+				if exceeds(len(value), limits.MaxLiteralLength) {
+					return nil, newError(CodeLiteralTooLong, i,
+						"string literal length %d exceeds the maximum of %d bytes", len(value), limits.MaxLiteralLength)
+				}
+				tokens = append(tokens, sqlToken{typ: sqlString, val: value, pos: i})
+			} else {
+				tokens = append(tokens, sqlToken{typ: sqlIdentifier, val: value, quoted: true, pos: i})
+			}
+			i += n
+		case strings.ContainsRune("=<>!", rune(ch)):
+			op := s[i : i+1]
+			if i+1 < len(s) && sqlComparisons[s[i:i+2]] != "" {
+				op = s[i : i+2]
+			}
+			if sqlComparisons[op] == "" {
+				return nil, newError(CodeUnsupportedSQL, i, "unsupported operator %q", op)
+			}
+			tokens = append(tokens, sqlToken{typ: sqlOperator, val: op, pos: i})
+			i += len(op)
+		case isDigit(ch) || ch == '.' || (ch == '-' && i+1 < len(s) && (isDigit(s[i+1]) || s[i+1] == '.')):
+			start := i
+			i++
+			for i < len(s) && (isDigit(s[i]) || s[i] == '.' || s[i] == 'e' || s[i] == 'E' ||
+				((s[i] == '+' || s[i] == '-') && (s[i-1] == 'e' || s[i-1] == 'E'))) {
+				i++
+			}
+			number := s[start:i]
+			if strings.HasPrefix(number, ".") || strings.HasPrefix(number, "-.") {
+				number = strings.Replace(number, ".", "0.", 1)
+			}
+			if !numberRegex.MatchString(number) {
+				return nil, newError(CodeUnsupportedSQL, start, "invalid number %q", s[start:i])
+			}
+			tokens = append(tokens, sqlToken{typ: sqlNumber, val: number, pos: start})
+		case isIdentifierStart(ch):
+			start := i
+			for i < len(s) && (isIdentifierStart(s[i]) || isDigit(s[i]) || s[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{typ: sqlIdentifier, val: s[start:i], pos: start})
+		default:
+			return nil, newError(CodeUnsupportedSQL, i, "unsupported character %q", ch)
+		}
+	}
+	return tokens, nil
+}
+
+// readSQLQuoted reads a quoted string or identifier, where the closing character is escaped
+// by doubling it, and returns its unquoted value and length.
+func readSQLQuoted(s string, closing byte) (string, int, bool) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != closing {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == closing {
+			sb.WriteByte(closing)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, true
+	}
+	return "", 0, false
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentifierStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+type sqlParser struct {
+	tokens   []sqlToken
+	pos      int
+	end      int
+	limits   Limits
+	nodes    int
+	property func(column string) (string, error)
+}
+
+func (p *sqlParser) parseOr(depth int) (ast.Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.matchKeyword("or") {
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &ast.BinaryNode{Op: ast.OpOr, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAnd(depth int) (ast.Node, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.matchKeyword("and") {
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &ast.BinaryNode{Op: ast.OpAnd, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseNot(depth int) (ast.Node, error) {
+	if p.matchKeyword("not") {
+		child, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.NotNode{Child: child}, nil
+	}
+	if p.check(sqlParenOpen) {
+		if exceeds(depth+1, p.limits.MaxDepth) {
+			return nil, newError(CodeMaxDepthExceeded, p.position(),
+				"exceeded maximum nesting depth of %d", p.limits.MaxDepth)
+		}
+		p.pos++
+		child, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.match(sqlParenClose) {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		return &ast.ParenNode{Child: child}, nil
+	}
+	return p.parsePredicate()
+}
+
+// parsePredicate parses a comparison, IN, IS NULL, LIKE or BETWEEN predicate.
+func (p *sqlParser) parsePredicate() (ast.Node, error) {
+	// A literal on the left of a comparison: 18 < age.
+	if p.check(sqlString) || p.check(sqlNumber) || p.checkLiteralKeyword() {
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		opTok := p.current()
+		if !p.match(sqlOperator) {
+			return nil, p.errorf("expected comparison operator, got %s", p.describeCurrent())
+		}
+		if value.Kind == ast.KindNull {
+			return nil, nullComparison(opTok)
+		}
+		field, err := p.parseColumn()
+		if err != nil {
+			return nil, err
+		}
+		return p.condition(field, flippedComparisons[sqlComparisons[opTok.val]], value)
+	}
+
+	field, err := p.parseColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	if opTok := p.current(); p.match(sqlOperator) {
+		if p.check(sqlIdentifier) && !p.checkLiteralKeyword() {
+			return nil, p.errorf("unsupported comparison with %s: only literals are supported", p.describeCurrent())
+		}
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if value.Kind == ast.KindNull {
+			return nil, nullComparison(opTok)
+		}
+		return p.condition(field, sqlComparisons[opTok.val], value)
+	}
+
+	if p.matchKeyword("is") {
+		op := ast.OpEq
+		if p.matchKeyword("not") {
+			op = ast.OpNe
+		}
+		if !p.matchKeyword("null") {
+			return nil, p.errorf("expected NULL after IS, got %s", p.describeCurrent())
+		}
+		return p.condition(field, op, ast.Literal{Kind: ast.KindNull, Value: "null"})
+	}
+
+	negated := p.matchKeyword("not")
+	var node ast.Node
+	switch {
+	case p.matchKeyword("in"):
+		node, err = p.parseIn(field)
+	case p.matchKeyword("like"):
+		node, err = p.parseLike(field)
+	case p.matchKeyword("between"):
+		node, err = p.parseBetween(field)
+	default:
+		return nil, p.errorf("unsupported predicate: expected comparison, IN, IS, LIKE or BETWEEN after column, got %s", p.describeCurrent())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negated {
+		if _, ok := node.(*ast.BinaryNode); ok {
+			node = &ast.ParenNode{Child: node}
+		}
+		node = &ast.NotNode{Child: node}
+	}
+	return node, nil
+}
+
+func (p *sqlParser) parseIn(field string) (ast.Node, error) {
+	if !p.match(sqlParenOpen) {
+		return nil, p.errorf("expected '(' after IN")
+	}
+	var values []ast.Literal
+	for {
+		if p.checkKeyword("select") {
+			return nil, p.errorf("subqueries are not supported")
+		}
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if value.Kind == ast.KindNull {
+			return nil, p.errorf("NULL is not supported in IN lists")
+		}
+		if exceeds(len(values)+1, p.limits.MaxInValues) {
+			return nil, newError(CodeMaxInValuesExceeded, p.position(),
+				"IN list exceeds the maximum of %d values", p.limits.MaxInValues)
+		}
+		values = append(values, value)
+		if !p.match(sqlComma) {
+			break
+		}
+	}
+	if !p.match(sqlParenClose) {
+		return nil, p.errorf("missing closing parenthesis in IN list")
+	}
+	return &ast.InNode{Field: field, Values: values}, nil
+}
+
+// parseLike converts a LIKE pattern into a string function, or an equality when it has no
+// wildcards.
+func (p *sqlParser) parseLike(field string) (ast.Node, error) {
+	patternTok := p.current()
+	if !p.match(sqlString) {
+		return nil, p.errorf("expected string pattern after LIKE, got %s", p.describeCurrent())
+	}
+	escape := ""
+	if p.matchKeyword("escape") {
+		escTok := p.current()
+		if !p.match(sqlString) || len(escTok.val) != 1 {
+			return nil, newError(CodeUnsupportedSQL, escTok.pos, "ESCAPE must be followed by a single character string")
+		}
+		escape = escTok.val
+	}
+
+	// Split the pattern into literal text and unescaped wildcards.
+	var text strings.Builder
+	leading, trailing := false, false
+	pattern := patternTok.val
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case escape != "" && ch == escape[0]:
+			if i+1 == len(pattern) {
+				return nil, newError(CodeUnsupportedSQL, patternTok.pos, "LIKE pattern ends with the escape character")
+			}
+			i++
+			text.WriteByte(pattern[i])
+		case ch == '%' && i == 0:
+			leading = true
+		case ch == '%' && i == len(pattern)-1:
+			trailing = true
+		case ch == '%' || ch == '_':
+			return nil, newError(CodeUnsupportedSQL, patternTok.pos,
+				"unsupported LIKE pattern %q: only leading and trailing %% wildcards are supported", pattern)
+		default:
+			text.WriteByte(ch)
+		}
+	}
+
+	value := ast.Literal{Kind: ast.KindString, Value: text.String()}
+	var name string
+	switch {
+	case leading && trailing:
+		name = ast.FuncContains
+	case trailing:
+		name = ast.FuncStartsWith
+	case leading:
+		name = ast.FuncEndsWith
+	default:
+		return p.condition(field, ast.OpEq, value)
+	}
+	if err := p.countNode(); err != nil {
+		return nil, err
+	}
+	return &ast.FunctionNode{Name: name, Field: field, Value: value}, nil
+}
+
+// parseBetween converts "x BETWEEN a AND b" into "x ge a and x le b".
+func (p *sqlParser) parseBetween(field string) (ast.Node, error) {
+	low, err := p.parseBound()
+	if err != nil {
+		return nil, err
+	}
+	if !p.matchKeyword("and") {
+		return nil, p.errorf("expected AND in BETWEEN, got %s", p.describeCurrent())
+	}
+	high, err := p.parseBound()
+	if err != nil {
+		return nil, err
+	}
+	left, err := p.condition(field, ast.OpGe, low)
+	if err != nil {
+		return nil, err
+	}
+	right, err := p.condition(field, ast.OpLe, high)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.BinaryNode{Op: ast.OpAnd, Left: left, Right: right}, nil
+}
+
+// parseBound parses a bound of BETWEEN, which cannot be NULL.
+func (p *sqlParser) parseBound() (ast.Literal, error) {
+	tok := p.current()
+	value, err := p.parseLiteral()
+	if err == nil && value.Kind == ast.KindNull {
+		return ast.Literal{}, newError(CodeUnsupportedSQL, tok.pos, "BETWEEN with NULL is never true: use IS [NOT] NULL")
+	}
+	return value, err
+}
+
+// nullComparison reports a comparison with NULL, which is never true in SQL while the OData
+// "eq null" and "ne null" test for null. Only IS [NOT] NULL is converted.
+func nullComparison(opTok sqlToken) error {
+	return newError(CodeUnsupportedSQL, opTok.pos, "comparison with NULL using %s is never true: use IS [NOT] NULL", opTok.val)
+}
+
+func (p *sqlParser) condition(field, op string, value ast.Literal) (ast.Node, error) {
+	if err := p.countNode(); err != nil {
+		return nil, err
+	}
+	return &ast.ConditionNode{Field: field, Op: op, Value: value}, nil
+}
+
+// parseColumn parses a possibly qualified column and maps it to a property.
+func (p *sqlParser) parseColumn() (string, error) {
+	start := p.current()
+	if !p.check(sqlIdentifier) || p.checkLiteralKeyword() {
+		return "", p.errorf("expected column, got %s", p.describeCurrent())
+	}
+	var parts []string
+	for {
+		tok := p.current()
+		if !p.match(sqlIdentifier) {
+			return "", p.errorf("expected identifier after '.', got %s", p.describeCurrent())
+		}
+		parts = append(parts, tok.val)
+		if !p.match(sqlDot) {
+			break
+		}
+	}
+	if p.check(sqlParenOpen) {
+		return "", p.errorf("function calls are not supported")
+	}
+
+	column := strings.Join(parts, ".")
+	field := strings.Join(parts, "/")
+	if p.property == nil && len(parts) > 1 {
+		// The qualifier may be a table alias as well as a navigation path: only a schema can tell.
+		return "", newError(CodeUnsupportedSQL, start.pos, "qualified column %q requires a schema to map it to a property", column)
+	}
+	if p.property != nil {
+		var err error
+		if field, err = p.property(column); err != nil {
+			return "", newError(CodeUnsupportedSQL, start.pos, "column %q: %s", column, err)
+		}
+	}
+	if !fieldRegex.MatchString(field) {
+		return "", newError(CodeUnsupportedSQL, start.pos, "column %q is not a valid property name", column)
+	}
+	return field, nil
+}
+
+func (p *sqlParser) parseLiteral() (ast.Literal, error) {
+	tok := p.current()
+	switch {
+	case p.match(sqlString):
+		return ast.Literal{Kind: ast.KindString, Value: tok.val}, nil
+	case p.match(sqlNumber):
+		return ast.Literal{Kind: ast.KindNumber, Value: tok.val}, nil
+	case p.matchKeyword("true"), p.matchKeyword("false"):
+		return ast.Literal{Kind: ast.KindBoolean, Value: strings.ToLower(tok.val)}, nil
+	case p.matchKeyword("null"):
+		return ast.Literal{Kind: ast.KindNull, Value: "null"}, nil
+	}
+	return ast.Literal{}, p.errorf("expected literal, got %s", p.describeCurrent())
+}
+
+func (p *sqlParser) checkLiteralKeyword() bool {
+	return p.checkKeyword("true") || p.checkKeyword("false") || p.checkKeyword("null")
+}
+
+func (p *sqlParser) checkKeyword(keyword string) bool {
+	return p.check(sqlIdentifier) && !p.current().quoted && strings.EqualFold(p.current().val, keyword)
+}
+
+func (p *sqlParser) matchKeyword(keyword string) bool {
+	if p.checkKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) match(tt sqlTokenType) bool {
+	if p.check(tt) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) check(tt sqlTokenType) bool {
+	return !p.isAtEnd() && p.tokens[p.pos].typ == tt
+}
+
+func (p *sqlParser) current() sqlToken {
+	if p.isAtEnd() {
+		return sqlToken{pos: p.end}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) isAtEnd() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *sqlParser) position() int {
+	return p.current().pos
+}
+
+func (p *sqlParser) describeCurrent() string {
+	if p.isAtEnd() {
+		return "end of input"
+	}
+	return "\"" + p.current().val + "\""
+}
+
+func (p *sqlParser) errorf(format string, args ...any) *Error {
+	return newError(CodeUnsupportedSQL, p.position(), format, args...)
+}
+
+func (p *sqlParser) countNode() error {
+	p.nodes++
+	if exceeds(p.nodes, p.limits.MaxNodes) {
+		return newError(CodeMaxNodesExceeded, p.position(),
+			"condition exceeds the maximum of %d AST nodes", p.limits.MaxNodes)
+	}
+	return nil
+}
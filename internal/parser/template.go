@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// Template splits a filter into its shape and its slot literals, the string and number
+// literals in the order they appear. The shape is the filter's tokens with each slot literal
+// replaced by a placeholder, so filters differing only in those literals, or in whitespace,
+// share a shape. Errors are those of the tokenizer and validateValue, without the position
+// BuildAST reports.
+func Template(input string, limits Limits) (string, []ast.Literal, error) {
+	tokens, err := tokenize(input, limits.withDefaults())
+	if err != nil {
+		return "", nil, err
+	}
+
+	var shape strings.Builder
+	var literals []ast.Literal
+	for _, tok := range tokens {
+		shape.WriteByte(byte(tok.typ))
+		switch tok.typ {
+		case tString, tJSONString, tNumber:
+			value, err := validateValue(tok)
+			if err != nil {
+				return "", nil, err
+			}
+			literals = append(literals, value)
+		default:
+			shape.WriteString(tok.val)
+		}
+		shape.WriteByte(0)
+	}
+	return shape.String(), literals, nil
+}
@@ -1,8 +1,9 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
-	"strconv"
+	"regexp"
 	"strings"
 )
 
@@ -12,10 +13,14 @@ const (
 	tIdentifier tokenType = iota
 	tLiteral
 	tString
+	tJSONString
 	tNumber
 	tParenOpen
 	tParenClose
+	tBracketOpen
+	tBracketClose
 	tComma
+	tAlias
 	tOpIn
 	tOpNot
 	tOpAnd
@@ -29,16 +34,27 @@ const (
 )
 
 const (
-	parenOpen  = "("
-	parenClose = ")"
-	comma      = ","
+	parenOpen    = "("
+	parenClose   = ")"
+	bracketOpen  = "["
+	bracketClose = "]"
+	comma        = ","
 )
 
 type token struct {
 	typ tokenType
 	val string
+	pos int // byte offset of the token in the input
 }
 
+func (t token) String() string {
+	return fmt.Sprintf("%q", t.val)
+}
+
+// numberRegex matches OData integer and decimal literals. Other spellings accepted by
+// strconv.ParseFloat, such as "inf", "NaN" or hex floats, are not numbers in a filter.
+var numberRegex = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
 var keywordTokens = map[string]tokenType{
 	"in":  tOpIn,
 	"not": tOpNot,
@@ -52,9 +68,15 @@ var keywordTokens = map[string]tokenType{
 	"le":  tOpLe,
 }
 
-func tokenize(input string) ([]token, error) {
+// tokenize splits the input into tokens, enforcing the filter and literal length limits.
+func tokenize(input string, limits Limits) ([]token, error) {
+	if exceeds(len(input), limits.MaxFilterLength) {
+		return nil, newError(CodeFilterTooLong, limits.MaxFilterLength,
+			"filter length %d exceeds the maximum of %d bytes", len(input), limits.MaxFilterLength)
+	}
+
 	var tokens []token
-	s := strings.TrimSpace(input)
+	s := input
 	i := 0
 	for i < len(s) {
 		ch := s[i]
@@ -64,27 +86,57 @@ func tokenize(input string) ([]token, error) {
 		}
 		switch ch {
 		case '(':
-			tokens = append(tokens, token{tParenOpen, parenOpen})
+			tokens = append(tokens, token{tParenOpen, parenOpen, i})
 			i++
 		case ')':
-			tokens = append(tokens, token{tParenClose, parenClose})
+			tokens = append(tokens, token{tParenClose, parenClose, i})
+			i++
+		case '[':
+			tokens = append(tokens, token{tBracketOpen, bracketOpen, i})
+			i++
+		case ']':
+			tokens = append(tokens, token{tBracketClose, bracketClose, i})
 			i++
 		case ',':
-			tokens = append(tokens, token{tComma, comma})
+			tokens = append(tokens, token{tComma, comma, i})
+			i++
+		case '@':
+			start := i
 			i++
-		case '\'':
-			str, consumed, err := readQuotedString(s[i:])
+			for i < len(s) && !isDelimiter(s[i]) {
+				i++
+			}
+			name := s[start+1 : i]
+			if !isValidAliasName(name) {
+				return nil, newError(CodeInvalidFilter, start, "invalid parameter alias: %q", s[start:i])
+			}
+			tokens = append(tokens, token{tAlias, name, start})
+		case '\'', '"':
+			read := readQuotedString
+			typ := tString
+			if ch == '"' {
+				read = readJSONString
+				typ = tJSONString
+			}
+			str, consumed, err := read(s[i:])
 			if err != nil {
-				return nil, err
+				return nil, newError(CodeInvalidFilter, i, "%s", err)
+			}
+			// The token value keeps its surrounding single quotes.
+			if n := len(str) - 2; exceeds(n, limits.MaxLiteralLength) {
+				return nil, newError(CodeLiteralTooLong, i,
+					"string literal length %d exceeds the maximum of %d bytes", n, limits.MaxLiteralLength)
 			}
-			tokens = append(tokens, token{tString, str})
+			tokens = append(tokens, token{typ, str, i})
 			i += consumed
 		default:
 			start := i
 			for i < len(s) && !isDelimiter(s[i]) {
 				i++
 			}
-			tokens = append(tokens, classifyWord(s[start:i]))
+			tok := classifyWord(s[start:i])
+			tok.pos = start
+			tokens = append(tokens, tok)
 		}
 	}
 	return tokens, nil
@@ -94,18 +146,18 @@ func classifyWord(w string) token {
 	lower := strings.ToLower(w)
 
 	if lower == "true" || lower == "false" || lower == "null" {
-		return token{tLiteral, lower}
+		return token{typ: tLiteral, val: lower}
 	}
 
 	if tokType, exists := keywordTokens[lower]; exists {
-		return token{tokType, lower}
+		return token{typ: tokType, val: lower}
 	}
 
-	if _, err := strconv.ParseFloat(w, 64); err == nil {
-		return token{tNumber, w}
+	if numberRegex.MatchString(w) {
+		return token{typ: tNumber, val: w}
 	}
 
-	return token{tIdentifier, w}
+	return token{typ: tIdentifier, val: w}
 }
 
 // isWhitespace checks if a character is a whitespace character.
@@ -113,9 +165,25 @@ func isWhitespace(ch byte) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
-// isDelimiter checks if a character is a delimiter (whitespace, parentheses, comma, or single quote).
+// isDelimiter checks if a character is a delimiter (whitespace, parentheses, brackets, comma, or quote).
 func isDelimiter(ch byte) bool {
-	return isWhitespace(ch) || ch == '(' || ch == ')' || ch == ',' || ch == '\''
+	return isWhitespace(ch) || ch == '(' || ch == ')' || ch == '[' || ch == ']' || ch == ',' || ch == '\'' || ch == '"'
+}
+
+// isValidAliasName checks that a parameter alias name (without the leading '@') is a simple identifier.
+func isValidAliasName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		isLetter := (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
+		isDigit := ch >= '0' && ch <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			return false
+		}
+	}
+	return true
 }
 
 // readQuotedString extracts a properly formatted quoted string.
@@ -149,3 +217,30 @@ func readQuotedString(input string) (string, int, error) {
 
 	return "", 0, fmt.Errorf("unclosed string literal: %q", input)
 }
+
+// readJSONString extracts a double-quoted JSON string, as used in OData 4.01 collection literals
+// like ["red","blue"]. JSON escapes are decoded, and the result is returned in the same
+// single-quoted form produced by readQuotedString so both notations yield identical values.
+func readJSONString(input string) (string, int, error) {
+	if len(input) < 2 || input[0] != '"' {
+		return "", 0, fmt.Errorf("invalid JSON string")
+	}
+
+	i := 1
+	for i < len(input) {
+		switch input[i] {
+		case '\\':
+			i += 2
+			continue
+		case '"':
+			var decoded string
+			if err := json.Unmarshal([]byte(input[:i+1]), &decoded); err != nil {
+				return "", 0, fmt.Errorf("invalid JSON string literal %s: %w", input[:i+1], err)
+			}
+			return "'" + decoded + "'", i + 1, nil
+		}
+		i++
+	}
+
+	return "", 0, fmt.Errorf("unclosed string literal: %q", input)
+}
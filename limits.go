@@ -0,0 +1,14 @@
+package odatasql
+
+import "github.com/maxlambrecht/odatasql/internal/parser"
+
+// Limits bounds the resources a single filter may consume while it is tokenized and parsed:
+// nesting depth, total AST nodes, IN list size, function arguments, string literal length and
+// filter length, as well as the size of the normal forms computed from it.
+// A zero field uses the default limit; a negative field disables that check.
+type Limits = parser.Limits
+
+// DefaultLimits returns the limits applied by FilterToSQL and ParseQueryOptions.
+func DefaultLimits() Limits {
+	return parser.DefaultLimits()
+}
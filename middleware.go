@@ -0,0 +1,147 @@
+package odatasql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ErrCodeBadRequest is the code written by WriteError for errors that are not an *Error, such
+// as a parameter alias given twice.
+const ErrCodeBadRequest = "BadRequest"
+
+// ErrCodeInternal is the code of the 500 Internal Server Error responses written by
+// Middleware when the server's own configuration fails, such as a misconfigured converter or
+// an invalid predicate added with ContextWithPredicates. The response carries no details of
+// the failure.
+const ErrCodeInternal = "InternalError"
+
+type queryOptionsKey struct{}
+
+// Middleware returns an http.Handler that parses the OData query options of each request with
+// the default converter. See Converter.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return defaultConverter.Middleware(next)
+}
+
+// Middleware returns an http.Handler that parses the OData query options of each request and
+// stores them in the request context, where next retrieves them with QueryOptionsFromContext.
+// Predicates added to the request context with ContextWithPredicates are appended to the
+// converter's, and the request context is passed to the converter's Authorizer. Requests with
+// invalid query options are rejected with 400 Bad Request, or 403 Forbidden when a field is
+// denied, and an OData JSON error body written by WriteError; next is not called. A converter
+// configuration error (see Converter.Err) and invalid context predicates are server errors,
+// reported as a generic 500 Internal Server Error.
+//
+// Example:
+//
+//	mux.Handle("/users", conv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	    opts, _ := odatasql.QueryOptionsFromContext(r.Context())
+//	    rows, err := conv.Select("users").Query(r.Context(), db, opts)
+//	    ...
+//	})))
+func (c *Converter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.err != nil {
+			writeInternalError(w)
+			return
+		}
+		opts, err := c.ParseQueryOptionsContext(r.Context(), r.URL.Query())
+		if err != nil {
+			WriteError(w, errorStatus(err), err)
+			return
+		}
+		if extra := PredicatesFromContext(r.Context()); len(extra) > 0 {
+			resolved, err := c.resolvePredicates(extra)
+			if err != nil {
+				writeInternalError(w)
+				return
+			}
+			opts.Predicates = append(opts.Predicates, resolved...)
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithQueryOptions(r.Context(), opts)))
+	})
+}
+
+// errorStatus returns the HTTP status code reporting a query option error.
+func errorStatus(err error) int {
+	var filterErr *Error
+	if errors.As(err, &filterErr) && filterErr.Code == ErrCodeFieldAccessDenied {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// ContextWithQueryOptions returns a copy of ctx carrying opts.
+func ContextWithQueryOptions(ctx context.Context, opts *QueryOptions) context.Context {
+	return context.WithValue(ctx, queryOptionsKey{}, opts)
+}
+
+// QueryOptionsFromContext returns the query options stored by Middleware, and whether there
+// were any.
+func QueryOptionsFromContext(ctx context.Context) (*QueryOptions, bool) {
+	opts, ok := ctx.Value(queryOptionsKey{}).(*QueryOptions)
+	return opts, ok && opts != nil
+}
+
+// errorResponse is the body of an OData JSON error response.
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Target  string        `json:"target,omitempty"`
+	Details []errorDetail `json:"details,omitempty"`
+}
+
+type errorDetail struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Target   string `json:"target,omitempty"`
+	Position int    `json:"@odatasql.position"`
+}
+
+// WriteError writes err as an OData JSON error response with the given status code.
+//
+// When err wraps an *Error, the response uses its code, targets $filter and has one detail
+// entry with the message and the position of the problem, in the "@odatasql.position"
+// annotation:
+//
+//	{"error": {"code": "InvalidFilter", "message": "invalid OData filter ...", "target": "$filter",
+//	    "details": [{"code": "InvalidFilter", "message": "...", "target": "$filter", "@odatasql.position": 4}]}}
+//
+// Other errors are reported with the code ErrCodeBadRequest and no details.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	body := errorBody{Code: ErrCodeBadRequest, Message: err.Error()}
+
+	var filterErr *Error
+	if errors.As(err, &filterErr) {
+		body.Code = filterErr.Code
+		body.Target = queryFilter
+		body.Details = []errorDetail{{
+			Code:     filterErr.Code,
+			Message:  filterErr.Message,
+			Target:   queryFilter,
+			Position: filterErr.Position,
+		}}
+	}
+
+	writeErrorBody(w, status, body)
+}
+
+// writeInternalError writes a 500 Internal Server Error response without details of the
+// failure, which concerns the server rather than the request.
+func writeInternalError(w http.ResponseWriter) {
+	writeErrorBody(w, http.StatusInternalServerError, errorBody{Code: ErrCodeInternal, Message: "internal server error"})
+}
+
+// writeErrorBody writes an OData JSON error response.
+func writeErrorBody(w http.ResponseWriter, status int, body errorBody) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("OData-Version", "4.0")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: body})
+}
@@ -0,0 +1,325 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// FilterToMongo converts an OData filter into a MongoDB query document with the default
+// converter. See Converter.FilterToMongo.
+func FilterToMongo(filter string) (map[string]any, error) {
+	return defaultConverter.FilterToMongo(filter)
+}
+
+// FilterToMongo converts an OData filter into a MongoDB query document that can be passed to
+// Collection.Find as is, without depending on the MongoDB driver. An empty filter returns an
+// empty document, which matches every document.
+//
+// The document is built from maps with string keys and slices of type []any:
+//   - "and" and "or" become $and and $or, "not" becomes $nor with a single element;
+//   - comparisons become $eq, $ne, $gt, $gte, $lt and $lte, and "in" becomes $in;
+//   - contains, startswith and endswith become $regex with the argument escaped;
+//   - any and all become $elemMatch, all being expressed as "no element fails the predicate"
+//     with $nor. Predicates on primitive collections ("tags/any(t: t eq 'red')") must be
+//     comparisons of the element, possibly negated or combined with "and".
+//
+// Fields are named after the schema's columns, or after their property paths with '/'
+// replaced by '.' ("address/city" becomes "address.city"): naming strategies, which map
+// properties to SQL columns, do not apply. Numbers become int64 or float64, and string
+// literals compared with Edm.Date or Edm.DateTimeOffset properties become time.Time values.
+//
+// Comparisons follow MongoDB semantics rather than SQL's: "eq null" also matches documents
+// without the field, and "not" matches documents for which its operand is not true.
+//
+// Example:
+//
+//	doc, err := conv.FilterToMongo("age ge 18 and tags/any(t: t eq 'vip')")
+//	// map[$and:[map[age:map[$gte:18]] map[tags:map[$elemMatch:map[$eq:vip]]]]]
+//	cursor, err := collection.Find(ctx, doc)
+func (c *Converter) FilterToMongo(filter string) (map[string]any, error) {
+	return c.FilterToMongoContext(context.Background(), filter)
+}
+
+// FilterToMongoContext is like FilterToMongo, passing ctx to the converter's Authorizer.
+func (c *Converter) FilterToMongoContext(ctx context.Context, filter string) (map[string]any, error) {
+	if strings.TrimSpace(filter) == "" {
+		return map[string]any{}, nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := mongoWriter{c}.document(node, "")
+	if err != nil {
+		return nil, fmt.Errorf("converting OData filter %q to MongoDB: %w", filter, err)
+	}
+	return doc, nil
+}
+
+// mongoOperators maps OData comparison operators to MongoDB query operators.
+var mongoOperators = map[string]string{
+	ast.OpEq: "$eq",
+	ast.OpNe: "$ne",
+	ast.OpGt: "$gt",
+	ast.OpGe: "$gte",
+	ast.OpLt: "$lt",
+	ast.OpLe: "$lte",
+}
+
+// mongoWriter renders an AST as a MongoDB query document.
+type mongoWriter struct {
+	c *Converter
+}
+
+// document renders node with fields named relative to the collection at path scope, "" at the
+// root. Conditions on the elements of a primitive collection are keyed by "".
+func (w mongoWriter) document(node ast.Node, scope string) (map[string]any, error) {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		op := "$and"
+		if n.Op == ast.OpOr {
+			op = "$or"
+		}
+		var operands []any
+		for _, operand := range flatten(n, n.Op) {
+			doc, err := w.document(operand, scope)
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, doc)
+		}
+		return map[string]any{op: operands}, nil
+	case *ast.NotNode:
+		child, err := w.document(n.Child, scope)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"$nor": []any{child}}, nil
+	case *ast.ParenNode:
+		return w.document(n.Child, scope)
+	case *ast.ConditionNode:
+		return w.condition(n.Field, scope, mongoOperators[n.Op], w.value(n.Field, n.Value))
+	case *ast.InNode:
+		values := make([]any, len(n.Values))
+		for i, v := range n.Values {
+			values[i] = w.value(n.Field, v)
+		}
+		return w.condition(n.Field, scope, "$in", values)
+	case *ast.FunctionNode:
+		pattern := regexp.QuoteMeta(n.Value.Value)
+		switch n.Name {
+		case ast.FuncStartsWith:
+			pattern = "^" + pattern
+		case ast.FuncEndsWith:
+			pattern += "$"
+		}
+		return w.condition(n.Field, scope, "$regex", pattern)
+	case *ast.LambdaNode:
+		return w.lambda(n, scope)
+	}
+	return nil, fmt.Errorf("unsupported expression %T", node)
+}
+
+// condition returns the document applying a query operator to a field.
+func (w mongoWriter) condition(field, scope, op string, value any) (map[string]any, error) {
+	name, err := w.field(field, scope)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{name: map[string]any{op: value}}, nil
+}
+
+// lambda renders an any or all operator with $elemMatch.
+func (w mongoWriter) lambda(n *ast.LambdaNode, scope string) (map[string]any, error) {
+	name, err := w.field(n.Field, scope)
+	if err != nil {
+		return nil, err
+	}
+	if n.Predicate == nil {
+		return map[string]any{name + ".0": map[string]any{"$exists": true}}, nil
+	}
+
+	predicate, err := w.document(n.Predicate, n.Field)
+	if err != nil {
+		return nil, err
+	}
+	operators, primitive := elementOperators(predicate)
+	if primitive && n.Op == ast.LambdaAll {
+		operators, primitive = negateOperators(operators)
+	}
+	if !primitive && hasElementKey(predicate) {
+		return nil, fmt.Errorf("%s: the predicate on the elements of %q must be a comparison or an \"and\" of comparisons", n.Op, n.Field)
+	}
+
+	match := operators
+	switch {
+	case primitive:
+	case n.Op == ast.LambdaAny:
+		match = predicate
+	default:
+		match = map[string]any{"$nor": []any{predicate}}
+	}
+
+	doc := map[string]any{name: map[string]any{"$elemMatch": match}}
+	if n.Op == ast.LambdaAll {
+		// Every element matches when no element fails the predicate.
+		doc = map[string]any{"$nor": []any{doc}}
+	}
+	return doc, nil
+}
+
+// elementOperators returns the query operators of a predicate on the elements of a primitive
+// collection: a single condition keyed by "", its negation, or an $and of such conditions
+// whose operators do not repeat.
+func elementOperators(doc map[string]any) (map[string]any, bool) {
+	if len(doc) != 1 {
+		return nil, false
+	}
+	if operators, ok := doc[""].(map[string]any); ok {
+		return operators, true
+	}
+	if operands, ok := doc["$nor"].([]any); ok && len(operands) == 1 {
+		operators, ok := elementOperators(operands[0].(map[string]any))
+		if !ok {
+			return nil, false
+		}
+		return negateOperators(operators)
+	}
+	operands, ok := doc["$and"].([]any)
+	if !ok {
+		return nil, false
+	}
+	merged := map[string]any{}
+	for _, operand := range operands {
+		operators, ok := elementOperators(operand.(map[string]any))
+		if !ok {
+			return nil, false
+		}
+		for op, value := range operators {
+			if _, repeated := merged[op]; repeated {
+				return nil, false
+			}
+			merged[op] = value
+		}
+	}
+	return merged, true
+}
+
+// negateOperators negates query operators with $not, which cannot be nested.
+func negateOperators(operators map[string]any) (map[string]any, bool) {
+	negated, ok := operators["$not"].(map[string]any)
+	switch {
+	case ok && len(operators) == 1:
+		return negated, true
+	case ok:
+		return nil, false
+	}
+	return map[string]any{"$not": operators}, true
+}
+
+// hasElementKey reports whether doc contains a condition keyed by "" at any depth.
+func hasElementKey(doc map[string]any) bool {
+	for key, value := range doc {
+		if key == "" {
+			return true
+		}
+		if operands, ok := value.([]any); ok && strings.HasPrefix(key, "$") {
+			for _, operand := range operands {
+				if sub, ok := operand.(map[string]any); ok && hasElementKey(sub) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// field returns the document field of a property, relative to the collection at path scope.
+func (w mongoWriter) field(field, scope string) (string, error) {
+	name := documentField(w.c.schema, field)
+	if scope == "" {
+		return name, nil
+	}
+	if field == scope {
+		return "", nil
+	}
+	parent := documentField(w.c.schema, scope)
+	rest, ok := strings.CutPrefix(name, parent+".")
+	if !ok {
+		return "", fmt.Errorf("field %q of property %q is not inside field %q of collection %q", name, field, parent, scope)
+	}
+	return rest, nil
+}
+
+// value converts a literal compared with a property into a MongoDB value.
+func (w mongoWriter) value(field string, v ast.Literal) any {
+	if t := propertyType(w.c.schema, field); v.Kind == ast.KindString && (t == EdmDate || t == EdmDateTimeOffset) {
+		if tm, err := parseTime(v); err == nil {
+			return tm
+		}
+	}
+	return literalValue(v)
+}
+
+// literalValue converts a literal into a Go value: a bool, nil, an int64 or float64 number,
+// or a string.
+func literalValue(v ast.Literal) any {
+	switch v.Kind {
+	case ast.KindBoolean:
+		return v.Value == "true"
+	case ast.KindNull:
+		return nil
+	case ast.KindNumber:
+		if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			return n
+		}
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+	}
+	return v.Value
+}
+
+// documentField returns the field of a property in a document store: its schema column, or its
+// property path with '/' replaced by '.'.
+func documentField(schema *Schema, field string) string {
+	if schema != nil {
+		if p, ok := schema.Property(field); ok && p.Column != "" {
+			return p.Column
+		} else if ok && p.ColumnPrefix != "" {
+			return p.ColumnPrefix + field[strings.LastIndex(field, "/")+1:]
+		}
+	}
+	return strings.ReplaceAll(field, "/", ".")
+}
+
+// propertyType returns the EDM type of a property, or "" when it is unknown.
+func propertyType(schema *Schema, field string) EdmType {
+	if schema == nil {
+		return ""
+	}
+	p, _ := schema.Property(field)
+	return p.Type
+}
+
+// flatten returns the operands of a chain of binary nodes with the same operator, looking
+// through explicit parentheses: "a and (b and c)" has the operands a, b and c.
+func flatten(node ast.Node, op string) []ast.Node {
+	switch n := node.(type) {
+	case *ast.BinaryNode:
+		if n.Op == op {
+			return append(flatten(n.Left, op), flatten(n.Right, op)...)
+		}
+	case *ast.ParenNode:
+		if inner := flatten(n.Child, op); len(inner) > 1 {
+			return inner
+		}
+	}
+	return []ast.Node{node}
+}
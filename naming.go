@@ -0,0 +1,76 @@
+package odatasql
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy maps OData property names to SQL column names.
+// Implementations must be safe for concurrent use.
+type NamingStrategy interface {
+	ColumnName(property string) string
+}
+
+// NamingFunc adapts an ordinary function to a NamingStrategy.
+type NamingFunc func(property string) string
+
+// ColumnName calls f(property).
+func (f NamingFunc) ColumnName(property string) string {
+	return f(property)
+}
+
+// Built-in naming strategies.
+var (
+	// SnakeCase converts property names to snake_case, keeping acronyms together:
+	// "firstName" becomes "first_name" and "HTTPStatus" becomes "http_status".
+	// It is the default naming strategy.
+	SnakeCase NamingStrategy = NamingFunc(func(property string) string {
+		return joinWords(property, '_')
+	})
+	// SimpleSnakeCase inserts an underscore only between a lowercase letter or digit and an
+	// uppercase letter: "firstName" becomes "first_name" but "HTTPStatus" becomes "httpstatus".
+	// It matches the naming used before naming strategies were configurable.
+	SimpleSnakeCase NamingStrategy = NamingFunc(func(property string) string {
+		return strings.ToLower(camelToSnakeRegex.ReplaceAllString(property, "${1}_${2}"))
+	})
+	// KebabCase converts property names to kebab-case: "HTTPStatus" becomes "http-status".
+	// Such columns must be quoted, so converters using it with a dialect that does not quote
+	// identifiers, like DialectGeneric, report a configuration error.
+	KebabCase NamingStrategy = kebabCase{}
+	// LowerCase lowercases property names: "firstName" becomes "firstname".
+	LowerCase NamingStrategy = NamingFunc(strings.ToLower)
+	// Identity uses property names unchanged, for camelCase or PascalCase columns.
+	Identity NamingStrategy = NamingFunc(func(property string) string {
+		return property
+	})
+)
+
+// kebabCase is a distinct type, rather than a NamingFunc, so that converters can recognize it.
+type kebabCase struct{}
+
+func (kebabCase) ColumnName(property string) string {
+	return joinWords(property, '-')
+}
+
+var camelToSnakeRegex = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// joinWords lowercases s and separates its words with sep. A new word starts at an uppercase
+// letter preceded by a lowercase letter or digit ("userId"), or at the last uppercase letter
+// of an acronym followed by a lowercase letter ("HTTPStatus"). Existing underscores and the
+// '/' of navigation paths are kept.
+func joinWords(s string, sep rune) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				sb.WriteRune(sep)
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
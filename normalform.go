@@ -0,0 +1,101 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// FilterToDNF converts an OData filter into disjunctive normal form with the default
+// converter. See Converter.FilterToDNF.
+func FilterToDNF(filter string) (string, error) {
+	return defaultConverter.FilterToDNF(filter)
+}
+
+// FilterToCNF converts an OData filter into conjunctive normal form with the default
+// converter. See Converter.FilterToCNF.
+func FilterToCNF(filter string) (string, error) {
+	return defaultConverter.FilterToCNF(filter)
+}
+
+// FilterToDNF parses an OData filter with the converter's configuration and writes it back in
+// disjunctive normal form: an "or" of clauses, each an "and" of conditions, string functions,
+// "in" lists, lambda operators or their negations. The filter is first simplified as by
+// SimplifyFilter, then "and" is distributed over "or"; clauses absorbed by a smaller clause
+// are removed.
+//
+// Distribution may multiply the size of a filter, so filters whose normal form has more
+// clauses than the converter's Limits.MaxClauses are rejected with an *Error with code
+// ErrCodeMaxClausesExceeded.
+//
+// Example:
+//
+//	s, err := odatasql.FilterToDNF("a eq 1 and (b eq 2 or c eq 3)")
+//	// s == "a eq 1 and b eq 2 or a eq 1 and c eq 3"
+func (c *Converter) FilterToDNF(filter string) (string, error) {
+	return c.FilterToDNFContext(context.Background(), filter)
+}
+
+// FilterToDNFContext is like FilterToDNF, passing ctx to the converter's Authorizer.
+func (c *Converter) FilterToDNFContext(ctx context.Context, filter string) (string, error) {
+	return c.normalForm(ctx, filter, ast.OpOr)
+}
+
+// FilterToCNF is like FilterToDNF, writing the filter in conjunctive normal form: an "and" of
+// clauses, each an "or" of conditions, string functions, "in" lists, lambda operators or their
+// negations.
+//
+// Example:
+//
+//	s, err := odatasql.FilterToCNF("a eq 1 or b eq 2 and c eq 3")
+//	// s == "(a eq 1 or b eq 2) and (a eq 1 or c eq 3)"
+func (c *Converter) FilterToCNF(filter string) (string, error) {
+	return c.FilterToCNFContext(context.Background(), filter)
+}
+
+// FilterToCNFContext is like FilterToCNF, passing ctx to the converter's Authorizer.
+func (c *Converter) FilterToCNFContext(ctx context.Context, filter string) (string, error) {
+	return c.normalForm(ctx, filter, ast.OpAnd)
+}
+
+func (c *Converter) normalForm(ctx context.Context, filter, outer string) (string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return "", err
+	}
+
+	normalize, form := ast.DNF, "DNF"
+	if outer == ast.OpAnd {
+		normalize, form = ast.CNF, "CNF"
+	}
+	clauses, ok := normalize(c.simplify(node), c.maxClauses())
+	if !ok {
+		return "", fmt.Errorf("converting OData filter %q to %s: %w", filter, form, c.tooManyClauses())
+	}
+	return ast.Format(ast.JoinClauses(clauses, outer)), nil
+}
+
+// maxClauses returns the converter's Limits.MaxClauses, or 0 when the limit is disabled.
+func (c *Converter) maxClauses() int {
+	switch {
+	case c.limits.MaxClauses == 0:
+		return DefaultLimits().MaxClauses
+	case c.limits.MaxClauses < 0:
+		return 0
+	}
+	return c.limits.MaxClauses
+}
+
+// tooManyClauses returns the error reported when a normal form exceeds Limits.MaxClauses.
+func (c *Converter) tooManyClauses() *Error {
+	return &Error{
+		Code:    ErrCodeMaxClausesExceeded,
+		Message: fmt.Sprintf("normal form exceeds the maximum of %d clauses", c.maxClauses()),
+	}
+}
@@ -1,14 +1,8 @@
 package odatasql
 
-import (
-	"fmt"
-	"strings"
-
-	"github.com/maxlambrecht/odatasql/internal/parser"
-)
-
 // FilterToSQL transforms an OData filter string into a SQL WHERE clause.
 // It maintains explicit parentheses and ensures correct operator precedence.
+// It uses a Converter with the default configuration; see NewConverter to customize it.
 //
 // Example:
 //
@@ -19,15 +13,12 @@ import (
 //   - A SQL WHERE clause as a string.
 //   - An error if the input is invalid.
 func FilterToSQL(filter string) (string, error) {
-	filter = strings.TrimSpace(filter)
-	if filter == "" {
-		return "", nil
-	}
-
-	ast, err := parser.BuildAST(filter)
-	if err != nil {
-		return "", fmt.Errorf("invalid OData filter %q: %w", filter, err)
-	}
+	return defaultConverter.FilterToSQL(filter)
+}
 
-	return ast.ToSQL(0), nil
+// FilterToSQLWithLimits is like FilterToSQL but enforces the given resource limits.
+// Filters exceeding a limit are rejected with an *Error whose Code identifies the limit.
+// It is equivalent to NewConverter(WithLimits(limits)).FilterToSQL(filter).
+func FilterToSQLWithLimits(filter string, limits Limits) (string, error) {
+	return NewConverter(WithLimits(limits)).FilterToSQL(filter)
 }
@@ -0,0 +1,221 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// FieldValues is the set of values an OData filter constrains a field to. The zero value is
+// unbounded. See Converter.ConstrainedValues.
+type FieldValues struct {
+	// Bounded reports whether every row matching the filter holds one of Values in the field.
+	Bounded bool
+	// Values lists the distinct values, in order of first use, as a bool, nil, an int64 or
+	// float64 number, or a string.
+	Values []any
+}
+
+// ConstrainedValues returns the set of values an OData filter constrains each field to with
+// the default converter. See Converter.ConstrainedValues.
+func ConstrainedValues(filter string) (map[string]FieldValues, error) {
+	return defaultConverter.ConstrainedValues(filter)
+}
+
+// ConstrainedValues returns, for each field the filter compares, the set of values rows
+// matching the filter can hold in the field, so that queries can be routed to the shards,
+// partitions or database connections holding those values. For example, "region eq 'eu' and
+// (tenantId in (1, 2) or tenantId eq 3)" bounds region to "eu" and tenantId to 1, 2 and 3,
+// while "region eq 'eu' or age gt 18" leaves both fields unbounded.
+//
+// Values are computed from the filter's disjunctive normal form, the same way as for
+// Contradiction: "and" intersects and "or" unites the values of a field, "not" is pushed down
+// to the comparisons, and ranges of integer properties with fewer values than the converter's
+// Limits.MaxInValues are enumerated. Null is one of the values only when the filter can match
+// rows where the field is null, like "region eq null" with NullAsIsNull. Fields missing from
+// the result are unbounded, as are all fields of an empty filter or of a filter proven to match
+// no row. Filters whose normal form has more clauses than Limits.MaxClauses are rejected with
+// an *Error with code ErrCodeMaxClausesExceeded.
+//
+// Example:
+//
+//	values, err := odatasql.ConstrainedValues("region eq 'eu' and tenantId in (1, 2)")
+//	// values["region"] == FieldValues{Bounded: true, Values: []any{"eu"}}
+//	// values["tenantId"] == FieldValues{Bounded: true, Values: []any{int64(1), int64(2)}}
+func (c *Converter) ConstrainedValues(filter string) (map[string]FieldValues, error) {
+	return c.ConstrainedValuesContext(context.Background(), filter)
+}
+
+// ConstrainedValuesContext is like ConstrainedValues, passing ctx to the converter's
+// Authorizer.
+func (c *Converter) ConstrainedValuesContext(ctx context.Context, filter string) (map[string]FieldValues, error) {
+	result := make(map[string]FieldValues)
+	if strings.TrimSpace(filter) == "" {
+		return result, nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	r := reasoner{c: c, max: c.maxClauses()}
+	clauses, ok := r.clauses(node, isTrue)
+	if !ok {
+		return nil, fmt.Errorf("reasoning about OData filter %q: %w", filter, c.tooManyClauses())
+	}
+
+	var fields []string
+	for _, cl := range clauses {
+		for _, con := range cl {
+			if _, seen := result[con.field]; con.predicate == "" && !seen {
+				result[con.field] = FieldValues{}
+				fields = append(fields, con.field)
+			}
+		}
+	}
+	for _, field := range fields {
+		result[field] = r.fieldValues(clauses, field)
+	}
+	return result, nil
+}
+
+// fieldValues unites the values each clause allows for a field, which is unbounded when a
+// clause does not constrain the field to finitely many values.
+func (r reasoner) fieldValues(clauses []clause, field string) FieldValues {
+	fv := FieldValues{Bounded: true}
+	var seen []*value
+	null := false
+	for _, cl := range clauses {
+		values, ok := r.fieldSet(cl, field)
+		if !ok {
+			return FieldValues{}
+		}
+		points, ok := r.points(values, field)
+		if !ok {
+			return FieldValues{}
+		}
+	next:
+		for _, p := range points {
+			for _, s := range seen {
+				if cmp, ok := p.compare(s); ok && cmp == 0 {
+					continue next
+				}
+			}
+			seen = append(seen, p)
+			fv.Values = append(fv.Values, literalValue(p.literal))
+		}
+		if values.null && !null {
+			null = true
+			fv.Values = append(fv.Values, nil)
+		}
+	}
+	return fv
+}
+
+// fieldSet returns the values a clause allows for a field, or false if the clause does not
+// constrain the field or its values are not comparable.
+func (r reasoner) fieldSet(cl clause, field string) (valueSet, bool) {
+	var values valueSet
+	constrained := false
+	for _, c := range cl {
+		if c.predicate != "" || c.field != field {
+			continue
+		}
+		if !constrained {
+			values, constrained = c.values, true
+			continue
+		}
+		intersection, ok := values.intersect(c.values)
+		if !ok {
+			return valueSet{}, false
+		}
+		values = intersection
+	}
+	return values, constrained
+}
+
+// points returns the non-null values of a set, or false if there are too many to list.
+func (r reasoner) points(values valueSet, field string) ([]*value, bool) {
+	integer := r.integer(field)
+	max := r.c.limits.MaxInValues
+	if max <= 0 {
+		max = DefaultLimits().MaxInValues
+	}
+
+	var points []*value
+	for _, i := range values.intervals {
+		if i.empty(integer) {
+			continue
+		}
+		if i.lo.value != nil && i.hi.value != nil {
+			if cmp, ok := i.lo.value.compare(i.hi.value); ok && cmp == 0 {
+				points = append(points, i.lo.value)
+				continue
+			}
+		}
+
+		discrete, ok := i.discrete(integer, max)
+		if !ok || len(points)+len(discrete) > max {
+			return nil, false
+		}
+		points = append(points, discrete...)
+	}
+	return points, len(points) <= max
+}
+
+// discrete returns the integers or booleans in an interval, or false if the interval holds
+// other values or more than max integers.
+func (i interval) discrete(integer bool, max int) ([]*value, bool) {
+	boolean := false
+	for _, b := range []bound{i.lo, i.hi} {
+		switch {
+		case b.value == nil:
+		case b.value.kind == valueBoolean:
+			boolean = true
+		case b.value.kind != valueNumber || !integer:
+			return nil, false
+		}
+	}
+	if boolean {
+		var values []*value
+		for _, lit := range []ast.Literal{{Kind: ast.KindBoolean, Value: "false"}, {Kind: ast.KindBoolean, Value: "true"}} {
+			v := &value{kind: valueBoolean, number: big.NewRat(0, 1), literal: lit}
+			if lit.Value == "true" {
+				v.number = big.NewRat(1, 1)
+			}
+			if i.contains(v) {
+				values = append(values, v)
+			}
+		}
+		return values, true
+	}
+
+	lo, hi := integerBound(i.lo, 1), integerBound(i.hi, -1)
+	if lo == nil || hi == nil || new(big.Int).Sub(hi, lo).Cmp(big.NewInt(int64(max))) >= 0 {
+		return nil, false
+	}
+	var values []*value
+	for n := lo; n.Cmp(hi) <= 0; n = new(big.Int).Add(n, big.NewInt(1)) {
+		lit := ast.Literal{Kind: ast.KindNumber, Value: n.String()}
+		values = append(values, &value{kind: valueNumber, number: new(big.Rat).SetInt(n), literal: lit})
+	}
+	return values, true
+}
+
+// contains reports whether a value lies in an interval.
+func (i interval) contains(v *value) bool {
+	if i.lo.value != nil {
+		if cmp, ok := v.compare(i.lo.value); !ok || cmp < 0 || cmp == 0 && !i.lo.inclusive {
+			return false
+		}
+	}
+	if i.hi.value != nil {
+		if cmp, ok := v.compare(i.hi.value); !ok || cmp > 0 || cmp == 0 && !i.hi.inclusive {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/parser"
+)
+
+// Predicate is a server-side condition that every query must satisfy regardless of the
+// client's filter, such as a tenant or soft-delete check. Predicates are AND-ed with the
+// client filter, which is parenthesized so that its OR clauses cannot escape them.
+type Predicate struct {
+	// Filter is the OData expression the predicate was built from, if any.
+	Filter string
+	// SQL is the SQL condition. Its "?" placeholders are bound to Args in order and rewritten
+	// to the dialect's placeholders when the statement is composed.
+	SQL  string
+	Args []any
+}
+
+// SQLPredicate returns a predicate from a trusted SQL fragment with "?" placeholders.
+//
+// Example:
+//
+//	odatasql.SQLPredicate("tenant_id = ?", tenantID)
+func SQLPredicate(sql string, args ...any) Predicate {
+	return Predicate{SQL: sql, Args: args}
+}
+
+// FilterPredicate returns a predicate from a trusted OData expression, converted with the
+// converter's dialect, naming strategy and schema columns. The schema's filterability rules do
+// not apply, so predicates may use properties clients cannot filter on.
+//
+// Example:
+//
+//	odatasql.FilterPredicate("deletedAt eq null")
+func FilterPredicate(filter string) Predicate {
+	return Predicate{Filter: filter}
+}
+
+// WithPredicates adds mandatory predicates to every query options parsed by the converter.
+// They apply to ParseQueryOptions, Middleware and Select, but not to FilterToSQL, which only
+// converts the filter it is given. The predicates are converted once by NewConverter, which
+// reports an invalid one from Err.
+func WithPredicates(predicates ...Predicate) Option {
+	return func(c *Converter) {
+		c.predicates = append(c.predicates[:len(c.predicates):len(c.predicates)], predicates...)
+	}
+}
+
+type predicatesKey struct{}
+
+// ContextWithPredicates returns a copy of ctx carrying additional mandatory predicates for the
+// request, for instance set by an authentication middleware. Converter.Middleware adds them
+// to the query options it stores in the context.
+func ContextWithPredicates(ctx context.Context, predicates ...Predicate) context.Context {
+	existing := PredicatesFromContext(ctx)
+	return context.WithValue(ctx, predicatesKey{}, append(existing[:len(existing):len(existing)], predicates...))
+}
+
+// PredicatesFromContext returns the predicates added by ContextWithPredicates.
+func PredicatesFromContext(ctx context.Context) []Predicate {
+	predicates, _ := ctx.Value(predicatesKey{}).([]Predicate)
+	return predicates
+}
+
+// resolvePredicates converts the OData expressions of predicates into SQL.
+func (c *Converter) resolvePredicates(predicates []Predicate) ([]Predicate, error) {
+	resolved := make([]Predicate, len(predicates))
+	for i, p := range predicates {
+		if p.SQL == "" && p.Filter != "" {
+			node, err := parser.BuildAST(p.Filter, parser.Options{Limits: c.limits})
+			if err != nil {
+				return nil, fmt.Errorf("invalid mandatory predicate %q: %w", p.Filter, err)
+			}
+			p.SQL = c.render(node)
+		}
+		if strings.TrimSpace(p.SQL) == "" {
+			return nil, fmt.Errorf("mandatory predicate %d is empty", i)
+		}
+		resolved[i] = p
+	}
+	return resolved, nil
+}
+
+// where combines predicates and a filter into one condition, binding the predicates'
+// placeholders after offset previous arguments. Conditions are parenthesized when there is
+// more than one.
+func where(d Dialect, predicates []Predicate, filter string, offset int) (string, []any) {
+	var conditions []string
+	var args []any
+	for _, p := range predicates {
+		conditions = append(conditions, bindPlaceholders(d, p.SQL, offset+len(args)))
+		args = append(args, p.Args...)
+	}
+	if filter != "" {
+		conditions = append(conditions, filter)
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0], args
+	}
+	if len(conditions) > 1 {
+		return "(" + strings.Join(conditions, ") AND (") + ")", args
+	}
+	return "", args
+}
+
+// bindPlaceholders rewrites the "?" placeholders of a fragment to the dialect's, numbering
+// them after offset previous arguments. Question marks inside quoted strings or identifiers
+// are left alone.
+func bindPlaceholders(d Dialect, fragment string, offset int) string {
+	var sb strings.Builder
+	var quote rune
+	for _, r := range fragment {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case r == '?':
+			offset++
+			sb.WriteString(placeholder(d, offset))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	queryFilter = "$filter"
+	aliasPrefix = "@"
+)
+
+// QueryOptions holds the OData query options extracted from a request URL.
+type QueryOptions struct {
+	// Filter is the SQL condition built from the client's $filter, or "" if no filter was
+	// given. It does not include Predicates; use Where for the complete condition.
+	Filter string
+	// Predicates are the mandatory server-side predicates applying to the request, from
+	// WithPredicates and ContextWithPredicates, with their OData expressions converted to SQL.
+	// They are kept apart from Filter so they can be audited.
+	Predicates []Predicate
+
+	dialect Dialect
+}
+
+// Where returns the complete WHERE condition, Predicates AND-ed with the parenthesized
+// Filter, and the arguments of its placeholders. It returns "" if there is no condition.
+//
+// Example:
+//
+//	cond, args := opts.Where()
+//	// cond = "(tenant_id = $1) AND (age > 18 OR vip = TRUE)", args = []any{42}
+func (q *QueryOptions) Where() (string, []any) {
+	return where(q.dialect, q.Predicates, q.Filter, 0)
+}
+
+// ParseQueryOptions extracts the supported OData query options from URL query values.
+// Parameter aliases referenced in $filter (e.g. "status in @s&@s=['open','pending']")
+// are resolved from the same query values.
+//
+// Example:
+//
+//	q, _ := url.ParseQuery("$filter=price gt @min&@min=10")
+//	opts, err := ParseQueryOptions(q)
+//	// opts.Filter = "price > 10"
+//
+// Returns:
+//   - The parsed query options.
+//   - An error if the filter is invalid, or if an alias is undefined or unused.
+func ParseQueryOptions(query url.Values) (*QueryOptions, error) {
+	return defaultConverter.ParseQueryOptions(query)
+}
+
+// ParseQueryOptionsWithLimits is like ParseQueryOptions but enforces the given resource limits
+// on $filter and on every alias value.
+// It is equivalent to NewConverter(WithLimits(limits)).ParseQueryOptions(query).
+func ParseQueryOptionsWithLimits(query url.Values, limits Limits) (*QueryOptions, error) {
+	return NewConverter(WithLimits(limits)).ParseQueryOptions(query)
+}
+
+// ParseQueryOptions extracts the supported OData query options from URL query values using
+// the converter's configuration. See the package-level ParseQueryOptions.
+func (c *Converter) ParseQueryOptions(query url.Values) (*QueryOptions, error) {
+	return c.ParseQueryOptionsContext(context.Background(), query)
+}
+
+// ParseQueryOptionsContext is like ParseQueryOptions, passing ctx to the converter's Authorizer.
+func (c *Converter) ParseQueryOptionsContext(ctx context.Context, query url.Values) (*QueryOptions, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	aliases := make(map[string]string)
+	for key, values := range query {
+		if !strings.HasPrefix(key, aliasPrefix) {
+			continue
+		}
+		if len(values) != 1 {
+			return nil, fmt.Errorf("parameter alias %s must be specified exactly once", key)
+		}
+		aliases[strings.TrimPrefix(key, aliasPrefix)] = values[0]
+	}
+
+	opts := &QueryOptions{Predicates: slices.Clone(c.predicates), dialect: c.dialect}
+
+	filter := query.Get(queryFilter)
+	if strings.TrimSpace(filter) == "" {
+		if len(aliases) > 0 {
+			return nil, fmt.Errorf("parameter aliases defined without a $filter")
+		}
+		return opts, nil
+	}
+
+	sql, err := c.toSQL(ctx, filter, aliases)
+	if err != nil {
+		return nil, err
+	}
+	opts.Filter = sql
+	return opts, nil
+}
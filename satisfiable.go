@@ -0,0 +1,555 @@
+package odatasql
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// Contradiction reports whether an OData filter matches no row with the default converter.
+// See Converter.Contradiction.
+func Contradiction(filter string) (bool, error) {
+	return defaultConverter.Contradiction(filter)
+}
+
+// Tautology reports whether an OData filter matches every row with the default converter.
+// See Converter.Tautology.
+func Tautology(filter string) (bool, error) {
+	return defaultConverter.Tautology(filter)
+}
+
+// Implies reports whether every row matching filter also matches other with the default
+// converter. See Converter.Implies.
+func Implies(filter, other string) (bool, error) {
+	return defaultConverter.Implies(filter, other)
+}
+
+// Contradiction reports whether an OData filter can be proven to match no row, like
+// "age gt 10 and age lt 5". Filters are reasoned about in normal form, with the values each
+// clause allows for a field, following the semantics of the generated SQL:
+//
+//   - comparisons with null and the converter's NullHandling are evaluated like SQL's
+//     three-valued logic, where a row matches only when the filter is true
+//   - numbers compare numerically, and schema properties of integer types only hold integers
+//   - strings compare by their bytes, as with a binary collation, and strings compared with
+//     Edm.Date and Edm.DateTimeOffset properties compare as times
+//   - properties that the schema declares as NotNull never hold null; others may
+//
+// String functions and lambda operators are treated as independent predicates, so a false
+// result means the filter could not be proven contradictory. Filters whose normal form has
+// more clauses than the converter's Limits.MaxClauses are rejected with an *Error with code
+// ErrCodeMaxClausesExceeded. An empty filter matches every row.
+func (c *Converter) Contradiction(filter string) (bool, error) {
+	return c.ContradictionContext(context.Background(), filter)
+}
+
+// ContradictionContext is like Contradiction, passing ctx to the converter's Authorizer.
+func (c *Converter) ContradictionContext(ctx context.Context, filter string) (bool, error) {
+	return c.unsatisfiable(ctx, filter, isTrue, "")
+}
+
+// Tautology reports whether an OData filter can be proven to match every row, like
+// "age le 10 or age gt 5" on a property declared NotNull. A filter comparing a nullable
+// property is not a tautology, as it does not match rows where the property is null. See
+// Contradiction for how filters are reasoned about.
+func (c *Converter) Tautology(filter string) (bool, error) {
+	return c.TautologyContext(context.Background(), filter)
+}
+
+// TautologyContext is like Tautology, passing ctx to the converter's Authorizer.
+func (c *Converter) TautologyContext(ctx context.Context, filter string) (bool, error) {
+	return c.unsatisfiable(ctx, "", notTrue, filter)
+}
+
+// Implies reports whether every row matching filter can be proven to also match other, that
+// is, whether the rows of filter are a subset of the rows of other. For example,
+// "age gt 18 and status eq 'a'" implies "age ge 10", so results cached for the latter can
+// answer the former. See Contradiction for how filters are reasoned about.
+func (c *Converter) Implies(filter, other string) (bool, error) {
+	return c.ImpliesContext(context.Background(), filter, other)
+}
+
+// ImpliesContext is like Implies, passing ctx to the converter's Authorizer.
+func (c *Converter) ImpliesContext(ctx context.Context, filter, other string) (bool, error) {
+	return c.unsatisfiable(ctx, filter, notTrue, other)
+}
+
+// unsatisfiable reports whether no row matches filter while other has a truth value in want.
+// Empty filters are true for every row.
+func (c *Converter) unsatisfiable(ctx context.Context, filter string, want truthSet, other string) (bool, error) {
+	r := reasoner{c: c, max: c.maxClauses()}
+	clauses := []clause{{}}
+	for _, f := range []struct {
+		filter string
+		want   truthSet
+	}{{filter, isTrue}, {other, want}} {
+		if strings.TrimSpace(f.filter) == "" {
+			if f.want&isTrue == 0 {
+				return true, nil // an empty filter is never false or unknown
+			}
+			continue
+		}
+		node, err := c.parse(ctx, f.filter, nil, true)
+		if err != nil {
+			return false, err
+		}
+		next, ok := r.clauses(node, f.want)
+		if ok {
+			clauses, ok = r.product(clauses, next)
+		}
+		if !ok {
+			return false, fmt.Errorf("reasoning about OData filter %q: %w", f.filter, c.tooManyClauses())
+		}
+	}
+	return len(clauses) == 0, nil
+}
+
+// truthSet is a set of truth values of SQL's three-valued logic.
+type truthSet uint8
+
+const (
+	isTrue    truthSet = 1 << truthTrue
+	isFalse   truthSet = 1 << truthFalse
+	isUnknown truthSet = 1 << truthUnknown
+	notTrue            = isFalse | isUnknown
+	anyTruth           = isTrue | isFalse | isUnknown
+)
+
+// negate returns the truth values of "not x" for x in s.
+func (s truthSet) negate() truthSet {
+	n := s & isUnknown
+	if s&isTrue != 0 {
+		n |= isFalse
+	}
+	if s&isFalse != 0 {
+		n |= isTrue
+	}
+	return n
+}
+
+// constraint restricts the values of a field, or the truth values of a predicate the reasoner
+// does not look into, identified by its OData text.
+type constraint struct {
+	field     string
+	values    valueSet
+	predicate string
+	truths    truthSet
+}
+
+// clause is a conjunction of constraints.
+type clause []constraint
+
+// reasoner computes, in disjunctive normal form, the conditions on the rows for which a filter
+// has given truth values, dropping the clauses no row satisfies.
+type reasoner struct {
+	c   *Converter
+	max int
+}
+
+// clauses returns the clauses, combined with OR, satisfied by the rows for which node has a
+// truth value in want, which is {true}, {false}, {false, unknown} or {true, unknown}. It
+// returns false if there are more than r.max clauses.
+func (r reasoner) clauses(node ast.Node, want truthSet) ([]clause, bool) {
+	switch n := node.(type) {
+	case *ast.ParenNode:
+		return r.clauses(n.Child, want)
+	case *ast.NotNode:
+		return r.clauses(n.Child, want.negate())
+	case *ast.BinaryNode:
+		left, ok := r.clauses(n.Left, want)
+		if !ok {
+			return nil, false
+		}
+		right, ok := r.clauses(n.Right, want)
+		if !ok {
+			return nil, false
+		}
+		// "and" is true when both operands are true and not false when neither is false; "or"
+		// is false when both operands are false and not true when neither is true.
+		if (n.Op == ast.OpAnd) == (want&isTrue != 0) {
+			return r.product(left, right)
+		}
+		clauses := append(left, right...)
+		return clauses, r.max <= 0 || len(clauses) <= r.max
+	case *ast.ConditionNode:
+		t, f, u := r.condition(n)
+		return r.leaf(n.Field, t, f, u, want), true
+	case *ast.InNode:
+		t, f, u := r.in(n)
+		return r.leaf(n.Field, t, f, u, want), true
+	case *ast.FunctionNode:
+		// A string function is unknown for null and otherwise an independent predicate.
+		var clauses []clause
+		if known := want &^ isUnknown; known != 0 {
+			clauses = append(clauses, clause{
+				{predicate: ast.Format(n), truths: known},
+				{field: n.Field, values: r.restrict(n.Field, allValues())},
+			})
+		}
+		if want&isUnknown != 0 {
+			clauses = append(clauses, clause{{field: n.Field, values: r.restrict(n.Field, nullValue())}})
+		}
+		return r.satisfiable(clauses), true
+	}
+	return []clause{{{predicate: ast.Format(node), truths: want}}}, true
+}
+
+// product returns the clauses satisfied by the rows satisfying a clause of left and a clause
+// of right, or false if there are more than r.max.
+func (r reasoner) product(left, right []clause) ([]clause, bool) {
+	var clauses []clause
+	for _, l := range left {
+		for _, rc := range right {
+			cl := append(append(clause{}, l...), rc...)
+			if !r.satisfied(cl) {
+				continue
+			}
+			clauses = append(clauses, cl)
+			if r.max > 0 && len(clauses) > r.max {
+				return nil, false
+			}
+		}
+	}
+	return clauses, true
+}
+
+// leaf returns the clause of a comparison whose values for true, false and unknown are t, f
+// and u.
+func (r reasoner) leaf(field string, t, f, u valueSet, want truthSet) []clause {
+	var values valueSet
+	for _, s := range []struct {
+		truth  truthSet
+		values valueSet
+	}{{isTrue, t}, {isFalse, f}, {isUnknown, u}} {
+		if want&s.truth != 0 {
+			values = values.union(s.values)
+		}
+	}
+	return r.satisfiable([]clause{{{field: field, values: r.restrict(field, values)}}})
+}
+
+// condition returns the values of a field for which a comparison is true, false and unknown.
+func (r reasoner) condition(n *ast.ConditionNode) (valueSet, valueSet, valueSet) {
+	if n.Value.Kind == ast.KindNull {
+		if r.c.nulls == NullAsIsNull && (n.Op == ast.OpEq || n.Op == ast.OpNe) {
+			if n.Op == ast.OpEq {
+				return nullValue(), allValues(), valueSet{}
+			}
+			return allValues(), nullValue(), valueSet{}
+		}
+		return valueSet{}, valueSet{}, allValues().union(nullValue())
+	}
+
+	v, ok := r.value(n.Field, n.Value)
+	if !ok {
+		return allValues(), allValues(), nullValue()
+	}
+	return compared(n.Op, v), compared(negatedOperators[n.Op], v), nullValue()
+}
+
+// in returns the values of a field for which an IN list is true, false and unknown.
+func (r reasoner) in(n *ast.InNode) (valueSet, valueSet, valueSet) {
+	t, f := valueSet{}, allValues()
+	for _, lit := range n.Values {
+		v, ok := r.value(n.Field, lit)
+		if !ok {
+			return allValues(), allValues(), nullValue()
+		}
+		t = t.union(compared(ast.OpEq, v))
+		if f, ok = f.intersect(compared(ast.OpNe, v)); !ok {
+			return t, allValues(), nullValue()
+		}
+	}
+	return t, f, nullValue()
+}
+
+// negatedOperators maps comparison operators to their negation on non-null values.
+var negatedOperators = map[string]string{
+	ast.OpEq: ast.OpNe,
+	ast.OpNe: ast.OpEq,
+	ast.OpGt: ast.OpLe,
+	ast.OpGe: ast.OpLt,
+	ast.OpLt: ast.OpGe,
+	ast.OpLe: ast.OpGt,
+}
+
+// compared returns the values for which "x op v" is true.
+func compared(op string, v *value) valueSet {
+	at := bound{value: v, inclusive: true}
+	after := bound{value: v}
+	switch op {
+	case ast.OpEq:
+		return valueSet{intervals: []interval{{at, at}}}
+	case ast.OpNe:
+		return valueSet{intervals: []interval{{hi: after}, {lo: after}}}
+	case ast.OpGt:
+		return valueSet{intervals: []interval{{lo: after}}}
+	case ast.OpGe:
+		return valueSet{intervals: []interval{{lo: at}}}
+	case ast.OpLt:
+		return valueSet{intervals: []interval{{hi: after}}}
+	default:
+		return valueSet{intervals: []interval{{hi: at}}}
+	}
+}
+
+// restrict removes null from values of properties declared NotNull.
+func (r reasoner) restrict(field string, values valueSet) valueSet {
+	if r.c.schema != nil {
+		if p, ok := r.c.schema.Property(field); ok && p.NotNull {
+			values.null = false
+		}
+	}
+	return values
+}
+
+// satisfiable returns the clauses some row may satisfy.
+func (r reasoner) satisfiable(clauses []clause) []clause {
+	var result []clause
+	for _, cl := range clauses {
+		if r.satisfied(cl) {
+			result = append(result, cl)
+		}
+	}
+	return result
+}
+
+// satisfied reports whether some row may satisfy all the constraints of a clause. It returns
+// true when values cannot be compared.
+func (r reasoner) satisfied(cl clause) bool {
+	values := make(map[string]valueSet)
+	truths := make(map[string]truthSet)
+	for _, c := range cl {
+		if c.predicate != "" {
+			t, ok := truths[c.predicate]
+			if !ok {
+				t = anyTruth
+			}
+			if truths[c.predicate] = t & c.truths; truths[c.predicate] == 0 {
+				return false
+			}
+			continue
+		}
+
+		v, seen := values[c.field]
+		if !seen {
+			v = c.values
+		} else if intersection, ok := v.intersect(c.values); ok {
+			v = intersection
+		} else {
+			return true
+		}
+		if v.empty(r.integer(c.field)) {
+			return false
+		}
+		values[c.field] = v
+	}
+	return true
+}
+
+// integer reports whether a field is a schema property holding only integers.
+func (r reasoner) integer(field string) bool {
+	switch propertyType(r.c.schema, field) {
+	case EdmByte, EdmSByte, EdmInt16, EdmInt32, EdmInt64:
+		return true
+	}
+	return false
+}
+
+// value converts a literal compared with a field into an ordered value.
+func (r reasoner) value(field string, lit ast.Literal) (*value, bool) {
+	switch lit.Kind {
+	case ast.KindNumber:
+		n, ok := new(big.Rat).SetString(lit.Value)
+		return &value{kind: valueNumber, number: n, literal: lit}, ok
+	case ast.KindBoolean:
+		n := big.NewRat(0, 1)
+		if lit.Value == "true" {
+			n = big.NewRat(1, 1)
+		}
+		return &value{kind: valueBoolean, number: n, literal: lit}, true
+	case ast.KindString:
+		switch propertyType(r.c.schema, field) {
+		case EdmDate, EdmDateTimeOffset:
+			t, err := parseTime(lit)
+			return &value{kind: valueTime, time: t, literal: lit}, err == nil
+		}
+		return &value{kind: valueString, str: lit.Value, literal: lit}, true
+	}
+	return nil, false
+}
+
+// Kinds of values, which are only comparable with values of the same kind.
+const (
+	valueNumber = iota
+	valueBoolean
+	valueString
+	valueTime
+)
+
+// value is a non-null value of a field. Booleans are the numbers 0 and 1.
+type value struct {
+	kind    int
+	number  *big.Rat
+	str     string
+	time    time.Time
+	literal ast.Literal // the literal the value was read from
+}
+
+// compare compares a and b, or returns false if they are of different kinds.
+func (a *value) compare(b *value) (int, bool) {
+	if a.kind != b.kind {
+		return 0, false
+	}
+	switch a.kind {
+	case valueString:
+		return strings.Compare(a.str, b.str), true
+	case valueTime:
+		return a.time.Compare(b.time), true
+	}
+	return a.number.Cmp(b.number), true
+}
+
+// bound is an end of an interval, unbounded when value is nil.
+type bound struct {
+	value     *value
+	inclusive bool
+}
+
+// interval is a range of non-null values.
+type interval struct {
+	lo, hi bound
+}
+
+// valueSet is a set of values of a field: null and ranges of non-null values.
+type valueSet struct {
+	null      bool
+	intervals []interval
+}
+
+func allValues() valueSet { return valueSet{intervals: []interval{{}}} }
+
+func nullValue() valueSet { return valueSet{null: true} }
+
+func (s valueSet) union(o valueSet) valueSet {
+	return valueSet{null: s.null || o.null, intervals: append(append([]interval{}, s.intervals...), o.intervals...)}
+}
+
+// intersect returns the intersection of s and o, or false if their values are not comparable.
+func (s valueSet) intersect(o valueSet) (valueSet, bool) {
+	result := valueSet{null: s.null && o.null}
+	for _, a := range s.intervals {
+		for _, b := range o.intervals {
+			i, ok := a.intersect(b)
+			if !ok {
+				return valueSet{}, false
+			}
+			if !i.empty(false) {
+				result.intervals = append(result.intervals, i)
+			}
+		}
+	}
+	return result, true
+}
+
+// empty reports whether s has no value, given whether its numbers are integers.
+func (s valueSet) empty(integer bool) bool {
+	if s.null {
+		return false
+	}
+	for _, i := range s.intervals {
+		if !i.empty(integer) {
+			return false
+		}
+	}
+	return true
+}
+
+func (i interval) intersect(o interval) (interval, bool) {
+	lo, ok := tighter(i.lo, o.lo, 1)
+	if !ok {
+		return interval{}, false
+	}
+	hi, ok := tighter(i.hi, o.hi, -1)
+	return interval{lo, hi}, ok
+}
+
+// tighter returns the larger lower bound (sign 1) or the smaller upper bound (sign -1).
+func tighter(a, b bound, sign int) (bound, bool) {
+	switch {
+	case a.value == nil:
+		return b, true
+	case b.value == nil:
+		return a, true
+	}
+	cmp, ok := a.value.compare(b.value)
+	switch {
+	case !ok:
+		return bound{}, false
+	case cmp*sign > 0:
+		return a, true
+	case cmp*sign < 0:
+		return b, true
+	}
+	return bound{value: a.value, inclusive: a.inclusive && b.inclusive}, true
+}
+
+// empty reports whether an interval has no value, given whether its numbers are integers.
+// Intervals of values that are not comparable are not empty.
+func (i interval) empty(integer bool) bool {
+	discrete := integer
+	for _, b := range []bound{i.lo, i.hi} {
+		if b.value != nil && b.value.kind == valueBoolean {
+			discrete = true
+		} else if b.value != nil && b.value.kind != valueNumber {
+			discrete = false
+		}
+	}
+	if !discrete {
+		if i.lo.value == nil || i.hi.value == nil {
+			return false
+		}
+		cmp, ok := i.lo.value.compare(i.hi.value)
+		return ok && (cmp > 0 || cmp == 0 && !(i.lo.inclusive && i.hi.inclusive))
+	}
+
+	// Compare the smallest and largest integers in the interval; booleans are 0 and 1.
+	boolean := i.lo.value != nil && i.lo.value.kind == valueBoolean || i.hi.value != nil && i.hi.value.kind == valueBoolean
+	lo, hi := integerBound(i.lo, 1), integerBound(i.hi, -1)
+	if boolean {
+		if lo == nil || lo.Sign() < 0 {
+			lo = big.NewInt(0)
+		}
+		if hi == nil || hi.Cmp(big.NewInt(1)) > 0 {
+			hi = big.NewInt(1)
+		}
+	}
+	return lo != nil && hi != nil && lo.Cmp(hi) > 0
+}
+
+// integerBound returns the smallest integer above a lower bound (sign 1) or the largest
+// integer below an upper bound (sign -1), or nil if the bound is unbounded.
+func integerBound(b bound, sign int) *big.Int {
+	if b.value == nil {
+		return nil
+	}
+	r := b.value.number
+	floor := new(big.Int).Div(r.Num(), r.Denom()) // Euclidean division rounds down for positive denominators
+	isInteger := r.IsInt()
+	if sign > 0 {
+		// Smallest integer n with n >= r, or n > r for an exclusive bound.
+		if isInteger && b.inclusive {
+			return floor
+		}
+		return floor.Add(floor, big.NewInt(1))
+	}
+	// Largest integer n with n <= r, or n < r for an exclusive bound.
+	if isInteger && !b.inclusive {
+		return floor.Sub(floor, big.NewInt(1))
+	}
+	return floor
+}
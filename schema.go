@@ -0,0 +1,200 @@
+package odatasql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// EdmType is the name of an OData primitive type, such as "Edm.String".
+type EdmType string
+
+// Supported EDM primitive types.
+const (
+	EdmString         EdmType = "Edm.String"
+	EdmBoolean        EdmType = "Edm.Boolean"
+	EdmByte           EdmType = "Edm.Byte"
+	EdmSByte          EdmType = "Edm.SByte"
+	EdmInt16          EdmType = "Edm.Int16"
+	EdmInt32          EdmType = "Edm.Int32"
+	EdmInt64          EdmType = "Edm.Int64"
+	EdmSingle         EdmType = "Edm.Single"
+	EdmDouble         EdmType = "Edm.Double"
+	EdmDecimal        EdmType = "Edm.Decimal"
+	EdmGuid           EdmType = "Edm.Guid"
+	EdmDate           EdmType = "Edm.Date"
+	EdmDateTimeOffset EdmType = "Edm.DateTimeOffset"
+	EdmTimeOfDay      EdmType = "Edm.TimeOfDay"
+	EdmDuration       EdmType = "Edm.Duration"
+)
+
+// literalKinds maps each EDM type to the kind of literal it is compared against.
+var literalKinds = map[EdmType]ast.LiteralKind{
+	EdmString:         ast.KindString,
+	EdmBoolean:        ast.KindBoolean,
+	EdmByte:           ast.KindNumber,
+	EdmSByte:          ast.KindNumber,
+	EdmInt16:          ast.KindNumber,
+	EdmInt32:          ast.KindNumber,
+	EdmInt64:          ast.KindNumber,
+	EdmSingle:         ast.KindNumber,
+	EdmDouble:         ast.KindNumber,
+	EdmDecimal:        ast.KindNumber,
+	EdmGuid:           ast.KindString,
+	EdmDate:           ast.KindString,
+	EdmDateTimeOffset: ast.KindString,
+	EdmTimeOfDay:      ast.KindString,
+	EdmDuration:       ast.KindString,
+}
+
+// Supported reports whether t is one of the EDM types supported in schemas.
+func (t EdmType) Supported() bool {
+	_, ok := literalKinds[t]
+	return ok
+}
+
+// Property describes a property that may be referenced in a filter.
+type Property struct {
+	// Name is the OData property name used in filters.
+	Name string
+	// Column is the SQL column the property maps to, optionally qualified ("addr.city").
+	// If empty, the converter's naming strategy is applied to Name.
+	Column string
+	// ColumnPrefix, used when Column is empty, is prepended to the column the naming strategy
+	// derives from the last segment of Name: "addr." maps "address/city" to "addr.city".
+	ColumnPrefix string
+	// Type is the EDM type of the property. If empty, any literal is accepted.
+	Type EdmType
+	// NotNull declares that the property never holds null, as with a NOT NULL column. It lets
+	// Contradiction, Tautology, Implies and SimplifyFilter rule out null; properties without
+	// it may hold null.
+	NotNull bool
+	// NonFilterable excludes the property from filters while keeping it in the schema.
+	NonFilterable bool
+	// NonSortable marks the property as not usable for sorting.
+	NonSortable bool
+	// Enum restricts a string property to the members of an enumeration.
+	Enum *EnumType
+	// FullText marks a string property indexed as analyzed text, such as an Elasticsearch
+	// "text" field, rather than as an exact keyword. FilterToElastic queries it with phrase
+	// matches instead of term-level queries.
+	FullText bool
+	// Index is the kind of index on the property's column, which EstimateCost uses to tell
+	// which predicates can be served without scanning the table.
+	Index IndexKind
+	// Stats holds optional statistics on the property's column, which EstimateCost uses to
+	// estimate the selectivity of predicates.
+	Stats *ColumnStats
+}
+
+// EnumType describes an enumeration. Filters compare enum properties with member names,
+// e.g. "color eq 'Red'".
+type EnumType struct {
+	// Name is the qualified name of the enumeration, e.g. "Sales.Color".
+	Name string
+	// Members are the names of the enumeration members.
+	Members []string
+}
+
+// hasMember reports whether name is a member of the enumeration.
+func (e *EnumType) hasMember(name string) bool {
+	for _, m := range e.Members {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema is the allow-list of properties a filter may reference.
+// A Schema is immutable once created and safe for concurrent use.
+type Schema struct {
+	properties map[string]Property
+	order      []string
+}
+
+// NewSchema creates a schema from the given properties.
+// Property names are case-sensitive and must be unique.
+func NewSchema(properties ...Property) (*Schema, error) {
+	s := &Schema{properties: make(map[string]Property, len(properties))}
+	for _, p := range properties {
+		if p.Name == "" {
+			return nil, fmt.Errorf("property name must not be empty")
+		}
+		if _, exists := s.properties[p.Name]; exists {
+			return nil, fmt.Errorf("duplicate property %q", p.Name)
+		}
+		if p.Type != "" && !p.Type.Supported() {
+			return nil, fmt.Errorf("property %q has unsupported type %q", p.Name, p.Type)
+		}
+		if p.Enum != nil && p.Type != EdmString {
+			return nil, fmt.Errorf("enum property %q must have type %s", p.Name, EdmString)
+		}
+		if p.Stats != nil && (p.Stats.Distinct < 0 || p.Stats.NullFraction < 0 || p.Stats.NullFraction > 1) {
+			return nil, fmt.Errorf("property %q has invalid statistics", p.Name)
+		}
+		s.properties[p.Name] = p
+		s.order = append(s.order, p.Name)
+	}
+	return s, nil
+}
+
+// Properties returns the schema's properties in declaration order.
+func (s *Schema) Properties() []Property {
+	properties := make([]Property, len(s.order))
+	for i, name := range s.order {
+		properties[i] = s.properties[name]
+	}
+	return properties
+}
+
+// Property returns the property with the given name.
+func (s *Schema) Property(name string) (Property, bool) {
+	p, ok := s.properties[name]
+	return p, ok
+}
+
+// checkField verifies that a field is declared and that the literals compared against it
+// match its type. Null is accepted for every type. String functions require a string
+// property and accept any argument, including partial enum members. A lambda operator may also
+// range over a collection declared only through its element properties, like "items/price".
+func (s *Schema) checkField(field, op string, values []ast.Literal) error {
+	p, ok := s.properties[field]
+	if !ok && (op == ast.LambdaAny || op == ast.LambdaAll) {
+		// A collection of structured elements is declared through its element properties.
+		for name := range s.properties {
+			if strings.HasPrefix(name, field+"/") {
+				return nil
+			}
+		}
+	}
+	if !ok {
+		return fmt.Errorf("unknown property")
+	}
+	if p.NonFilterable {
+		return fmt.Errorf("property is not filterable")
+	}
+	if p.Type == "" {
+		return nil
+	}
+	if isStringFunction(op) {
+		if p.Type != EdmString {
+			return fmt.Errorf("%s requires a string property, not %s", op, p.Type)
+		}
+		return nil
+	}
+	want := literalKinds[p.Type]
+	for _, v := range values {
+		if v.Kind == ast.KindNull {
+			continue
+		}
+		if v.Kind != want {
+			return fmt.Errorf("cannot compare %s property with %q", p.Type, v.Value)
+		}
+		if p.Enum != nil && !p.Enum.hasMember(v.Value) {
+			return fmt.Errorf("%q is not a member of %s", v.Value, p.Enum.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package odatasql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	tagJSON  = "json"
+	tagDB    = "db"
+	tagGorm  = "gorm"
+	tagOData = "odata"
+
+	odataFilterable = "filterable"
+	odataSortable   = "sortable"
+)
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	nullStringType  = reflect.TypeOf(sql.NullString{})
+	nullBoolType    = reflect.TypeOf(sql.NullBool{})
+	nullByteType    = reflect.TypeOf(sql.NullByte{})
+	nullInt16Type   = reflect.TypeOf(sql.NullInt16{})
+	nullInt32Type   = reflect.TypeOf(sql.NullInt32{})
+	nullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	nullTimeType    = reflect.TypeOf(sql.NullTime{})
+)
+
+// nullTypes maps the database/sql NullX types to the EDM type of their value.
+var nullTypes = map[reflect.Type]EdmType{
+	nullStringType:  EdmString,
+	nullBoolType:    EdmBoolean,
+	nullByteType:    EdmByte,
+	nullInt16Type:   EdmInt16,
+	nullInt32Type:   EdmInt32,
+	nullInt64Type:   EdmInt64,
+	nullFloat64Type: EdmDouble,
+	nullTimeType:    EdmDateTimeOffset,
+}
+
+// kindTypes maps Go kinds to EDM types.
+var kindTypes = map[reflect.Kind]EdmType{
+	reflect.String:  EdmString,
+	reflect.Bool:    EdmBoolean,
+	reflect.Int8:    EdmSByte,
+	reflect.Uint8:   EdmByte,
+	reflect.Int16:   EdmInt16,
+	reflect.Uint16:  EdmInt32,
+	reflect.Int32:   EdmInt32,
+	reflect.Uint32:  EdmInt64,
+	reflect.Int:     EdmInt64,
+	reflect.Int64:   EdmInt64,
+	reflect.Uint:    EdmDecimal,
+	reflect.Uint64:  EdmDecimal,
+	reflect.Float32: EdmSingle,
+	reflect.Float64: EdmDouble,
+}
+
+// SchemaFromStruct derives a Schema from the exported fields of the struct type T.
+//
+// For each field:
+//   - The OData name comes from the `json` tag, falling back to the Go field name.
+//   - The column comes from the `db` tag or the `column:` setting of the `gorm` tag. Without
+//     either, the column is left empty so the converter's naming strategy applies.
+//   - The EDM type is derived from the Go type, looking through pointers, database/sql NullX
+//     and sql.Null[T] types; time.Time maps to Edm.DateTimeOffset and 16-byte arrays (such as
+//     uuid.UUID) to Edm.Guid. Fields of other types are skipped.
+//   - A `not null` setting of the `gorm` tag marks the property NotNull, unless the field is
+//     reached through a pointer or a navigation field, whose joined row may be missing.
+//     Non-pointer fields are not NotNull by themselves: gorm scans NULL into their zero value.
+//   - An `odata:"-"` tag (or "-" in `json`, `db` or `gorm`) skips the field, and
+//     `odata:"filterable,sortable"` lists its capabilities; without the tag it is both.
+//
+// Embedded structs are flattened like in encoding/json. Other struct fields become
+// navigation paths such as "address/city". A `db` or `gorm` column tag on the struct field
+// names the alias of a joined table ("addr.city"), and gorm `embedded` fields flatten their
+// columns with the embeddedPrefix ("loc_lat"). Columns the tags leave open are mapped with
+// the converter's naming strategy: an untagged field below a tagged path gets a ColumnPrefix,
+// and a path without tags is mapped segment by segment ("address.city"). A path must not mix
+// the two, since the strategy is only known when rendering.
+//
+// Example:
+//
+//	type User struct {
+//	    ID        int64     `json:"id" db:"id"`
+//	    Email     string    `json:"email" db:"email_address"`
+//	    CreatedAt time.Time `json:"createdAt" odata:"sortable"`
+//	}
+//	schema, err := SchemaFromStruct[User]()
+func SchemaFromStruct[T any]() (*Schema, error) {
+	t := indirect(reflect.TypeFor[T]())
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("SchemaFromStruct requires a struct type, got %s", t)
+	}
+
+	var properties []Property
+	if err := collectProperties(t, "", columnPath{}, map[reflect.Type]bool{}, &properties); err != nil {
+		return nil, err
+	}
+	return NewSchema(properties...)
+}
+
+// columnPath tracks how the columns below a path prefix are derived: from struct tags, as a
+// table alias chain plus a flattened column prefix, or from the converter's naming strategy
+// once an untagged navigation field is crossed.
+type columnPath struct {
+	table   string // '.'-joined aliases of the tagged navigation fields
+	prefix  string // gorm embeddedPrefix of the columns within table
+	tagged  bool   // a tagged navigation or gorm embedded field was crossed
+	derived bool   // an untagged navigation field was crossed
+	// nullable reports that a pointer or a navigation field, whose joined row may be
+	// missing, was crossed: the properties below may be null whatever their tags.
+	nullable bool
+}
+
+// errMixedColumns reports a path whose column would mix struct tags with the naming strategy.
+func errMixedColumns(path string) error {
+	return fmt.Errorf("columns below %q mix struct tags with the naming strategy: tag every navigation field along the path with its table alias, or none", path)
+}
+
+// collectProperties appends the properties of struct type t, prefixing their names with
+// namePrefix and deriving their columns from cp.
+func collectProperties(t reflect.Type, namePrefix string, cp columnPath, visiting map[reflect.Type]bool, out *[]Property) error {
+	if visiting[t] {
+		return nil // recursive type: stop at the first repetition
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// Like encoding/json, promote the exported fields of unexported embedded structs.
+		if !f.IsExported() && !(f.Anonymous && indirect(f.Type).Kind() == reflect.Struct) {
+			continue
+		}
+
+		tags, skip, err := readFieldTags(f)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), f.Name, err)
+		}
+		if skip {
+			continue
+		}
+
+		ft := indirect(f.Type)
+		name := namePrefix + tags.nameOr(f.Name)
+
+		edm := edmTypeOf(ft)
+		if edm == "" && ft.Kind() == reflect.Struct {
+			next := cp
+			next.nullable = cp.nullable || f.Type.Kind() == reflect.Pointer
+			if f.Anonymous && tags.name == "" {
+				if err := collectProperties(ft, namePrefix, next, visiting, out); err != nil {
+					return err
+				}
+				continue
+			}
+
+			switch {
+			case tags.embedded:
+				next.prefix += tags.embeddedPrefix
+				next.tagged = true
+			case tags.column != "":
+				next.table = joinAlias(cp.table, tags.column)
+				next.prefix = ""
+				next.tagged = true
+				next.nullable = true
+			default:
+				next.derived = true
+				next.nullable = true
+			}
+			if next.tagged && next.derived {
+				return errMixedColumns(name)
+			}
+			if err := collectProperties(ft, name+"/", next, visiting, out); err != nil {
+				return err
+			}
+			continue
+		}
+		if edm == "" {
+			continue // not representable as an EDM primitive
+		}
+
+		p := Property{
+			Name:          name,
+			Type:          edm,
+			NotNull:       tags.notNull && !cp.nullable,
+			NonFilterable: !tags.filterable,
+			NonSortable:   !tags.sortable,
+		}
+		switch {
+		case cp.derived && tags.column != "":
+			return errMixedColumns(name)
+		case cp.tagged:
+			base := cp.prefix
+			if cp.table != "" {
+				base = cp.table + "." + base
+			}
+			if tags.column != "" {
+				p.Column = base + tags.column
+			} else if base == "" {
+				return fmt.Errorf("field %s.%s: gorm embedded fields without an embeddedPrefix need a column tag", t.Name(), f.Name)
+			} else {
+				p.ColumnPrefix = base
+			}
+		default:
+			p.Column = tags.column
+		}
+		*out = append(*out, p)
+	}
+	return nil
+}
+
+// joinAlias appends a table alias to a '.'-joined alias chain.
+func joinAlias(table, alias string) string {
+	if table == "" {
+		return alias
+	}
+	return table + "." + alias
+}
+
+// indirect dereferences pointer types.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// edmTypeOf returns the EDM type of a (non-pointer) Go type, or of the value held by a
+// database/sql null type. It returns "" for types without an EDM equivalent.
+func edmTypeOf(t reflect.Type) EdmType {
+	if edm, ok := nullTypes[t]; ok {
+		return edm
+	}
+	if t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null[") {
+		if v, ok := t.FieldByName("V"); ok {
+			return edmTypeOf(v.Type)
+		}
+	}
+	if t == timeType {
+		return EdmDateTimeOffset
+	}
+	if t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8 {
+		return EdmGuid
+	}
+	return kindTypes[t.Kind()]
+}
+
+// fieldTags holds the tag settings relevant to a struct field.
+type fieldTags struct {
+	name           string // from json
+	column         string // from db or gorm
+	embedded       bool   // gorm embedded
+	embeddedPrefix string // gorm embeddedPrefix
+	notNull        bool   // gorm not null
+	filterable     bool
+	sortable       bool
+}
+
+func (t fieldTags) nameOr(fallback string) string {
+	if t.name != "" {
+		return t.name
+	}
+	return fallback
+}
+
+// readFieldTags parses the json, db, gorm and odata tags of a field. It reports skip when
+// any of them excludes the field.
+func readFieldTags(f reflect.StructField) (fieldTags, bool, error) {
+	tags := fieldTags{filterable: true, sortable: true}
+
+	if json, ok := f.Tag.Lookup(tagJSON); ok {
+		name, _, _ := strings.Cut(json, ",")
+		if name == "-" {
+			return tags, true, nil
+		}
+		tags.name = name
+	}
+
+	if db, ok := f.Tag.Lookup(tagDB); ok {
+		name, _, _ := strings.Cut(db, ",")
+		if name == "-" {
+			return tags, true, nil
+		}
+		tags.column = name
+	}
+
+	if gorm, ok := f.Tag.Lookup(tagGorm); ok {
+		if gorm == "-" {
+			return tags, true, nil
+		}
+		for _, setting := range strings.Split(gorm, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(setting), ":")
+			switch strings.ToLower(key) {
+			case "column":
+				if tags.column == "" {
+					tags.column = value
+				}
+			case "embedded":
+				tags.embedded = true
+			case "embeddedprefix":
+				tags.embeddedPrefix = value
+			case "not null":
+				tags.notNull = true
+			case "-":
+				return tags, true, nil
+			}
+		}
+	}
+
+	if odata, ok := f.Tag.Lookup(tagOData); ok {
+		if odata == "-" {
+			return tags, true, nil
+		}
+		tags.filterable, tags.sortable = false, false
+		for _, option := range strings.Split(odata, ",") {
+			switch strings.TrimSpace(option) {
+			case odataFilterable:
+				tags.filterable = true
+			case odataSortable:
+				tags.sortable = true
+			case "":
+			default:
+				return tags, false, fmt.Errorf("unknown odata tag option %q", option)
+			}
+		}
+	}
+
+	return tags, false, nil
+}
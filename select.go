@@ -0,0 +1,101 @@
+package odatasql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Queryer runs queries. It is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Select composes SELECT statements from a fixed base (table, columns and mandatory
+// predicates) and the query options of a request. A Select is immutable: Where returns a new
+// Select, so a base can be built once and shared across goroutines.
+type Select struct {
+	conv    *Converter
+	table   string
+	columns []string
+	where   []Predicate
+}
+
+// Select starts a statement selecting columns (all columns when none are given) from table.
+// The table and column names are trusted and quoted with the converter's dialect; qualified
+// names such as "u.id" are quoted part by part.
+//
+// Example:
+//
+//	users := conv.Select("users", "id", "name").Where("deleted_at IS NULL")
+//	rows, err := users.Query(ctx, db, opts)
+func (c *Converter) Select(table string, columns ...string) *Select {
+	return &Select{conv: c, table: table, columns: columns}
+}
+
+// Where returns a copy of s with an additional mandatory predicate. The predicate is a trusted
+// SQL fragment whose "?" placeholders are bound to args in order; they are rewritten to the
+// dialect's placeholders ("$1" for DialectPostgres, "@p1" for DialectSQLServer). Question
+// marks inside quoted strings or identifiers are left alone.
+func (s *Select) Where(predicateSQL string, args ...any) *Select {
+	clone := *s
+	clone.where = append(s.where[:len(s.where):len(s.where)], SQLPredicate(predicateSQL, args...))
+	return &clone
+}
+
+// SQL returns the statement selecting the rows that match the mandatory predicates of s and
+// opts and the filter of opts, and the arguments to run it with. opts may be nil.
+//
+// Each condition is parenthesized before they are combined with AND, so an OR in the filter
+// cannot escape the mandatory predicates:
+//
+//	SELECT id, name FROM users WHERE (deleted_at IS NULL) AND (age > 18 OR vip = true)
+func (s *Select) SQL(opts *QueryOptions) (string, []any) {
+	columns := "*"
+	if len(s.columns) > 0 {
+		quoted := make([]string, len(s.columns))
+		for i, column := range s.columns {
+			quoted[i] = s.conv.quoteQualified(column)
+		}
+		columns = strings.Join(quoted, ", ")
+	}
+	return s.statement(columns, opts)
+}
+
+// CountSQL is like SQL but returns a statement counting the matching rows.
+func (s *Select) CountSQL(opts *QueryOptions) (string, []any) {
+	return s.statement("COUNT(*)", opts)
+}
+
+// Query runs the statement returned by SQL.
+func (s *Select) Query(ctx context.Context, db Queryer, opts *QueryOptions) (*sql.Rows, error) {
+	query, args := s.SQL(opts)
+	return db.QueryContext(ctx, query, args...)
+}
+
+// Count runs the statement returned by CountSQL and returns the number of matching rows.
+func (s *Select) Count(ctx context.Context, db Queryer, opts *QueryOptions) (int64, error) {
+	query, args := s.CountSQL(opts)
+	var n int64
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("counting rows: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Select) statement(columns string, opts *QueryOptions) (string, []any) {
+	predicates, filter := s.where, ""
+	if opts != nil {
+		predicates = append(predicates[:len(predicates):len(predicates)], opts.Predicates...)
+		filter = opts.Filter
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", columns, s.conv.quoteQualified(s.table))
+	condition, args := where(s.conv.dialect, predicates, filter, 0)
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	return query, args
+}
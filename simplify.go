@@ -0,0 +1,66 @@
+package odatasql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/maxlambrecht/odatasql/internal/ast"
+)
+
+// SimplifyFilter simplifies an OData filter with the default converter. See
+// Converter.SimplifyFilter.
+func SimplifyFilter(filter string) (string, error) {
+	return defaultConverter.SimplifyFilter(filter)
+}
+
+// SimplifyFilter parses an OData filter with the converter's configuration, removes its
+// redundancies and writes it back in canonical form, like FormatFilter. It:
+//
+//   - removes redundant parentheses and double negations ("not not a eq 1")
+//   - pushes "not" inward with De Morgan's laws: "not (a eq 1 or b eq 2)" becomes
+//     "not a eq 1 and not b eq 2"
+//   - negates comparisons by flipping their operator when it cannot change the result for
+//     null: comparisons against null, and with a schema, comparisons on properties declared
+//     NotNull, so "not age gt 18" becomes "age le 18"
+//   - flattens chains of "and" and "or" and removes duplicate terms
+//   - collapses equalities on the same field within an "or" into an "in" list, within the
+//     converter's MaxInValues, and "in" lists with a single value into equalities
+//
+// The simplified filter matches the same rows as the original under SQL's three-valued logic,
+// in every rendering of this package. Simplifying it again returns it unchanged.
+//
+// Example:
+//
+//	s, err := odatasql.SimplifyFilter("not not (a eq 1 or (a eq 2 or a eq 1))")
+//	// s == "a in (1, 2)"
+func (c *Converter) SimplifyFilter(filter string) (string, error) {
+	return c.SimplifyFilterContext(context.Background(), filter)
+}
+
+// SimplifyFilterContext is like SimplifyFilter, passing ctx to the converter's Authorizer.
+func (c *Converter) SimplifyFilterContext(ctx context.Context, filter string) (string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", nil
+	}
+
+	node, err := c.parse(ctx, filter, nil, true)
+	if err != nil {
+		return "", err
+	}
+	return ast.Format(c.simplify(node)), nil
+}
+
+// simplify applies ast.Simplify with the nullability of the schema's properties.
+func (c *Converter) simplify(node ast.Node) ast.Node {
+	opts := ast.SimplifyOptions{MaxInValues: c.limits.MaxInValues}
+	if opts.MaxInValues == 0 {
+		opts.MaxInValues = DefaultLimits().MaxInValues
+	}
+	if c.schema != nil {
+		opts.NonNull = func(field string) bool {
+			p, ok := c.schema.Property(field)
+			return ok && p.NotNull
+		}
+	}
+	return ast.Simplify(node, opts)
+}
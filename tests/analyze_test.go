@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeFilter(t *testing.T) {
+	t.Parallel()
+
+	info, err := odatasql.AnalyzeFilter(
+		"firstName eq 'Al' and (age gt 18 or age le 5) and status in ('a', 'b') and status eq 'a' " +
+			"and not vip eq true and contains(firstName, 'x') and email ne null and tags/any(t: t eq 'red')")
+	require.NoError(t, err)
+
+	expected := []odatasql.FieldInfo{
+		{Field: "firstName", Column: "first_name", Operators: []string{"eq", "contains"}, Values: []any{"Al", "x"}},
+		{Field: "age", Column: "age", Operators: []string{"gt", "le"}, Values: []any{int64(18), int64(5)}},
+		{Field: "status", Column: "status", Operators: []string{"in", "eq"}, Values: []any{"a", "b"}, EqualityOnly: true},
+		{Field: "vip", Column: "vip", Operators: []string{"eq"}, Values: []any{true}},
+		{Field: "email", Column: "email", Operators: []string{"ne"}, Values: []any{nil}},
+		{Field: "tags", Column: "tags", Operators: []string{"any", "eq"}, Values: []any{"red"}},
+	}
+	assert.Equal(t, expected, info.Fields)
+
+	f, ok := info.Field("status")
+	require.True(t, ok)
+	assert.True(t, f.EqualityOnly)
+	_, ok = info.Field("missing")
+	assert.False(t, ok)
+
+	info, err = odatasql.AnalyzeFilter("")
+	require.NoError(t, err)
+	assert.Empty(t, info.Fields)
+
+	_, err = odatasql.AnalyzeFilter("age gt")
+	assert.Error(t, err)
+}
+
+func TestAnalyzeFilter_Converter(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "age", Column: "u.user_age", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "price", Type: odatasql.EdmDouble},
+		odatasql.Property{Name: "maskedEmail", Type: odatasql.EdmString},
+		odatasql.Property{Name: "email", Type: odatasql.EdmString},
+	)
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(
+		odatasql.WithSchema(schema),
+		odatasql.WithAuthorizer(odatasql.AuthorizerFunc(func(_ context.Context, a odatasql.FieldAccess) odatasql.FieldDecision {
+			if a.Field == "email" {
+				return odatasql.RewriteField("maskedEmail")
+			}
+			return odatasql.AllowField()
+		})),
+	)
+
+	info, err := conv.AnalyzeFilter("age eq 3 and not (price eq 1.5) and email eq 'x'")
+	require.NoError(t, err)
+	assert.Equal(t, []odatasql.FieldInfo{
+		{Field: "age", Column: "u.user_age", Operators: []string{"eq"}, Values: []any{int64(3)}, EqualityOnly: true},
+		{Field: "price", Column: "price", Operators: []string{"eq"}, Values: []any{1.5}},
+		{Field: "maskedEmail", Column: "masked_email", Operators: []string{"eq"}, Values: []any{"x"}, EqualityOnly: true},
+	}, info.Fields)
+
+	_, err = conv.AnalyzeFilter("unknown eq 1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roleKey struct{}
+
+func newAuthorizedConverter(t *testing.T) *odatasql.Converter {
+	t.Helper()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "name", Type: odatasql.EdmString},
+		odatasql.Property{Name: "email", Type: odatasql.EdmString},
+		odatasql.Property{Name: "salary", Type: odatasql.EdmDecimal},
+		odatasql.Property{Name: "ssn", Type: odatasql.EdmString},
+		odatasql.Property{Name: "ssnLast4", Column: "ssn_last4", Type: odatasql.EdmString},
+	)
+	require.NoError(t, err)
+
+	authorizer := odatasql.AuthorizerFunc(func(ctx context.Context, a odatasql.FieldAccess) odatasql.FieldDecision {
+		role, _ := ctx.Value(roleKey{}).(string)
+		switch {
+		case a.Field == "salary" && role != "manager":
+			return odatasql.DenyField("salary is restricted to managers")
+		case a.Field == "ssn" && role != "hr":
+			return odatasql.RewriteField("ssnLast4")
+		case a.Field == "ssnLast4":
+			return odatasql.DenyField("")
+		case a.Field == "email" && a.Operator != "eq":
+			return odatasql.DenyField("email only supports eq")
+		case a.Field == "name" && role == "broken":
+			return odatasql.RewriteField("name; DROP TABLE users")
+		}
+		return odatasql.AllowField()
+	})
+	return odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithAuthorizer(authorizer))
+}
+
+func TestAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	conv := newAuthorizedConverter(t)
+
+	tests := []struct {
+		name     string
+		role     string
+		input    string
+		expected string
+		wantCode string
+	}{
+		{"Allowed field", "", "name eq 'Bob'", "name = 'Bob'", ""},
+		{"Denied field", "", "name eq 'Bob' or salary gt 100000", "", odatasql.ErrCodeFieldAccessDenied},
+		{"Field allowed for role", "manager", "salary gt 100000", "salary > 100000", ""},
+		{"Rewritten field", "", "ssn eq '1234'", "ssn_last4 = '1234'", ""},
+		{"Field not rewritten for role", "hr", "ssn eq '123-45-1234'", "ssn = '123-45-1234'", ""},
+		{"Rewrite target denied directly", "hr", "ssnLast4 eq '1234'", "", odatasql.ErrCodeFieldAccessDenied},
+		{"Operator allowed", "", "email eq 'a@b.c'", "email = 'a@b.c'", ""},
+		{"Operator denied", "", "email in ('a@b.c')", "", odatasql.ErrCodeFieldAccessDenied},
+		{"Invalid rewrite", "broken", "name eq 'Bob'", "", odatasql.ErrCodeInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.WithValue(context.Background(), roleKey{}, tt.role)
+			sql, err := conv.FilterToSQLContext(ctx, tt.input)
+			if tt.wantCode != "" {
+				var filterErr *odatasql.Error
+				require.True(t, errors.As(err, &filterErr), "FilterToSQLContext(%q) expected an *odatasql.Error, got %v", tt.input, err)
+				assert.Equal(t, tt.wantCode, filterErr.Code)
+				return
+			}
+
+			require.NoError(t, err, "FilterToSQLContext(%q) did not expect an error", tt.input)
+			assert.Equal(t, tt.expected, sql)
+		})
+	}
+}
+
+func TestAuthorizer_RewriteKeyword(t *testing.T) {
+	t.Parallel()
+
+	// Without a schema, only the parser's field rules stand between a rewrite and the SQL.
+	conv := odatasql.NewConverter(odatasql.WithAuthorizer(odatasql.AuthorizerFunc(func(_ context.Context, a odatasql.FieldAccess) odatasql.FieldDecision {
+		if a.Field == "name" {
+			return odatasql.RewriteField("Select")
+		}
+		return odatasql.AllowField()
+	})))
+
+	_, err := conv.FilterToSQL("name eq 'Bob'")
+	var filterErr *odatasql.Error
+	require.True(t, errors.As(err, &filterErr), "expected an *odatasql.Error, got %v", err)
+	assert.Equal(t, odatasql.ErrCodeInvalidFilter, filterErr.Code)
+	assert.Contains(t, filterErr.Message, "reserved SQL keyword")
+}
+
+func TestAuthorizer_Middleware(t *testing.T) {
+	t.Parallel()
+
+	conv := newAuthorizedConverter(t)
+	handler := conv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		role   string
+		filter string
+		status int
+	}{
+		{"manager", "salary gt 1", http.StatusNoContent},
+		{"", "salary gt 1", http.StatusForbidden},
+		{"", "salary gt", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/?"+url.Values{"$filter": {tt.filter}}.Encode(), nil)
+		req = req.WithContext(context.WithValue(req.Context(), roleKey{}, tt.role))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tt.status, rec.Code, "role %q, filter %q", tt.role, tt.filter)
+	}
+}
@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type builderStatus string
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	f := odatasql.Field
+	tests := []struct {
+		name     string
+		expr     odatasql.Expr
+		expected string
+	}{
+		{"empty", odatasql.Expr{}, ""},
+		{"eq string", f("name").Eq("O'Brien"), "name eq 'O''Brien'"},
+		{"ne null", f("email").Ne(nil), "email ne null"},
+		{"gt int", f("age").Gt(18), "age gt 18"},
+		{"ge float", f("score").Ge(4.5), "score ge 4.5"},
+		{"lt uint", f("count").Lt(uint8(3)), "count lt 3"},
+		{"le bool", f("vip").Le(true), "vip le true"},
+		{"named type", f("status").Eq(builderStatus("open")), "status eq 'open'"},
+		{"pointer", f("age").Eq(ptr(30)), "age eq 30"},
+		{"nil pointer", f("age").Eq((*int)(nil)), "age eq null"},
+		{"time", f("createdAt").Gt(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)), "createdAt gt '2024-03-01T10:00:00Z'"},
+		{"json number", f("price").Eq(json.Number("9.99")), "price eq 9.99"},
+		{"uuid", f("id").Eq([16]byte{0x12, 0x34, 15: 0xff}), "id eq '12340000-0000-0000-0000-0000000000ff'"},
+		{"in", f("status").In("new", "open"), "status in ('new', 'open')"},
+		{"functions", f("name").StartsWith("A").Or(f("name").Contains("b"), f("name").EndsWith("c")),
+			"startswith(name, 'A') or contains(name, 'b') or endswith(name, 'c')"},
+		{"and chain", f("a").Eq(1).And(f("b").Eq(2), f("c").Eq(3)), "a eq 1 and b eq 2 and c eq 3"},
+		{"or inside and", f("a").Eq(1).Or(f("b").Eq(2)).And(f("c").Eq(3)), "(a eq 1 or b eq 2) and c eq 3"},
+		{"and inside or", f("a").Eq(1).Or(f("b").Eq(2).And(f("c").Eq(3))), "a eq 1 or b eq 2 and c eq 3"},
+		{"nested and", f("a").Eq(1).And(f("b").Eq(2).And(f("c").Eq(3))), "a eq 1 and (b eq 2 and c eq 3)"},
+		{"not", odatasql.Not(f("a").Eq(1).Or(f("b").Eq(2))), "not (a eq 1 or b eq 2)"},
+		{"empty operands", odatasql.Expr{}.And(f("a").Eq(1), odatasql.Expr{}), "a eq 1"},
+		{"not empty", odatasql.Not(odatasql.Expr{}), ""},
+		{"any", f("tags").Any(f("tags").Eq("red")), "tags/any(x: x eq 'red')"},
+		{"any empty", f("tags").Any(), "tags/any()"},
+		{"all nested", f("lines").All(f("lines/price").Gt(10), f("lines/labels").Any(f("lines/labels").Ne("x"), f("lines/qty").Lt(2))),
+			"lines/all(x: x/price gt 10 and x/labels/any(x1: x1 ne 'x' and x/qty lt 2))"},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, tt.expr.Err(), tt.name)
+		assert.Equal(t, tt.expected, tt.expr.String(), tt.name)
+
+		// The text of an expression parses into the same expression.
+		canonical, err := odatasql.FormatFilter(tt.expected)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, canonical, tt.name)
+	}
+}
+
+func TestBuilder_ExprToSQL(t *testing.T) {
+	t.Parallel()
+
+	f := odatasql.Field
+	expr := f("age").Gt(18).And(f("status").In("a", "b"))
+
+	sql, err := odatasql.ExprToSQL(expr)
+	require.NoError(t, err)
+	assert.Equal(t, "age > 18 AND status IN ('a', 'b')", sql)
+
+	want, err := odatasql.FilterToSQL("age gt 18 and status in ('a', 'b')")
+	require.NoError(t, err)
+	assert.Equal(t, want, sql)
+
+	sql, err = odatasql.ExprToSQL(odatasql.Expr{})
+	require.NoError(t, err)
+	assert.Empty(t, sql)
+
+	schema, err := odatasql.NewSchema(odatasql.Property{Name: "age", Column: "user_age", Type: odatasql.EdmInt32})
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithDialect(odatasql.DialectPostgres))
+
+	sql, err = conv.ExprToSQL(odatasql.Not(f("age").Lt(18)))
+	require.NoError(t, err)
+	assert.Equal(t, `NOT "user_age" < 18`, sql)
+
+	for _, expr := range []odatasql.Expr{
+		f("age").Eq("x"),              // wrong type for the schema
+		f("name").Eq("x"),             // unknown property
+		f("age").Eq(make(chan int)),   // unsupported value
+		f("age").In(),                 // no values
+		f("age").In(1, nil),           // null in list
+		f("age").Eq(math.NaN()),       // non-finite number
+		f("tags").All(),               // all without predicate
+		f("tags").Any(f("age").Eq(1)), // lambda operators have no SQL rendering
+		f("age").Gt(1).And(f("x").Eq(func() {})),
+	} {
+		_, err := conv.ExprToSQL(expr)
+		assert.Error(t, err, expr.String())
+	}
+}
+
+func TestBuilder_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// Expressions converted by ExprToSQL convert the same way from their text, and values the
+	// parser rejects are rejected by the builder too.
+	f := odatasql.Field
+	tests := []struct {
+		name  string
+		expr  odatasql.Expr
+		valid bool
+	}{
+		{"string", f("name").Eq("O'Brien"), true},
+		{"json number", f("price").Lt(json.Number("-1.5e3")), true},
+		{"nested", f("a").Eq(1).And(f("b").Eq(2).And(odatasql.Not(f("c").In("x", "y")))), true},
+		{"function", f("name").Contains("50%"), true},
+		{"statement separator", f("name").Eq("a; --"), false},
+		{"comment", f("name").StartsWith("/* x"), false},
+		{"reserved keyword", f("name").Eq("select"), false},
+		{"reserved keyword in list", f("name").In("a", "DROP"), false},
+		{"NaN", f("age").Eq(json.Number("NaN")), false},
+		{"infinity", f("age").Eq(json.Number("Inf")), false},
+		{"plus sign", f("age").Eq(json.Number("+1")), false},
+		{"hex float", f("age").Eq(json.Number("0x1p3")), false},
+		{"separator", f("age").Eq(json.Number("1_000")), false},
+	}
+
+	for _, tt := range tests {
+		sql, err := odatasql.ExprToSQL(tt.expr)
+		if !tt.valid {
+			assert.Error(t, err, tt.name)
+			continue
+		}
+		require.NoError(t, err, tt.name)
+		want, err := odatasql.FilterToSQL(tt.expr.String())
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, want, sql, tt.name)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestBuilder_Combine(t *testing.T) {
+	t.Parallel()
+
+	parse := func(filter string) odatasql.Expr {
+		t.Helper()
+		expr, err := odatasql.ParseFilter(filter)
+		require.NoError(t, err, filter)
+		return expr
+	}
+
+	tests := []struct {
+		name     string
+		expr     odatasql.Expr
+		expected string
+		sql      string
+	}{
+		{"empty and", odatasql.And(), "", ""},
+		{"empty filters", odatasql.And(parse(""), parse("  ")), "", ""},
+		{"empty operand", odatasql.And(parse(""), parse("a eq 1")), "a eq 1", "a = 1"},
+		{"and of or", odatasql.And(parse("a eq 1 or b eq 2"), parse("c eq 3")),
+			"(a eq 1 or b eq 2) and c eq 3", "(a = 1 OR b = 2) AND c = 3"},
+		{"or of and", odatasql.Or(parse("a eq 1 and b eq 2"), parse("c eq 3 or d eq 4")),
+			"a eq 1 and b eq 2 or (c eq 3 or d eq 4)", "(a = 1 AND b = 2) OR (c = 3 OR d = 4)"},
+		{"and of and", odatasql.And(parse("a eq 1 and b eq 2"), parse("c eq 3 and d eq 4")),
+			"a eq 1 and b eq 2 and (c eq 3 and d eq 4)", "(a = 1 AND b = 2) AND (c = 3 AND d = 4)"},
+		{"not", odatasql.Not(parse("a eq 1 or (b eq 2)")), "not (a eq 1 or b eq 2)", "NOT (a = 1 OR (b = 2))"},
+		{"not empty", odatasql.Not(parse("")), "", ""},
+		{"with builder", odatasql.And(parse("(name eq 'x')"), odatasql.Field("age").Gt(1)),
+			"name eq 'x' and age gt 1", "(name = 'x') AND age > 1"},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, tt.expr.Err(), tt.name)
+		assert.Equal(t, tt.expected, tt.expr.String(), tt.name)
+
+		sql, err := odatasql.ExprToSQL(tt.expr)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.sql, sql, tt.name)
+	}
+
+	_, err := odatasql.ParseFilter("a eq")
+	assert.Error(t, err)
+}
+
+func TestBuilder_ParseFilterAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	// Fields parsed from filters are authorized when the combination is converted, once.
+	var calls []string
+	conv := odatasql.NewConverter(odatasql.WithAuthorizer(odatasql.AuthorizerFunc(
+		func(_ context.Context, a odatasql.FieldAccess) odatasql.FieldDecision {
+			calls = append(calls, a.Field)
+			switch a.Field {
+			case "salary":
+				return odatasql.DenyField("managers only")
+			case "email":
+				return odatasql.RewriteField("maskedEmail")
+			}
+			return odatasql.AllowField()
+		})))
+
+	user, err := conv.ParseFilter("email eq 'x'")
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+
+	sql, err := conv.ExprToSQL(odatasql.And(user, odatasql.Field("tenant").Eq(1)))
+	require.NoError(t, err)
+	assert.Equal(t, "masked_email = 'x' AND tenant = 1", sql)
+	assert.Equal(t, []string{"email", "tenant"}, calls)
+
+	denied, err := conv.ParseFilter("salary gt 10")
+	require.NoError(t, err)
+	_, err = conv.ExprToSQL(odatasql.Or(user, denied))
+	assert.Error(t, err)
+}
+
+func TestBuilder_FieldValidation(t *testing.T) {
+	t.Parallel()
+
+	f := odatasql.Field
+	for _, path := range []string{"tenantId eq 5 or tenantId", "name; DROP TABLE users", "a//b", "1st", "", "select", "ORDER"} {
+		expr := odatasql.And(f(path).Eq(7), f("tenantId").Eq(5))
+		require.Error(t, expr.Err(), path)
+		assert.Empty(t, expr.String(), path)
+		_, err := odatasql.ExprToSQL(expr)
+		assert.Error(t, err, path)
+
+		for _, built := range []odatasql.Expr{f(path).In(1), f(path).Contains("x"), f(path).Any()} {
+			assert.Error(t, built.Err(), path)
+		}
+	}
+
+	sql, err := odatasql.ExprToSQL(f("address/city").Eq("Paris"))
+	require.NoError(t, err)
+	assert.Equal(t, "address.city = 'Paris'", sql)
+}
+
+func TestBuilder_Limits(t *testing.T) {
+	t.Parallel()
+
+	f := odatasql.Field
+	conv := odatasql.NewConverter(odatasql.WithLimits(odatasql.Limits{MaxInValues: 2, MaxNodes: 12, MaxDepth: 1, MaxLiteralLength: 3}))
+
+	tests := []struct {
+		name string
+		expr odatasql.Expr
+		code string
+	}{
+		{"In values", f("a").In(1, 2, 3), odatasql.ErrCodeMaxInValuesExceeded},
+		{"Nodes", odatasql.And(f("a").Eq(1), f("b").Eq(2), f("c").Eq(3), f("d").Eq(4), f("e").Eq(5), f("f").Eq(6), f("g").Eq(7)), odatasql.ErrCodeMaxNodesExceeded},
+		{"Depth", f("a").Eq(1).And(f("b").Eq(1).Or(f("c").Eq(1).And(f("d").Eq(1).Or(f("e").Eq(1))))), odatasql.ErrCodeMaxDepthExceeded},
+		{"Literal length", f("a").Eq("abcd"), odatasql.ErrCodeLiteralTooLong},
+		{"Function literal length", f("a").StartsWith("abcd"), odatasql.ErrCodeLiteralTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := conv.ExprToSQL(tt.expr)
+			var filterErr *odatasql.Error
+			require.True(t, errors.As(err, &filterErr), "ExprToSQL(%s) expected an *odatasql.Error, got %v", tt.expr, err)
+			assert.Equal(t, tt.code, filterErr.Code)
+		})
+	}
+
+	sql, err := conv.ExprToSQL(f("a").In(1, 2).Or(f("b").Eq("abc")))
+	require.NoError(t, err)
+	assert.Equal(t, "a IN (1, 2) OR b = 'abc'", sql)
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterCache(t *testing.T) {
+	t.Parallel()
+
+	cache := odatasql.NewFilterCache(2)
+	conv := odatasql.NewConverter(odatasql.WithFilterCache(cache))
+	uncached := odatasql.NewConverter()
+
+	for i := 0; i < 3; i++ {
+		sql, err := conv.FilterToSQL("age gt 18")
+		require.NoError(t, err)
+		assert.Equal(t, "age > 18", sql)
+	}
+	assert.Equal(t, odatasql.CacheStats{Hits: 2, Misses: 1, Entries: 1}, cache.Stats())
+
+	// The parsed filter is shared with the other conversions of the converter.
+	match, err := conv.CompileFilter("age gt 18")
+	require.NoError(t, err)
+	ok, err := match(map[string]any{"age": 20})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	opts, err := conv.ParseQueryOptions(url.Values{"$filter": {"age gt 18"}})
+	require.NoError(t, err)
+	assert.Equal(t, "age > 18", opts.Filter)
+	assert.Equal(t, odatasql.CacheStats{Hits: 3, Misses: 2, Entries: 2}, cache.Stats())
+
+	// The least recently used filter is evicted.
+	_, err = conv.FilterToSQL("name eq 'x'")
+	require.NoError(t, err)
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Entries)
+
+	// Errors are not cached, and results match the uncached path.
+	for _, filter := range []string{"age gt", "name eq 'x'", "a eq 1 or b eq 'it''s'"} {
+		want, wantErr := uncached.FilterToSQL(filter)
+		for i := 0; i < 2; i++ {
+			got, err := conv.FilterToSQL(filter)
+			assert.Equal(t, want, got, filter)
+			assert.Equal(t, wantErr, err, filter)
+		}
+	}
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Stats().Entries)
+	assert.Equal(t, odatasql.CacheStats{}, odatasql.NewFilterCache(0).Stats())
+}
+
+func TestFilterCache_Converters(t *testing.T) {
+	t.Parallel()
+
+	// Converters sharing a cache keep their own entries.
+	cache := odatasql.NewFilterCache(0)
+	generic := odatasql.NewConverter(odatasql.WithFilterCache(cache))
+	postgres := odatasql.NewConverter(odatasql.WithFilterCache(cache), odatasql.WithDialect(odatasql.DialectPostgres))
+
+	sql, err := generic.FilterToSQL("firstName eq 'a'")
+	require.NoError(t, err)
+	assert.Equal(t, "first_name = 'a'", sql)
+	sql, err = postgres.FilterToSQL("firstName eq 'a'")
+	require.NoError(t, err)
+	assert.Equal(t, `"first_name" = 'a'`, sql)
+	assert.Equal(t, uint64(2), cache.Stats().Misses)
+
+	// Authorization depends on the request context, so it bypasses the cache.
+	authorized := odatasql.NewConverter(
+		odatasql.WithFilterCache(cache),
+		odatasql.WithAuthorizer(odatasql.AuthorizerFunc(func(ctx context.Context, _ odatasql.FieldAccess) odatasql.FieldDecision {
+			if ctx.Value(adminKey{}) == nil {
+				return odatasql.DenyField("admins only")
+			}
+			return odatasql.AllowField()
+		})),
+	)
+	_, err = authorized.FilterToSQLContext(context.WithValue(context.Background(), adminKey{}, true), "age gt 1")
+	require.NoError(t, err)
+	_, err = authorized.FilterToSQL("age gt 1")
+	assert.Error(t, err)
+	_, err = authorized.ParseFilter("age gt 1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, cache.Stats().Entries)
+}
+
+type adminKey struct{}
+
+func TestFilterCache_Templates(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "name", Type: odatasql.EdmString},
+		odatasql.Property{Name: "tier", Type: odatasql.EdmString, Enum: tierEnum},
+	)
+	require.NoError(t, err)
+	opts := []odatasql.Option{odatasql.WithSchema(schema), odatasql.WithDialect(odatasql.DialectPostgres)}
+	uncached := odatasql.NewConverter(opts...)
+
+	// Filters differing only in their string and number literals share an entry.
+	cache := odatasql.NewFilterCache(0)
+	conv := odatasql.NewConverter(append(opts, odatasql.WithFilterCache(cache))...)
+	for _, filter := range []string{
+		"age gt 18 and name eq 'Bob'",
+		"age gt 21 and name eq 'O''Brien'",
+		"age  gt -1.5e3 and  name eq ''",
+	} {
+		want, err := uncached.FilterToSQL(filter)
+		require.NoError(t, err)
+		got, err := conv.FilterToSQL(filter)
+		require.NoError(t, err, filter)
+		assert.Equal(t, want, got, filter)
+	}
+	assert.Equal(t, odatasql.CacheStats{Hits: 2, Misses: 1, Entries: 1}, cache.Stats())
+
+	// The literals of a filter served from another filter's entry are checked and rendered
+	// like those of an uncached filter.
+	tests := []struct {
+		name          string
+		first, second string
+		shared        bool // whether second is served from the entry of first
+	}{
+		{"In list", "name in ('a', 'b')", "name in ('c', 'it''s')", true},
+		{"Function pattern", "contains(name, 'a') or name eq 'a'", "contains(name, 'b') or name eq 'c'", true},
+		{"Escaped pattern", "endswith(name, 'a')", `endswith(name, '50%_\')`, true},
+		{"Enum member", "tier eq 'Gold'", "tier eq 'Silver'", true},
+		{"Not an enum member", "tier eq 'Gold'", "tier eq 'Platinum'", false},
+		{"Type mismatch", "age gt 1 or name eq 'a'", "age gt 1 or name eq 2", false},
+		{"Reserved keyword", "name eq 'a'", "name eq 'select'", false},
+		{"Banned pattern", "name in ('a', 'b')", "name in ('a', 'b;c')", false},
+		{"Identifier value", "name eq Bob and age gt 1", "name eq Bob and age gt 2", false},
+		{"Null literal", "name eq null and age gt 1", "name eq null and age gt 2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := odatasql.NewFilterCache(0)
+			conv := odatasql.NewConverter(append(opts, odatasql.WithFilterCache(cache))...)
+			_, err := conv.FilterToSQL(tt.first)
+			require.NoError(t, err)
+
+			want, wantErr := uncached.FilterToSQL(tt.second)
+			got, err := conv.FilterToSQL(tt.second)
+			assert.Equal(t, want, got)
+			assert.Equal(t, wantErr, err)
+			assert.Equal(t, tt.shared, cache.Stats().Hits == 1)
+		})
+	}
+}
+
+func TestFilterCache_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	cache := odatasql.NewFilterCache(8)
+	conv := odatasql.NewConverter(odatasql.WithFilterCache(cache))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				n := i % 16
+				sql, err := conv.FilterToSQL(fmt.Sprintf("age eq %d", n))
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("age = %d", n), sql)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(8*200), stats.Hits+stats.Misses)
+	assert.LessOrEqual(t, stats.Entries, 8)
+}
+
+// benchmarkFilters are the distinct filters a hot endpoint receives.
+var benchmarkFilters = func() []string {
+	filters := make([]string, 64)
+	for i := range filters {
+		filters[i] = fmt.Sprintf("(status eq 'active' or status eq 'pending') and age ge %d and contains(name, 'a%d')", i, i)
+	}
+	return filters
+}()
+
+func BenchmarkFilterToSQL(b *testing.B) {
+	conv := odatasql.NewConverter(odatasql.WithDialect(odatasql.DialectPostgres))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := conv.FilterToSQL(benchmarkFilters[i%len(benchmarkFilters)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFilterToSQL_Cached(b *testing.B) {
+	conv := odatasql.NewConverter(
+		odatasql.WithDialect(odatasql.DialectPostgres),
+		odatasql.WithFilterCache(odatasql.NewFilterCache(len(benchmarkFilters))),
+	)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := conv.FilterToSQL(benchmarkFilters[i%len(benchmarkFilters)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFilterToSQL_CachedParallel(b *testing.B) {
+	conv := odatasql.NewConverter(
+		odatasql.WithDialect(odatasql.DialectPostgres),
+		odatasql.WithFilterCache(odatasql.NewFilterCache(len(benchmarkFilters))),
+	)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, err := conv.FilterToSQL(benchmarkFilters[i%len(benchmarkFilters)]); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upperNaming struct{}
+
+func (upperNaming) ColumnName(property string) string { return strings.ToUpper(property) }
+
+func TestConverter_FilterToSQL(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "name", Type: odatasql.EdmString},
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "isActive", Type: odatasql.EdmBoolean},
+		odatasql.Property{Name: "createdAt", Column: "created_ts", Type: odatasql.EdmDateTimeOffset},
+		odatasql.Property{Name: "tags"},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		opts     []odatasql.Option
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		// --- Defaults ---
+		{"No options", nil, "firstName eq 'Bob' and isActive eq true", "first_name = 'Bob' AND is_active = true", false},
+
+		// --- Dialects ---
+		{"Postgres", []odatasql.Option{odatasql.WithDialect(odatasql.DialectPostgres)}, "firstName eq 'O''Brien' and isActive eq true", `"first_name" = 'O''Brien' AND "is_active" = TRUE`, false},
+		{"MySQL", []odatasql.Option{odatasql.WithDialect(odatasql.DialectMySQL)}, `firstName in ["a\\b", "c"]`, "`first_name` IN ('a\\\\b', 'c')", false},
+		{"SQLite", []odatasql.Option{odatasql.WithDialect(odatasql.DialectSQLite)}, "isActive eq false", `"is_active" = 0`, false},
+		{"SQL Server", []odatasql.Option{odatasql.WithDialect(odatasql.DialectSQLServer)}, "isActive ne true and age gt 3", "[is_active] != 1 AND [age] > 3", false},
+		{"MySQL function", []odatasql.Option{odatasql.WithDialect(odatasql.DialectMySQL)}, `contains(firstName, '_')`, "`first_name` LIKE '%\\\\_%' ESCAPE '\\\\'", false},
+		{"Nil dialect keeps default", []odatasql.Option{odatasql.WithDialect(nil)}, "age gt 3", "age > 3", false},
+
+		// --- Null Handling ---
+		{"Null as value", []odatasql.Option{odatasql.WithNullHandling(odatasql.NullAsValue)}, "deletedAt eq null", "deleted_at = null", false},
+		{"Null eq as IS NULL", []odatasql.Option{odatasql.WithNullHandling(odatasql.NullAsIsNull)}, "deletedAt eq null", "deleted_at IS NULL", false},
+		{"Null ne as IS NOT NULL", []odatasql.Option{odatasql.WithNullHandling(odatasql.NullAsIsNull)}, "deletedAt ne null or age gt 3", "deleted_at IS NOT NULL OR age > 3", false},
+
+		// --- Naming Strategy ---
+		{"Custom naming", []odatasql.Option{odatasql.WithNamingStrategy(upperNaming{})}, "firstName eq 'Bob'", "FIRSTNAME = 'Bob'", false},
+		{"Nil naming keeps default", []odatasql.Option{odatasql.WithNamingStrategy(nil)}, "firstName eq 'Bob'", "first_name = 'Bob'", false},
+
+		// --- Schema ---
+		{"Schema known fields", []odatasql.Option{odatasql.WithSchema(schema)}, "name eq 'Bob' and age ge 18", "name = 'Bob' AND age >= 18", false},
+		{"Schema column mapping", []odatasql.Option{odatasql.WithSchema(schema)}, "createdAt gt 2024-01-01", "created_ts > '2024-01-01'", false},
+		{"Schema naming fallback", []odatasql.Option{odatasql.WithSchema(schema), odatasql.WithDialect(odatasql.DialectPostgres)}, "isActive eq true", `"is_active" = TRUE`, false},
+		{"Schema null for any type", []odatasql.Option{odatasql.WithSchema(schema)}, "age eq null", "age = null", false},
+		{"Schema untyped property", []odatasql.Option{odatasql.WithSchema(schema)}, "tags in ('a', 1)", "tags IN ('a', 1)", false},
+		{"Schema IN values typed", []odatasql.Option{odatasql.WithSchema(schema)}, "age in (1, 2)", "age IN (1, 2)", false},
+		{"Schema unknown field", []odatasql.Option{odatasql.WithSchema(schema)}, "salary gt 10", "", true},
+		{"Schema field is case-sensitive", []odatasql.Option{odatasql.WithSchema(schema)}, "Name eq 'Bob'", "", true},
+		{"Schema number for string", []odatasql.Option{odatasql.WithSchema(schema)}, "name eq 42", "", true},
+		{"Schema string for number", []odatasql.Option{odatasql.WithSchema(schema)}, "age eq 'old'", "", true},
+		{"Schema string in numeric IN", []odatasql.Option{odatasql.WithSchema(schema)}, "age in (1, 'two')", "", true},
+		{"Schema string for boolean", []odatasql.Option{odatasql.WithSchema(schema)}, "isActive eq 'yes'", "", true},
+
+		{"Schema string function", []odatasql.Option{odatasql.WithSchema(schema)}, "startswith(name, 'B')", `name LIKE 'B%' ESCAPE '\'`, false},
+		{"Schema string function on number", []odatasql.Option{odatasql.WithSchema(schema)}, "contains(age, '1')", "", true},
+
+		// --- Limits ---
+		{"Limits option", []odatasql.Option{odatasql.WithLimits(odatasql.Limits{MaxInValues: 1})}, "age in (1, 2)", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, err := odatasql.NewConverter(tt.opts...).FilterToSQL(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err, "FilterToSQL(%q) expected error", tt.input)
+				return
+			}
+
+			require.NoError(t, err, "FilterToSQL(%q) did not expect an error", tt.input)
+			assert.Equal(t, tt.expected, sql, "FilterToSQL(%q) = %q, want %q", tt.input, sql, tt.expected)
+		})
+	}
+}
+
+func TestConverter_SchemaErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(odatasql.Property{Name: "age", Type: odatasql.EdmInt32})
+	require.NoError(t, err)
+
+	_, err = odatasql.NewConverter(odatasql.WithSchema(schema)).FilterToSQL("age gt 1 and salary gt 10")
+
+	var oerr *odatasql.Error
+	require.True(t, errors.As(err, &oerr), "error %v is not an *odatasql.Error", err)
+	assert.Equal(t, odatasql.ErrCodeInvalidFilter, oerr.Code)
+	assert.Equal(t, 13, oerr.Position)
+}
+
+func TestNewSchema_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		properties []odatasql.Property
+	}{
+		{"Empty name", []odatasql.Property{{Name: ""}}},
+		{"Duplicate name", []odatasql.Property{{Name: "a"}, {Name: "a"}}},
+		{"Unknown type", []odatasql.Property{{Name: "a", Type: "Edm.Unknown"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := odatasql.NewSchema(tt.properties...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConverter_ConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithDialect(odatasql.DialectPostgres))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sql, err := conv.FilterToSQL(fmt.Sprintf("userId eq %d", i))
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf(`"user_id" = %d`, i), sql)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func costSchema(t *testing.T) *odatasql.Schema {
+	t.Helper()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "id", Type: odatasql.EdmInt64, Index: odatasql.BTreeIndex,
+			Stats: &odatasql.ColumnStats{Distinct: 1_000_000}},
+		odatasql.Property{Name: "status", Type: odatasql.EdmString, Index: odatasql.HashIndex,
+			Stats: &odatasql.ColumnStats{Distinct: 4}},
+		odatasql.Property{Name: "name", Type: odatasql.EdmString, Index: odatasql.BTreeIndex,
+			Stats: &odatasql.ColumnStats{Distinct: 1000, NullFraction: 0.5}},
+		odatasql.Property{Name: "email", Type: odatasql.EdmString, Index: odatasql.TrigramIndex},
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "tags", Type: odatasql.EdmString, Index: odatasql.BTreeIndex},
+	)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestEstimateCost(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(
+		odatasql.WithSchema(costSchema(t)),
+		odatasql.WithCostModel(odatasql.CostModel{TableRows: 1000}),
+		odatasql.WithNullHandling(odatasql.NullAsIsNull),
+	)
+	tests := []struct {
+		filter      string
+		selectivity float64
+		cost        float64
+		scan        bool
+		nonSargable []string
+	}{
+		{"", 1, 1000, true, nil},
+		{"id eq 5", 0.000001, 1, false, nil},
+		{"status eq 'a'", 0.25, 250, false, nil},
+		{"status in ('a', 'b')", 0.5, 500, false, nil},
+		{"name eq 'x'", 0.0005, 1, false, nil},
+		{"name eq null", 0.5, 500, false, nil},
+		{"age gt 18", 1.0 / 3, 1000, true, []string{"age gt 18"}},
+		{"id gt 10", 1.0 / 3, 1000.0 / 3, false, nil},
+		{"status gt 'a'", 1.0 / 3, 1000, true, []string{"status gt 'a'"}},
+		{"contains(name, 'x')", 0.05, 1000, true, []string{"contains(name, 'x')"}},
+		{"startswith(name, 'x')", 0.05, 50, false, nil},
+		{"contains(email, 'x')", 0.05, 50, false, nil},
+		{"contains(name, 'x') and status eq 'a'", 0.0125, 250, false, []string{"contains(name, 'x')"}},
+		{"contains(name, 'x') or status eq 'a'", 0.2875, 1000, true, []string{"contains(name, 'x')"}},
+		{"id eq 1 or id eq 2", 0.000002, 2, false, nil},
+		{"not id eq 1", 0.999999, 1000, true, []string{"not id eq 1"}},
+		{"id ne 1", 0.999999, 1000, true, []string{"id ne 1"}},
+		{"tags/any(t: t eq 'a')", 0.5, 1000, true, []string{"tags/any(t: t eq 'a')"}},
+	}
+
+	for _, tt := range tests {
+		est, err := conv.EstimateCost(tt.filter)
+		require.NoError(t, err, "EstimateCost(%q)", tt.filter)
+		assert.InDelta(t, tt.selectivity, est.Selectivity, 1e-9, "selectivity of %q", tt.filter)
+		assert.InDelta(t, tt.selectivity*1000, est.Rows, 1e-6, "rows of %q", tt.filter)
+		assert.InDelta(t, tt.cost, est.Cost, 1e-6, "cost of %q", tt.filter)
+		assert.Equal(t, tt.scan, est.Scan, "scan of %q", tt.filter)
+
+		var predicates []string
+		for _, n := range est.NonSargable {
+			predicates = append(predicates, n.Predicate)
+			assert.NotEmpty(t, n.Reason)
+		}
+		assert.Equal(t, tt.nonSargable, predicates, "non-sargable predicates of %q", tt.filter)
+	}
+
+	est, err := conv.EstimateCost("contains(name, 'x')")
+	require.NoError(t, err)
+	assert.Equal(t, []odatasql.NonSargable{{
+		Predicate: "contains(name, 'x')",
+		Field:     "name",
+		Reason:    "contains matches with a leading wildcard, which a B-tree index cannot serve",
+	}}, est.NonSargable)
+
+	// Without a schema, no column is indexed.
+	est, err = odatasql.EstimateCost("a eq 1")
+	require.NoError(t, err)
+	assert.True(t, est.Scan)
+	assert.Equal(t, float64(odatasql.DefaultTableRows), est.Cost)
+	assert.InDelta(t, 0.005, est.Selectivity, 1e-9)
+
+	_, err = conv.EstimateCost("unknown eq 1")
+	assert.Error(t, err)
+}
+
+func TestEstimateCost_Budget(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(
+		odatasql.WithSchema(costSchema(t)),
+		odatasql.WithCostModel(odatasql.CostModel{TableRows: 1000, MaxCost: 300}),
+	)
+
+	sql, err := conv.FilterToSQL("status eq 'a' and contains(name, 'x')")
+	require.NoError(t, err)
+	assert.Equal(t, `status = 'a' AND name LIKE '%x%' ESCAPE '\'`, sql)
+
+	_, err = conv.FilterToSQL("contains(name, 'x') or id eq 1")
+	var e *odatasql.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeCostExceeded, e.Code)
+	assert.Equal(t,
+		"estimated cost of 1000 rows exceeds the budget of 300; the filter scans the table "+
+			"(contains(name, 'x'): contains matches with a leading wildcard, which a B-tree index cannot serve)",
+		e.Message)
+
+	_, err = conv.FilterToSQL("status in ('a', 'b')")
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, "estimated cost of 500 rows exceeds the budget of 300", e.Message)
+
+	_, err = conv.ParseQueryOptions(url.Values{"$filter": {"age gt 18"}})
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeCostExceeded, e.Code)
+
+	_, err = conv.ExprToSQL(odatasql.Field("age").Eq(3))
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeCostExceeded, e.Code)
+
+	_, err = odatasql.NewSchema(odatasql.Property{Name: "a", Stats: &odatasql.ColumnStats{NullFraction: 2}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,305 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/maxlambrecht/odatasql/csdl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tierEnum = &odatasql.EnumType{Name: "Sales.Tier", Members: []string{"Bronze", "Silver", "Gold"}}
+
+func TestCSDL_LoadSchema(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{"testdata/metadata.xml", "testdata/metadata.json"} {
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			customer, err := csdl.LoadSchema(file, "Customer")
+			require.NoError(t, err)
+			assert.Equal(t, []odatasql.Property{
+				{Name: "ID", Type: odatasql.EdmInt32, NotNull: true},
+				{Name: "Name", Type: odatasql.EdmString, NotNull: true},
+				{Name: "Tier", Type: odatasql.EdmString, Enum: tierEnum},
+				{Name: "HomeAddress/Street", Column: "home_address_street", Type: odatasql.EdmString},
+				{Name: "HomeAddress/ZipCode", Column: "home_address_zip_code", Type: odatasql.EdmString},
+			}, customer.Properties())
+
+			order, err := csdl.LoadSchema(file, "Sales.Order")
+			require.NoError(t, err)
+			assert.Equal(t, []odatasql.Property{
+				{Name: "ID", Type: odatasql.EdmInt32, NotNull: true},
+				{Name: "Total", Type: odatasql.EdmDecimal},
+				{Name: "PlacedAt", Type: odatasql.EdmDateTimeOffset, NotNull: true},
+				{Name: "Customer/ID", Column: "customer.id", Type: odatasql.EdmInt32, NotNull: true},
+				{Name: "Customer/Name", Column: "customer.name", Type: odatasql.EdmString, NotNull: true},
+				{Name: "Customer/Tier", Column: "customer.tier", Type: odatasql.EdmString, Enum: tierEnum},
+				{Name: "Customer/HomeAddress/Street", Column: "customer.home_address_street", Type: odatasql.EdmString},
+				{Name: "Customer/HomeAddress/ZipCode", Column: "customer.home_address_zip_code", Type: odatasql.EdmString},
+			}, order.Properties())
+		})
+	}
+}
+
+func TestCSDL_ColumnMapper(t *testing.T) {
+	t.Parallel()
+
+	mapper := csdl.WithColumnMapper(func(path string) string {
+		if path == "Name" {
+			return "full_name"
+		}
+		return ""
+	})
+	schema, err := csdl.LoadSchema("testdata/metadata.xml", "Customer", mapper)
+	require.NoError(t, err)
+
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema))
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"Mapped column", "Name eq 'Ann'", "full_name = 'Ann'", false},
+		{"Naming fallback", "ID gt 3", "id > 3", false},
+		{"Complex type path", "HomeAddress/ZipCode eq '1000'", "home_address_zip_code = '1000'", false},
+		{"Enum member", "Tier in ('Gold', 'Silver')", "tier IN ('Gold', 'Silver')", false},
+		{"Unknown enum member", "Tier eq 'Platinum'", "", true},
+		{"Collection skipped", "Emails eq 'a@b.c'", "", true},
+		{"Unsupported type skipped", "Photo eq null", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, err := conv.FilterToSQL(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err, "FilterToSQL(%q) expected error", tt.input)
+				return
+			}
+
+			require.NoError(t, err, "FilterToSQL(%q) did not expect an error", tt.input)
+			assert.Equal(t, tt.expected, sql)
+		})
+	}
+}
+
+func TestCSDL_NamingStrategy(t *testing.T) {
+	t.Parallel()
+
+	m, err := csdl.Parse([]byte(`{"Sales": {
+		"Address": {"$Kind": "ComplexType", "ZipCode": {}},
+		"Account": {"$Kind": "EntityType", "AccountNo": {}, "BillingAddress": {"$Type": "Sales.Address"}},
+		"Customer": {"$Kind": "EntityType", "FullName": {}, "MainAccount": {"$Kind": "NavigationProperty", "$Type": "Sales.Account"}},
+		"Order": {"$Kind": "EntityType", "OrderNo": {}, "BillTo": {"$Kind": "NavigationProperty", "$Type": "Sales.Customer"}}
+	}}`))
+	require.NoError(t, err)
+
+	// Multi-level navigation keeps every alias along the path.
+	schema, err := m.Schema("Order")
+	require.NoError(t, err)
+	assert.Equal(t, []odatasql.Property{
+		{Name: "OrderNo", Type: odatasql.EdmString, NotNull: true},
+		{Name: "BillTo/FullName", Column: "bill_to.full_name", Type: odatasql.EdmString, NotNull: true},
+		{Name: "BillTo/MainAccount/AccountNo", Column: "bill_to.main_account.account_no", Type: odatasql.EdmString, NotNull: true},
+		{Name: "BillTo/MainAccount/BillingAddress/ZipCode", Column: "bill_to.main_account.billing_address_zip_code", Type: odatasql.EdmString, NotNull: true},
+	}, schema.Properties())
+
+	// Prefixes, aliases and columns follow the configured naming strategy.
+	schema, err = m.Schema("Order", csdl.WithNamingStrategy(odatasql.Identity))
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithNamingStrategy(odatasql.Identity), odatasql.WithDialect(odatasql.DialectPostgres))
+	sql, err := conv.FilterToSQL("OrderNo eq '1' and BillTo/MainAccount/BillingAddress/ZipCode eq '1000'")
+	require.NoError(t, err)
+	assert.Equal(t, `"OrderNo" = '1' AND "BillTo"."MainAccount"."BillingAddress_ZipCode" = '1000'`, sql)
+}
+
+func TestCSDL_NullablePath(t *testing.T) {
+	t.Parallel()
+
+	m, err := csdl.Parse([]byte(`{"Sales": {
+		"Address": {"$Kind": "ComplexType", "ZipCode": {}},
+		"Customer": {"$Kind": "EntityType", "Name": {}, "Home": {"$Type": "Sales.Address", "$Nullable": true}, "Work": {"$Type": "Sales.Address"}},
+		"Order": {"$Kind": "EntityType", "No": {}, "Buyer": {"$Kind": "NavigationProperty", "$Type": "Sales.Customer", "$Nullable": true},
+			"Seller": {"$Kind": "NavigationProperty", "$Type": "Sales.Customer"}}
+	}}`))
+	require.NoError(t, err)
+
+	// Non-nullable properties below a nullable complex or navigation property may be null.
+	schema, err := m.Schema("Order")
+	require.NoError(t, err)
+	notNull := map[string]bool{}
+	for _, p := range schema.Properties() {
+		notNull[p.Name] = p.NotNull
+	}
+	assert.Equal(t, map[string]bool{
+		"No":                  true,
+		"Buyer/Name":          false,
+		"Buyer/Home/ZipCode":  false,
+		"Buyer/Work/ZipCode":  false,
+		"Seller/Name":         true,
+		"Seller/Home/ZipCode": false,
+		"Seller/Work/ZipCode": true,
+	}, notNull)
+
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema))
+	for field, want := range notNull {
+		tautology, err := conv.Tautology(field + " eq 'a' or " + field + " ne 'a'")
+		require.NoError(t, err)
+		assert.Equal(t, want, tautology, field)
+	}
+}
+
+func TestCSDL_Parse(t *testing.T) {
+	t.Parallel()
+
+	m, err := csdl.LoadFile("testdata/metadata.xml")
+	require.NoError(t, err)
+	require.Len(t, m.Namespaces, 1)
+
+	ns := m.Namespaces[0]
+	assert.Equal(t, "Sales", ns.Name)
+	assert.Equal(t, "self", ns.Alias)
+	assert.Equal(t, []csdl.EnumMember{{Name: "Bronze", Value: 0}, {Name: "Silver", Value: 1}, {Name: "Gold", Value: 10}}, ns.EnumTypes[0].Members)
+	assert.Equal(t, []string{"ID"}, ns.EntityTypes[0].Key)
+	assert.Equal(t, csdl.NavigationProperty{Name: "Orders", Type: "Sales.Order", Collection: true}, ns.EntityTypes[1].NavigationProperties[0])
+}
+
+func TestCSDL_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		document string
+		entity   string
+	}{
+		{"Not CSDL", "hello", "Customer"},
+		{"Malformed XML", "<edmx:Edmx", "Customer"},
+		{"Malformed JSON", `{"Sales": {`, "Customer"},
+		{"Unknown entity", `{"Sales": {"Customer": {"$Kind": "EntityType"}}}`, "Order"},
+		{"Unknown base type", `{"Sales": {"Customer": {"$Kind": "EntityType", "$BaseType": "Sales.Missing"}}}`, "Customer"},
+		{"Unknown navigation type", `{"Sales": {"Customer": {"$Kind": "EntityType", "Boss": {"$Kind": "NavigationProperty", "$Type": "Sales.Missing"}}}}`, "Customer"},
+		{"Ambiguous entity", `{"A": {"Customer": {"$Kind": "EntityType"}}, "B": {"Customer": {"$Kind": "EntityType"}}}`, "Customer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := csdl.Parse([]byte(tt.document))
+			if err == nil {
+				_, err = m.Schema(tt.entity)
+			}
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := csdl.LoadFile("testdata/missing.xml")
+	assert.True(t, err != nil && strings.Contains(err.Error(), "missing.xml"))
+}
+
+func TestCSDL_FromSchema_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	order, err := csdl.LoadSchema("testdata/metadata.xml", "Sales.Order")
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(order))
+
+	m, err := csdl.FromSchema(conv.Schema(), csdl.WithNamespace("Sales"), csdl.WithEntityType("Order"), csdl.WithEntitySet("Orders"))
+	require.NoError(t, err)
+
+	writers := map[string]func(*csdl.Model, *strings.Builder) error{
+		"XML":  func(m *csdl.Model, sb *strings.Builder) error { return m.WriteXML(sb) },
+		"JSON": func(m *csdl.Model, sb *strings.Builder) error { return m.WriteJSON(sb) },
+	}
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			require.NoError(t, write(m, &sb))
+
+			parsed, err := csdl.Parse([]byte(sb.String()))
+			require.NoError(t, err)
+			schema, err := parsed.Schema("Sales.Order")
+			require.NoError(t, err)
+			assert.Equal(t, order.Properties(), schema.Properties())
+
+			require.NotNil(t, parsed.Container)
+			assert.Equal(t, []csdl.EntitySet{{Name: "Orders", EntityType: "Sales.Order"}}, parsed.Container.Sets)
+		})
+	}
+}
+
+func TestCSDL_FromSchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "code", Type: odatasql.EdmString, NotNull: true},
+		odatasql.Property{Name: "id", Type: odatasql.EdmGuid},
+		odatasql.Property{Name: "notes", NonFilterable: true, NonSortable: true},
+		odatasql.Property{Name: "tier", Type: odatasql.EdmString, NotNull: true, Enum: &odatasql.EnumType{Name: "Tier", Members: []string{"Bronze", "Gold"}}},
+		odatasql.Property{Name: "address/city", Column: "address_city", Type: odatasql.EdmString, NotNull: true, NonSortable: true},
+		odatasql.Property{Name: "owner/name", Column: "owner.name", Type: odatasql.EdmString},
+	)
+	require.NoError(t, err)
+
+	m, err := csdl.FromSchema(schema)
+	require.NoError(t, err)
+
+	require.Len(t, m.Namespaces, 1)
+	ns := m.Namespaces[0]
+	assert.Equal(t, "Default", ns.Name)
+	assert.Equal(t, []csdl.EnumType{{Name: "Tier", Members: []csdl.EnumMember{{Name: "Bronze", Value: 0}, {Name: "Gold", Value: 1}}}}, ns.EnumTypes)
+	assert.Equal(t, []csdl.StructuredType{{
+		Name:       "Address",
+		Properties: []csdl.Property{{Name: "city", Type: "Edm.String"}},
+	}}, ns.ComplexTypes)
+	assert.Equal(t, []csdl.StructuredType{{
+		Name:       "Owner",
+		Key:        []string{"name"},
+		Properties: []csdl.Property{{Name: "name", Type: "Edm.String"}},
+	}, {
+		Name: "Entity",
+		Key:  []string{"id"},
+		Properties: []csdl.Property{
+			{Name: "code", Type: "Edm.String"},
+			{Name: "id", Type: "Edm.Guid"},
+			{Name: "notes", Type: "Edm.String", Nullable: true},
+			{Name: "tier", Type: "Default.Tier"},
+			{Name: "address", Type: "Default.Address"},
+		},
+		NavigationProperties: []csdl.NavigationProperty{{Name: "owner", Type: "Default.Owner", Nullable: true}},
+	}}, ns.EntityTypes)
+	assert.Equal(t, &csdl.EntityContainer{
+		Namespace: "Default",
+		Name:      "Container",
+		Sets: []csdl.EntitySet{{
+			Name:          "Entity",
+			EntityType:    "Default.Entity",
+			NonFilterable: []string{"notes"},
+			NonSortable:   []string{"notes", "address/city"},
+		}},
+	}, m.Container)
+
+	for _, write := range []func(*csdl.Model, *strings.Builder) error{
+		func(m *csdl.Model, sb *strings.Builder) error { return m.WriteXML(sb) },
+		func(m *csdl.Model, sb *strings.Builder) error { return m.WriteJSON(sb) },
+	} {
+		var sb strings.Builder
+		require.NoError(t, write(m, &sb))
+		parsed, err := csdl.Parse([]byte(sb.String()))
+		require.NoError(t, err)
+		assert.Equal(t, m.Container, parsed.Container)
+	}
+
+	_, err = csdl.FromSchema(schema, csdl.WithKey("missing"))
+	assert.Error(t, err)
+	_, err = csdl.FromSchema(nil)
+	assert.Error(t, err)
+}
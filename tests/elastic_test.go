@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterToElastic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter   string
+		expected string
+	}{
+		{"", `{"match_all": {}}`},
+		{"status eq 'open'", `{"term": {"status": "open"}}`},
+		{"age ge 18", `{"range": {"age": {"gte": 18}}}`},
+		{"vip ne true", `{"bool": {"filter": [{"exists": {"field": "vip"}}], "must_not": [{"term": {"vip": true}}]}}`},
+		{"email eq null", `{"bool": {"must_not": [{"exists": {"field": "email"}}]}}`},
+		{"email ne null", `{"exists": {"field": "email"}}`},
+		{"address/city eq 'Paris'", `{"term": {"address.city": "Paris"}}`},
+		{"color in ('red', 'blue')", `{"terms": {"color": ["red", "blue"]}}`},
+		{
+			"a eq 1 and (b eq 2 or c lt 3.5) and not (d eq 'x')",
+			`{"bool": {"filter": [
+				{"term": {"a": 1}},
+				{"bool": {"should": [{"term": {"b": 2}}, {"range": {"c": {"lt": 3.5}}}], "minimum_should_match": 1}},
+				{"bool": {"filter": [{"exists": {"field": "d"}}], "must_not": [{"term": {"d": "x"}}]}}
+			]}}`,
+		},
+		{
+			"not (a eq 1 or b in (2, 3))",
+			`{"bool": {"filter": [
+				{"bool": {"filter": [{"exists": {"field": "a"}}], "must_not": [{"term": {"a": 1}}]}},
+				{"bool": {"filter": [{"exists": {"field": "b"}}], "must_not": [{"terms": {"b": [2, 3]}}]}}
+			]}}`,
+		},
+		{
+			"not (a ne 1 and email ne null)",
+			`{"bool": {"should": [
+				{"term": {"a": 1}},
+				{"bool": {"must_not": [{"exists": {"field": "email"}}]}}
+			], "minimum_should_match": 1}}`,
+		},
+		{"not (email eq null)", `{"exists": {"field": "email"}}`},
+		{"not startswith(title, 'Go')", `{"bool": {"filter": [{"exists": {"field": "title"}}], "must_not": [{"prefix": {"title": "Go"}}]}}`},
+		{
+			"not lines/any(l: l/price gt 10)",
+			`{"bool": {"must_not": [{"nested": {"path": "lines", "query": {"range": {"lines.price": {"gt": 10}}}}}]}}`,
+		},
+		{"startswith(title, 'Go*')", `{"prefix": {"title": "Go*"}}`},
+		{"contains(title, 'a?b')", `{"wildcard": {"title": {"value": "*a\\?b*"}}}`},
+		{"endswith(title, 'x\\')", `{"wildcard": {"title": {"value": "*x\\\\"}}}`},
+		{"tags/any(t: t eq 'red')", `{"term": {"tags": "red"}}`},
+		{"tags/any(t: (t in ('a', 'b')))", `{"terms": {"tags": ["a", "b"]}}`},
+		{
+			"lines/any(l: l/price gt 10 and l/product eq 'ink')",
+			`{"nested": {"path": "lines", "query": {"bool": {"filter": [
+				{"range": {"lines.price": {"gt": 10}}},
+				{"term": {"lines.product": "ink"}}
+			]}}}}`,
+		},
+		{
+			"lines/all(l: l/price gt 10)",
+			`{"bool": {"must_not": [{"nested": {"path": "lines", "query": {"bool": {"must_not": [{"range": {"lines.price": {"gt": 10}}}]}}}}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := odatasql.FilterToElastic(tt.filter)
+		require.NoError(t, err, "FilterToElastic(%q)", tt.filter)
+		encoded, err := json.Marshal(got)
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.expected, string(encoded), "FilterToElastic(%q)", tt.filter)
+	}
+}
+
+func TestFilterToElastic_Schema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "title", Type: odatasql.EdmString, FullText: true},
+		odatasql.Property{Name: "status", Column: "state", Type: odatasql.EdmString},
+		odatasql.Property{Name: "tags", Type: odatasql.EdmString},
+		odatasql.Property{Name: "lines/price", Type: odatasql.EdmDecimal},
+	)
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema))
+
+	tests := []struct {
+		filter   string
+		expected string
+	}{
+		{"title eq 'Go in Action'", `{"match_phrase": {"title": "Go in Action"}}`},
+		{"contains(title, 'Action')", `{"match_phrase": {"title": "Action"}}`},
+		{"startswith(title, 'Go i')", `{"match_phrase_prefix": {"title": "Go i"}}`},
+		{
+			"title in ('a', 'b')",
+			`{"bool": {"should": [{"match_phrase": {"title": "a"}}, {"match_phrase": {"title": "b"}}], "minimum_should_match": 1}}`,
+		},
+		{"status eq 'open'", `{"term": {"state": "open"}}`},
+		{"tags/any()", `{"exists": {"field": "tags"}}`},
+		{"lines/any()", `{"nested": {"path": "lines", "query": {"match_all": {}}}}`},
+	}
+	for _, tt := range tests {
+		got, err := conv.FilterToElastic(tt.filter)
+		require.NoError(t, err, "FilterToElastic(%q)", tt.filter)
+		encoded, err := json.Marshal(got)
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.expected, string(encoded), "FilterToElastic(%q)", tt.filter)
+	}
+
+	for _, filter := range []string{
+		"title gt 'a'",
+		"endswith(title, 'x')",
+		"tags/all(t: t eq 'a')",
+		"tags/any(t: t eq 'a' or t eq 'b')",
+		"tags/any(t: t ne 'a')",
+		"missing eq 1",
+	} {
+		_, err := conv.FilterToElastic(filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestFilterToElastic_Negation(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithNullHandling(odatasql.NullAsIsNull))
+
+	var docs []map[string]any
+	for _, a := range []any{nil, int64(1), int64(2), int64(3)} {
+		for _, b := range []any{nil, int64(2), int64(5)} {
+			doc := map[string]any{}
+			if a != nil {
+				doc["a"] = a
+			}
+			if b != nil {
+				doc["b"] = b
+			}
+			docs = append(docs, doc)
+		}
+	}
+
+	// A filter, its simplified form and the SQL semantics of CompileFilter match the same
+	// documents, including those without a field.
+	for _, filter := range []string{
+		"a ne 1 and a ne 2",
+		"not (a eq 1 or b eq 2)",
+		"not (a eq 1 and b ne 2)",
+		"not not (a gt 1)",
+		"not (a in (1, 2) or b eq null)",
+		"not (a eq null) and not (b ne null)",
+		"not (a le 1 or (b eq 2 and not a eq 3))",
+	} {
+		simplified, err := conv.SimplifyFilter(filter)
+		require.NoError(t, err, filter)
+		original, err := conv.FilterToElastic(filter)
+		require.NoError(t, err, filter)
+		rewritten, err := conv.FilterToElastic(simplified)
+		require.NoError(t, err, simplified)
+		match, err := conv.CompileFilter(filter)
+		require.NoError(t, err, filter)
+
+		for _, doc := range docs {
+			want, err := match(doc)
+			require.NoError(t, err)
+			assert.Equal(t, want, elasticMatch(t, original, doc), "%q on %v", filter, doc)
+			assert.Equal(t, want, elasticMatch(t, rewritten, doc), "%q on %v", simplified, doc)
+		}
+	}
+}
+
+// elasticMatch evaluates the bool, term, terms, range and exists queries of FilterToElastic
+// on a document with numeric fields.
+func elasticMatch(t *testing.T, query, doc map[string]any) bool {
+	t.Helper()
+
+	require.Len(t, query, 1)
+	for kind, body := range query {
+		params := body.(map[string]any)
+		switch kind {
+		case "bool":
+			for _, q := range clauses(params["filter"]) {
+				if !elasticMatch(t, q.(map[string]any), doc) {
+					return false
+				}
+			}
+			for _, q := range clauses(params["must_not"]) {
+				if elasticMatch(t, q.(map[string]any), doc) {
+					return false
+				}
+			}
+			if should := clauses(params["should"]); len(should) > 0 {
+				for _, q := range should {
+					if elasticMatch(t, q.(map[string]any), doc) {
+						return true
+					}
+				}
+				return false
+			}
+			return true
+		case "exists":
+			_, ok := doc[params["field"].(string)]
+			return ok
+		}
+
+		require.Len(t, params, 1)
+		for field, value := range params {
+			v, ok := doc[field]
+			if !ok {
+				return false
+			}
+			switch kind {
+			case "term":
+				return fmt.Sprint(v) == fmt.Sprint(value)
+			case "terms":
+				for _, term := range value.([]any) {
+					if fmt.Sprint(v) == fmt.Sprint(term) {
+						return true
+					}
+				}
+				return false
+			case "range":
+				for op, bound := range value.(map[string]any) {
+					n, limit := v.(int64), bound.(int64)
+					if op == "gt" && n <= limit || op == "gte" && n < limit || op == "lt" && n >= limit || op == "lte" && n > limit {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	}
+	t.Fatalf("unsupported query %v", query)
+	return false
+}
+
+// clauses returns the clauses of a bool query occurrence, which may be absent.
+func clauses(occurrence any) []any {
+	c, _ := occurrence.([]any)
+	return c
+}
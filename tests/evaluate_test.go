@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type evalAddress struct {
+	City string `json:"city"`
+}
+
+type evalAudit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type evalUser struct {
+	evalAudit
+	Name    string         `json:"name"`
+	Age     int            `json:"age"`
+	Score   float64        `json:"score"`
+	VIP     bool           `json:"vip"`
+	Email   *string        `json:"email"`
+	Nick    sql.NullString `json:"nick"`
+	Address *evalAddress   `json:"address"`
+	Secret  string         `json:"-"`
+}
+
+func TestCompileFilter(t *testing.T) {
+	t.Parallel()
+
+	email := "alice@example.com"
+	user := evalUser{
+		evalAudit: evalAudit{CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		Name:      "Alice",
+		Age:       30,
+		Score:     4.5,
+		VIP:       true,
+		Email:     &email,
+		Address:   &evalAddress{City: "Paris"},
+	}
+	doc := []byte(`{"name": "Alice", "age": 30, "score": 4.5, "vip": true, "email": "alice@example.com",
+		"nick": null, "address": {"city": "Paris"}, "createdAt": "2024-03-01T12:00:00Z"}`)
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(doc, &m))
+
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{"", true},
+		{"name eq 'Alice'", true},
+		{"name ne 'Alice'", false},
+		{"name gt 'Adam' and name lt 'Bob'", true},
+		{"age ge 30 and age lt 31", true},
+		{"age eq 30.0", true},
+		{"score gt 4.25", true},
+		{"vip eq true", true},
+		{"vip eq false or age gt 40", false},
+		{"not (age gt 40)", true},
+		{"name in ('Bob', 'Alice')", true},
+		{"age in (1, 2)", false},
+		{"address/city eq 'Paris'", true},
+		{"contains(email, '@example')", true},
+		{"startswith(name, 'Al') and endswith(name, 'ce')", true},
+		{"startswith(name, 'al')", false},
+		{"email ne null", false},     // unknown with the default NullAsValue, like "email != null" in SQL
+		{"nick eq 'x'", false},       // null compared with a value is unknown
+		{"not (nick eq 'x')", false}, // and so is its negation
+	}
+
+	for _, tt := range tests {
+		match, err := odatasql.CompileFilter(tt.filter)
+		require.NoError(t, err, "CompileFilter(%q)", tt.filter)
+
+		for name, v := range map[string]any{"struct": user, "pointer": &user, "map": m, "JSON": doc, "RawMessage": json.RawMessage(doc)} {
+			got, err := match(v)
+			require.NoError(t, err, "%s: match(%q)", name, tt.filter)
+			assert.Equal(t, tt.expected, got, "%s: match(%q)", name, tt.filter)
+		}
+	}
+
+	// Time values compare with RFC 3339 and date literals.
+	for filter, expected := range map[string]bool{
+		"createdAt gt 2024-03-01T11:00:00Z":        true,
+		"createdAt lt '2024-03-01T13:00:00+02:00'": false,
+		"createdAt ge 2024-03-01":                  true,
+		"createdAt lt 2024-03-02":                  true,
+	} {
+		match, err := odatasql.CompileFilter(filter)
+		require.NoError(t, err)
+		got, err := match(user)
+		require.NoError(t, err, filter)
+		assert.Equal(t, expected, got, filter)
+	}
+}
+
+func TestCompileFilter_NullHandling(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithNullHandling(odatasql.NullAsIsNull))
+	values := []any{
+		evalUser{Name: "Bob"},
+		map[string]any{"name": "Bob"},
+		[]byte(`{"name": "Bob", "email": null}`),
+	}
+
+	for filter, expected := range map[string]bool{
+		"email eq null":                   true,
+		"email ne null":                   false,
+		"nick eq null":                    true,
+		"address/city eq null":            true,
+		"not (email eq 'x')":              false,
+		"email eq null or age gt 1":       true,
+		"email ne null and name eq 'Bob'": false,
+	} {
+		match, err := conv.CompileFilter(filter)
+		require.NoError(t, err)
+		for _, v := range values {
+			got, err := match(v)
+			require.NoError(t, err, "match(%q) on %T", filter, v)
+			assert.Equal(t, expected, got, "match(%q) on %T", filter, v)
+		}
+	}
+}
+
+func TestCompileFilter_Lambda(t *testing.T) {
+	t.Parallel()
+
+	type line struct {
+		Product string   `json:"product"`
+		Price   float64  `json:"price"`
+		Labels  []string `json:"labels"`
+	}
+	type order struct {
+		Tags  []string `json:"tags"`
+		Lines []line   `json:"lines"`
+		Notes []string `json:"notes"`
+	}
+	o := order{
+		Tags: []string{"red", "blue"},
+		Lines: []line{
+			{Product: "pen", Price: 2, Labels: []string{"sale"}},
+			{Product: "ink", Price: 12},
+		},
+	}
+	doc := []byte(`{"tags": ["red", "blue"], "lines": [{"product": "pen", "price": 2, "labels": ["sale"]},
+		{"product": "ink", "price": 12}], "notes": null}`)
+
+	for filter, expected := range map[string]bool{
+		"tags/any(t: t eq 'red')":    true,
+		"tags/any(t:t eq 'green')":   false,
+		"tags/all(t : t ne 'green')": true,
+		"tags/any()":                 true,
+		"notes/any()":                false,
+		"notes/all(n: n eq 'x')":     true,
+		"lines/any(l: l/price gt 10 and l/product eq 'ink')":      true,
+		"lines/all(l: l/price gt 10)":                             false,
+		"lines/any(l: l/labels/any(x: x eq 'sale'))":              true,
+		"lines/all(l: l/labels/any(x: startswith(x, 'sa')))":      false,
+		"not tags/any(t: t in ('green', 'black')) and tags/any()": true,
+	} {
+		match, err := odatasql.CompileFilter(filter)
+		require.NoError(t, err, filter)
+		for name, v := range map[string]any{"struct": o, "JSON": doc} {
+			got, err := match(v)
+			require.NoError(t, err, "%s: match(%q)", name, filter)
+			assert.Equal(t, expected, got, "%s: match(%q)", name, filter)
+		}
+	}
+
+	for _, filter := range []string{
+		"tags/all()",
+		"tags/any(t eq 'red')",
+		"tags/any(t: name eq 'red')",
+		"lines/any(l: l/labels/any(l: l eq 'x'))",
+		"tags/any(t: t eq 'red'",
+	} {
+		_, err := odatasql.CompileFilter(filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestCompileFilter_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := odatasql.CompileFilter("name eq")
+	assert.Error(t, err)
+
+	for _, tt := range []struct {
+		filter string
+		value  any
+	}{
+		{"name eq 1", evalUser{Name: "a"}},
+		{"age eq 'a'", evalUser{}},
+		{"vip gt 1", evalUser{}},
+		{"createdAt gt 'yesterday'", evalUser{}},
+		{"secret eq 'a'", evalUser{}},
+		{"missing eq 'a'", evalUser{}},
+		{"contains(age, '1')", evalUser{}},
+		{"name eq 'a'", []byte(`{`)},
+		{"name eq 'a'", 42},
+	} {
+		match, err := odatasql.CompileFilter(tt.filter)
+		require.NoError(t, err, tt.filter)
+		_, err = match(tt.value)
+		assert.Error(t, err, "match(%q) on %#v", tt.filter, tt.value)
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter   string
+		expected string
+	}{
+		{"", ""},
+		{"  name   EQ  'Bob' ", "name eq 'Bob'"},
+		{"(Age GT 18) AND ((name eq 'Bob'))", "Age gt 18 and name eq 'Bob'"},
+		{"name eq 'O''Brien'", "name eq 'O''Brien'"},
+		{"vip eq TRUE or email eq NULL", "vip eq true or email eq null"},
+		{"createdAt gt 2024-03-01T10:00:00Z", "createdAt gt '2024-03-01T10:00:00Z'"},
+		{"color in ['red',\"blue\"]", "color in ('red', 'blue')"},
+		{"a eq 1 and b eq 2 and c eq 3", "a eq 1 and b eq 2 and c eq 3"},
+		{"a eq 1 and (b eq 2 and c eq 3)", "a eq 1 and (b eq 2 and c eq 3)"},
+		{"(a eq 1 or b eq 2) and c eq 3", "(a eq 1 or b eq 2) and c eq 3"},
+		{"a eq 1 or (b eq 2 and c eq 3)", "a eq 1 or b eq 2 and c eq 3"},
+		{"not (a eq 1)", "not a eq 1"},
+		{"not (a eq 1 and b eq 2)", "not (a eq 1 and b eq 2)"},
+		{"NOT NOT a eq 1", "not not a eq 1"},
+		{"CONTAINS(name,'x')", "contains(name, 'x')"},
+		{"tags/any(t:t eq 'red')", "tags/any(t: t eq 'red')"},
+		{"tags/any()", "tags/any()"},
+		{
+			"lines/all(l : l/price gt 10 and l/labels/any(x:x eq 'a' or l/qty lt 2))",
+			"lines/all(l: l/price gt 10 and l/labels/any(x: x eq 'a' or l/qty lt 2))",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := odatasql.FormatFilter(tt.filter)
+		require.NoError(t, err, "FormatFilter(%q)", tt.filter)
+		assert.Equal(t, tt.expected, got, "FormatFilter(%q)", tt.filter)
+	}
+
+	_, err := odatasql.FormatFilter("name eq")
+	assert.Error(t, err)
+}
+
+func TestFormatFilter_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	filters := []string{
+		"name eq 'Bob' and (age gt 18 or vip eq true)",
+		"((a eq 1) or (b eq 2)) and not (c eq 3 or d eq 4)",
+		"a eq 1 or b eq 2 and c eq 3 or d eq 4",
+		"not (a eq 1 or b eq 2) and not c eq 3",
+		"(a eq 1 and b eq 2) and (c eq 3 and d eq 4)",
+		"status in ('a', 'b') and startswith(name, 'It''s')",
+		"tags/any(t: t in ('red', 'blue')) and score le -1.5e3",
+		"lines/any(l: l/labels/any(x: x eq 'sale') and not (l/price lt 2))",
+	}
+	value := map[string]any{
+		"name": "Bob", "age": 20, "vip": false, "a": 1, "b": 2, "c": 3, "d": 5, "status": "a",
+		"tags": []any{"blue"}, "score": -2000,
+		"lines": []any{map[string]any{"labels": []any{"sale"}, "price": 3}},
+	}
+
+	for _, filter := range filters {
+		canonical, err := odatasql.FormatFilter(filter)
+		require.NoError(t, err, filter)
+
+		// The canonical form is a fixed point.
+		again, err := odatasql.FormatFilter(canonical)
+		require.NoError(t, err, canonical)
+		assert.Equal(t, canonical, again, "FormatFilter(%q)", canonical)
+
+		// It has the same structure and meaning as the original filter.
+		want, err := odatasql.FilterToMongo(filter)
+		require.NoError(t, err)
+		got, err := odatasql.FilterToMongo(canonical)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "%q formatted as %q", filter, canonical)
+
+		wantMatch, err := odatasql.CompileFilter(filter)
+		require.NoError(t, err)
+		gotMatch, err := odatasql.CompileFilter(canonical)
+		require.NoError(t, err)
+		want1, err1 := wantMatch(value)
+		got1, err2 := gotMatch(value)
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		assert.Equal(t, want1, got1, "%q formatted as %q", filter, canonical)
+	}
+}
+
+func TestFormatFilter_Schema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(odatasql.Property{Name: "age", Type: odatasql.EdmInt32})
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema))
+
+	got, err := conv.FormatFilter("AGE  ge 18")
+	require.Error(t, err) // properties are case-sensitive
+	assert.Empty(t, got)
+
+	got, err = conv.FormatFilter("(age ge 18)")
+	require.NoError(t, err)
+	assert.Equal(t, "age ge 18", got)
+}
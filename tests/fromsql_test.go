@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLToExpr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		condition string
+		expected  string
+	}{
+		{"", ""},
+		{"age > 18", "age gt 18"},
+		{"age <> 18 AND score >= -1.5e2", "age ne 18 and score ge -1.5e2"},
+		{"age != 18 or score <= .5", "age ne 18 or score le 0.5"},
+		{"18 < age", "age gt 18"},
+		{"'Bob' = name", "name eq 'Bob'"},
+		{"name = 'O''Brien'", "name eq 'O''Brien'"},
+		{"vip = TRUE", "vip eq true"},
+		{"email IS NULL", "email eq null"},
+		{"email is not null", "email ne null"},
+		{"status IN ('new', 'open')", "status in ('new', 'open')"},
+		{"status NOT IN ('closed')", "not status in ('closed')"},
+		{"age BETWEEN 18 AND 65", "age ge 18 and age le 65"},
+		{"age NOT BETWEEN 18 AND 65", "not (age ge 18 and age le 65)"},
+		{"age BETWEEN 18 AND 65 OR vip = true", "age ge 18 and age le 65 or vip eq true"},
+		{"name LIKE 'Al%'", "startswith(name, 'Al')"},
+		{"name LIKE '%son'", "endswith(name, 'son')"},
+		{"name LIKE '%li%'", "contains(name, 'li')"},
+		{"name LIKE 'Bob'", "name eq 'Bob'"},
+		{"name NOT LIKE '%x%'", "not contains(name, 'x')"},
+		{`code LIKE '50!%%' ESCAPE '!'`, "startswith(code, '50%')"},
+		{"(a = 1 OR b = 2) AND NOT (c = 3)", "(a eq 1 or b eq 2) and not c eq 3"},
+		{"a = 1 OR (b = 2 AND c = 3)", "a eq 1 or b eq 2 and c eq 3"},
+		{`"first_name" = 'Al' AND [last_name] = 'B' AND ` + "`Age` = 3", "first_name eq 'Al' and last_name eq 'B' and Age eq 3"},
+	}
+
+	for _, tt := range tests {
+		expr, err := odatasql.SQLToExpr(tt.condition)
+		require.NoError(t, err, "SQLToExpr(%q)", tt.condition)
+		assert.Equal(t, tt.expected, expr.String(), "SQLToExpr(%q)", tt.condition)
+	}
+}
+
+func TestSQLToExpr_Schema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "firstName", Type: odatasql.EdmString},
+		odatasql.Property{Name: "age", Column: "user_age", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "vip", Type: odatasql.EdmBoolean},
+		odatasql.Property{Name: "city", Column: "addr.city", Type: odatasql.EdmString},
+	)
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithDialect(odatasql.DialectPostgres))
+
+	tests := []struct {
+		condition string
+		expected  string
+	}{
+		{"first_name LIKE 'A%'", "startswith(firstName, 'A')"},
+		{`"USER_AGE" >= 18`, "age ge 18"},
+		{"u.user_age < 65", "age lt 65"},
+		{"addr.city = 'Paris'", "city eq 'Paris'"},
+		{"vip = 1 OR vip <> 0", "vip eq true or vip ne false"},
+	}
+	for _, tt := range tests {
+		expr, err := conv.SQLToExpr(tt.condition)
+		require.NoError(t, err, "SQLToExpr(%q)", tt.condition)
+		assert.Equal(t, tt.expected, expr.String(), "SQLToExpr(%q)", tt.condition)
+	}
+
+	// The expression converts back to SQL through the schema.
+	expr, err := conv.SQLToExpr("user_age BETWEEN 18 AND 65 AND first_name IS NOT NULL")
+	require.NoError(t, err)
+	sql, err := conv.ExprToSQL(expr)
+	require.NoError(t, err)
+	assert.Equal(t, `("user_age" >= 18 AND "user_age" <= 65) AND "first_name" != null`, sql)
+
+	for _, condition := range []string{
+		"last_name = 'x'",   // unknown column
+		"user_age = 'old'",  // wrong type for the schema
+		"first_name > 18.5", // wrong type for the schema
+	} {
+		_, err := conv.SQLToExpr(condition)
+		assert.Error(t, err, condition)
+	}
+}
+
+func TestSQLToExpr_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		condition string
+		code      string
+		position  int
+	}{
+		{"function call", "lower(name) = 'bob'", odatasql.ErrCodeUnsupportedSQL, 5},
+		{"column comparison", "a = b", odatasql.ErrCodeUnsupportedSQL, 4},
+		{"arithmetic", "age + 1 > 18", odatasql.ErrCodeUnsupportedSQL, 4},
+		{"placeholder", "age > ?", odatasql.ErrCodeUnsupportedSQL, 6},
+		{"subquery", "id IN (SELECT id FROM t)", odatasql.ErrCodeUnsupportedSQL, 7},
+		{"exists", "EXISTS (SELECT 1)", odatasql.ErrCodeUnsupportedSQL, 7},
+		{"comment", "a = 1 -- x", odatasql.ErrCodeUnsupportedSQL, 6},
+		{"statement separator", "a = 1; DROP TABLE t", odatasql.ErrCodeUnsupportedSQL, 5},
+		{"underscore wildcard", "name LIKE 'a_c'", odatasql.ErrCodeUnsupportedSQL, 10},
+		{"inner wildcard", "name LIKE 'a%c'", odatasql.ErrCodeUnsupportedSQL, 10},
+		{"null in list", "a IN (1, NULL)", odatasql.ErrCodeUnsupportedSQL, 13},
+		{"unclosed quote", "name = 'bob", odatasql.ErrCodeUnsupportedSQL, 7},
+		{"missing parenthesis", "(a = 1", odatasql.ErrCodeUnsupportedSQL, 6},
+		{"trailing input", "a = 1 b = 2", odatasql.ErrCodeUnsupportedSQL, 6},
+		{"missing operand", "a = 1 AND", odatasql.ErrCodeUnsupportedSQL, 9},
+		{"unsupported operator", "a == 1", odatasql.ErrCodeUnsupportedSQL, 3},
+		{"equal to null", "age = NULL", odatasql.ErrCodeUnsupportedSQL, 4},
+		{"not equal to null", "age <> NULL", odatasql.ErrCodeUnsupportedSQL, 4},
+		{"ordered with null", "age > NULL", odatasql.ErrCodeUnsupportedSQL, 4},
+		{"null on the left", "NULL = age", odatasql.ErrCodeUnsupportedSQL, 5},
+		{"null bound", "age BETWEEN NULL AND 65", odatasql.ErrCodeUnsupportedSQL, 12},
+		{"qualified column without schema", "u.age = 5", odatasql.ErrCodeUnsupportedSQL, 0},
+	}
+
+	for _, tt := range tests {
+		_, err := odatasql.SQLToExpr(tt.condition)
+		require.Error(t, err, tt.name)
+
+		var e *odatasql.Error
+		require.True(t, errors.As(err, &e), tt.name)
+		assert.Equal(t, tt.code, e.Code, tt.name)
+		assert.Equal(t, tt.position, e.Position, tt.name)
+	}
+}
+
+func TestSQLToExpr_Limits(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithLimits(odatasql.Limits{MaxDepth: 2, MaxInValues: 2}))
+
+	_, err := conv.SQLToExpr("(((a = 1)))")
+	var e *odatasql.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeMaxDepthExceeded, e.Code)
+
+	_, err = conv.SQLToExpr("a IN (1, 2, 3)")
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeMaxInValuesExceeded, e.Code)
+}
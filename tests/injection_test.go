@@ -45,10 +45,17 @@ func TestFilterToSQL_Injection(t *testing.T) {
 		{"Empty IN List", "color in ()"},
 		{"IN with Boolean", "color in (true, false)"},
 		{"IN with NULL", "color in (null, 'red')"},
+		{"JSON IN with SQL Comment", `color in ["red'; --"]`},
+		{"JSON IN with Escaped Comment", `color in ["red\u002f*"]`},
+		{"JSON IN with null", `color in [null, "red"]`},
 
 		// --- SQL Keyword Manipulation ---
 		{"Quoted Field Name", "'name' eq 'Alice'"},
 		{"SQL Keyword as Field", "SELECT eq 'Alice'"},
+		{"Comment in Field Name", "name/**/ eq 'Alice'"},
+		{"Operator in Field Name", "name=name eq 'Alice'"},
+		{"Dash in Field Name", "na-me eq 'Alice'"},
+		{"Empty Path Segment", "address//city eq 'Paris'"},
 
 		// --- Excessive Nesting Attacks ---
 		{"Excessive Nesting", "(((((((((((name eq 'Alice')))))))))))"},
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterToSQLWithLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		limits   odatasql.Limits
+		wantCode string
+	}{
+		// --- Within Limits ---
+		{"Default limits", "name eq 'Bob'", odatasql.Limits{}, ""},
+		{"Depth at limit", "((name eq 'Bob'))", odatasql.Limits{MaxDepth: 2}, ""},
+		{"Nodes at limit", "a eq 1 and b eq 2", odatasql.Limits{MaxNodes: 3}, ""},
+		{"IN values at limit", "a in (1, 2, 3)", odatasql.Limits{MaxInValues: 3}, ""},
+		{"Literal at limit", "name eq 'abcd'", odatasql.Limits{MaxLiteralLength: 4}, ""},
+		{"Unescaped literal at limit", "name eq 'O''B'", odatasql.Limits{MaxLiteralLength: 3}, ""},
+		{"Filter at limit", "a eq 1", odatasql.Limits{MaxFilterLength: 6}, ""},
+		{"Function arguments at limit", "contains(name, 'x')", odatasql.Limits{MaxFunctionArgs: 2}, ""},
+		{"Disabled depth limit", strings.Repeat("(", 20) + "a eq 1" + strings.Repeat(")", 20), odatasql.Limits{MaxDepth: -1}, ""},
+
+		// --- Exceeding Limits ---
+		{"Depth exceeded", "(((name eq 'Bob')))", odatasql.Limits{MaxDepth: 2}, odatasql.ErrCodeMaxDepthExceeded},
+		{"Default depth exceeded", strings.Repeat("(", 11) + "a eq 1" + strings.Repeat(")", 11), odatasql.Limits{}, odatasql.ErrCodeMaxDepthExceeded},
+		{"Nodes exceeded", "a eq 1 and b eq 2 and c eq 3", odatasql.Limits{MaxNodes: 4}, odatasql.ErrCodeMaxNodesExceeded},
+		{"Nodes exceeded by NOT chain", strings.Repeat("not ", 10) + "a eq 1", odatasql.Limits{MaxNodes: 5}, odatasql.ErrCodeMaxNodesExceeded},
+		{"IN values exceeded", "a in (1, 2, 3, 4)", odatasql.Limits{MaxInValues: 3}, odatasql.ErrCodeMaxInValuesExceeded},
+		{"JSON IN values exceeded", `a in ["x", "y"]`, odatasql.Limits{MaxInValues: 1}, odatasql.ErrCodeMaxInValuesExceeded},
+		{"Literal exceeded", "name eq 'abcde'", odatasql.Limits{MaxLiteralLength: 4}, odatasql.ErrCodeLiteralTooLong},
+		{"JSON literal exceeded", `name in ["abcde"]`, odatasql.Limits{MaxLiteralLength: 4}, odatasql.ErrCodeLiteralTooLong},
+		{"Filter exceeded", "a eq 10", odatasql.Limits{MaxFilterLength: 6}, odatasql.ErrCodeFilterTooLong},
+		{"Function arguments exceeded", "contains(name, 'x')", odatasql.Limits{MaxFunctionArgs: 1}, odatasql.ErrCodeMaxFunctionArgsExceeded},
+		{"Default function arguments exceeded", "contains(name, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h')", odatasql.Limits{}, odatasql.ErrCodeMaxFunctionArgsExceeded},
+		{"Syntax error", "a xx 1", odatasql.Limits{}, odatasql.ErrCodeInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := odatasql.FilterToSQLWithLimits(tt.input, tt.limits)
+			if tt.wantCode == "" {
+				assert.NoError(t, err, "FilterToSQLWithLimits(%q) did not expect an error", tt.input)
+				return
+			}
+
+			var oerr *odatasql.Error
+			require.True(t, errors.As(err, &oerr), "FilterToSQLWithLimits(%q) error %v is not an *odatasql.Error", tt.input, err)
+			assert.Equal(t, tt.wantCode, oerr.Code, "FilterToSQLWithLimits(%q) error code", tt.input)
+		})
+	}
+}
+
+func TestFilterToSQL_ErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		position int
+	}{
+		{"Invalid operator", "name xx 'Bob'", 5},
+		{"Missing value", "name eq", 7},
+		{"Unclosed string", "name eq 'Bob", 8},
+		{"Invalid IN value", "a in (1, null)", 9},
+		{"Leading whitespace", "  name xx 'Bob'", 7},
+		{"Lone parenthesis", "(", 1},
+		{"Lambda operator", "vip eq true and tags/any(t: t eq 'red')", 16},
+		{"Function arguments", "contains(name, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h')", 48},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := odatasql.FilterToSQL(tt.input)
+
+			var oerr *odatasql.Error
+			require.True(t, errors.As(err, &oerr), "FilterToSQL(%q) error %v is not an *odatasql.Error", tt.input, err)
+			assert.Equal(t, tt.position, oerr.Position, "FilterToSQL(%q) error position", tt.input)
+		})
+	}
+}
+
+func TestParseQueryOptionsWithLimits(t *testing.T) {
+	t.Parallel()
+
+	query := url.Values{"$filter": {"status in @s"}, "@s": {"['a','b','c']"}}
+
+	_, err := odatasql.ParseQueryOptionsWithLimits(query, odatasql.Limits{MaxInValues: 2})
+
+	var oerr *odatasql.Error
+	require.True(t, errors.As(err, &oerr), "error %v is not an *odatasql.Error", err)
+	assert.Equal(t, odatasql.ErrCodeMaxInValuesExceeded, oerr.Code)
+	assert.Equal(t, 10, oerr.Position, "alias errors are reported at the alias reference")
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "name", Type: odatasql.EdmString},
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32},
+	)
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema))
+
+	handler := conv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, ok := odatasql.QueryOptionsFromContext(r.Context())
+		require.True(t, ok)
+		_, _ = w.Write([]byte(opts.Filter))
+	}))
+
+	type detail struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Target   string `json:"target"`
+		Position int    `json:"@odatasql.position"`
+	}
+	type body struct {
+		Error struct {
+			Code    string   `json:"code"`
+			Message string   `json:"message"`
+			Target  string   `json:"target"`
+			Details []detail `json:"details"`
+		} `json:"error"`
+	}
+
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantFilter string
+		wantCode   string
+		wantDetail *detail
+	}{
+		{
+			name:       "Valid filter",
+			query:      url.Values{"$filter": {"age gt @min"}, "@min": {"18"}},
+			wantFilter: "age > 18",
+		},
+		{
+			name:       "No filter",
+			query:      url.Values{},
+			wantFilter: "",
+		},
+		{
+			name:       "Syntax error",
+			query:      url.Values{"$filter": {"name eq"}},
+			wantCode:   odatasql.ErrCodeInvalidFilter,
+			wantDetail: &detail{Code: odatasql.ErrCodeInvalidFilter, Target: "$filter", Position: 7},
+		},
+		{
+			name:       "Unknown property",
+			query:      url.Values{"$filter": {"age gt 1 and email eq 'a'"}},
+			wantCode:   odatasql.ErrCodeInvalidFilter,
+			wantDetail: &detail{Code: odatasql.ErrCodeInvalidFilter, Target: "$filter", Position: 13},
+		},
+		{
+			name:     "Alias given twice",
+			query:    url.Values{"$filter": {"age gt @min"}, "@min": {"1", "2"}},
+			wantCode: odatasql.ErrCodeBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/users?"+tt.query.Encode(), nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantCode == "" {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, tt.wantFilter, rec.Body.String())
+				return
+			}
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var got body
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantCode, got.Error.Code)
+			assert.NotEmpty(t, got.Error.Message)
+			if tt.wantDetail == nil {
+				assert.Empty(t, got.Error.Details)
+				return
+			}
+			assert.Equal(t, "$filter", got.Error.Target)
+			require.Len(t, got.Error.Details, 1)
+			d := got.Error.Details[0]
+			assert.NotEmpty(t, d.Message)
+			d.Message = ""
+			assert.Equal(t, *tt.wantDetail, d)
+		})
+	}
+}
+
+func TestMiddleware_ConfigurationError(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithNamingStrategy(odatasql.KebabCase))
+	require.Error(t, conv.Err())
+	called := false
+	handler := conv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users?"+url.Values{"$filter": {"name eq 'a'"}}.Encode(), nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	// The server's misconfiguration is not the client's fault, and its text stays private.
+	assert.False(t, called)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error": {"code": "InternalError", "message": "internal server error"}}`, rec.Body.String())
+}
+
+func TestQueryOptionsFromContext_Missing(t *testing.T) {
+	t.Parallel()
+
+	_, ok := odatasql.QueryOptionsFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	assert.False(t, ok)
+}
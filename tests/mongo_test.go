@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type doc = map[string]any
+
+func TestFilterToMongo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter   string
+		expected doc
+	}{
+		{"", doc{}},
+		{"name eq 'Alice'", doc{"name": doc{"$eq": "Alice"}}},
+		{"age ge 18", doc{"age": doc{"$gte": int64(18)}}},
+		{"score lt 4.5", doc{"score": doc{"$lt": 4.5}}},
+		{"vip ne true", doc{"vip": doc{"$ne": true}}},
+		{"email eq null", doc{"email": doc{"$eq": nil}}},
+		{"address/city gt 'M'", doc{"address.city": doc{"$gt": "M"}}},
+		{"status in ('new', 'open')", doc{"status": doc{"$in": []any{"new", "open"}}}},
+		{
+			"a eq 1 and b eq 2 and (c eq 3 or d le 4)",
+			doc{"$and": []any{
+				doc{"a": doc{"$eq": int64(1)}},
+				doc{"b": doc{"$eq": int64(2)}},
+				doc{"$or": []any{doc{"c": doc{"$eq": int64(3)}}, doc{"d": doc{"$lte": int64(4)}}}},
+			}},
+		},
+		{"not (age lt 18)", doc{"$nor": []any{doc{"age": doc{"$lt": int64(18)}}}}},
+		{"contains(name, 'a.b')", doc{"name": doc{"$regex": `a\.b`}}},
+		{"startswith(name, '(A')", doc{"name": doc{"$regex": `^\(A`}}},
+		{"endswith(name, 'z*')", doc{"name": doc{"$regex": `z\*$`}}},
+		{"tags/any(t: t eq 'red')", doc{"tags": doc{"$elemMatch": doc{"$eq": "red"}}}},
+		{"tags/any(t: t gt 'a' and t lt 'c')", doc{"tags": doc{"$elemMatch": doc{"$gt": "a", "$lt": "c"}}}},
+		{"tags/any(t: not (t in ('x')))", doc{"tags": doc{"$elemMatch": doc{"$not": doc{"$in": []any{"x"}}}}}},
+		{"tags/all(t: startswith(t, 'a'))", doc{"$nor": []any{doc{"tags": doc{"$elemMatch": doc{"$not": doc{"$regex": "^a"}}}}}}},
+		{"tags/all(t: not (t eq 'x'))", doc{"$nor": []any{doc{"tags": doc{"$elemMatch": doc{"$eq": "x"}}}}}},
+		{"tags/any()", doc{"tags.0": doc{"$exists": true}}},
+		{
+			"lines/any(l: l/price gt 10 and l/product eq 'ink')",
+			doc{"lines": doc{"$elemMatch": doc{"$and": []any{
+				doc{"price": doc{"$gt": int64(10)}},
+				doc{"product": doc{"$eq": "ink"}},
+			}}}},
+		},
+		{
+			"lines/all(l: l/price gt 10)",
+			doc{"$nor": []any{doc{"lines": doc{"$elemMatch": doc{"$nor": []any{doc{"price": doc{"$gt": int64(10)}}}}}}}},
+		},
+		{
+			"lines/any(l: l/labels/any(x: x eq 'sale'))",
+			doc{"lines": doc{"$elemMatch": doc{"labels": doc{"$elemMatch": doc{"$eq": "sale"}}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := odatasql.FilterToMongo(tt.filter)
+		require.NoError(t, err, "FilterToMongo(%q)", tt.filter)
+		assert.Equal(t, tt.expected, got, "FilterToMongo(%q)", tt.filter)
+	}
+}
+
+func TestFilterToMongo_Schema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "name", Column: "full_name", Type: odatasql.EdmString},
+		odatasql.Property{Name: "createdAt", Type: odatasql.EdmDateTimeOffset},
+		odatasql.Property{Name: "lines/product", Type: odatasql.EdmString},
+		odatasql.Property{Name: "lines/price", Column: "items.cost", Type: odatasql.EdmDecimal},
+		odatasql.Property{Name: "secret", NonFilterable: true},
+	)
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema))
+
+	got, err := conv.FilterToMongo("name eq 'Bob' and createdAt ge 2024-03-01T10:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, doc{"$and": []any{
+		doc{"full_name": doc{"$eq": "Bob"}},
+		doc{"createdAt": doc{"$gte": time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}},
+	}}, got)
+
+	got, err = conv.FilterToMongo("lines/any(l: l/product eq 'ink')")
+	require.NoError(t, err)
+	assert.Equal(t, doc{"lines": doc{"$elemMatch": doc{"product": doc{"$eq": "ink"}}}}, got)
+
+	// The element field is resolved from the column, relative to the collection's field.
+	_, err = conv.FilterToMongo("lines/any(l: l/price gt 10)")
+	assert.ErrorContains(t, err, `field "items.cost" of property "lines/price" is not inside field "lines"`)
+
+	for _, filter := range []string{"secret eq 'x'", "other eq 1", "name eq 1", "lines/any(l: l/other eq 1)"} {
+		_, err := conv.FilterToMongo(filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestFilterToMongo_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, filter := range []string{
+		"name eq",
+		"tags/any(t: t eq 'a' or t eq 'b')",
+		"lines/any(l: l eq null and l/price gt 1)",
+	} {
+		_, err := odatasql.FilterToMongo(filter)
+		assert.Error(t, err, filter)
+	}
+}
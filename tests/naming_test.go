@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		strategy odatasql.NamingStrategy
+		input    string
+		expected string
+	}{
+		// --- SnakeCase ---
+		{"Snake camelCase", odatasql.SnakeCase, "firstName", "first_name"},
+		{"Snake PascalCase", odatasql.SnakeCase, "FirstName", "first_name"},
+		{"Snake trailing acronym", odatasql.SnakeCase, "userID", "user_id"},
+		{"Snake leading acronym", odatasql.SnakeCase, "HTTPStatus", "http_status"},
+		{"Snake inner acronym", odatasql.SnakeCase, "parseHTTPResponse", "parse_http_response"},
+		{"Snake digits", odatasql.SnakeCase, "address2Line", "address2_line"},
+		{"Snake already snake", odatasql.SnakeCase, "first_name", "first_name"},
+		{"Snake all caps", odatasql.SnakeCase, "ID", "id"},
+		{"Snake path", odatasql.SnakeCase, "homeAddress/zipCode", "home_address/zip_code"},
+
+		// --- SimpleSnakeCase ---
+		{"Simple snake camelCase", odatasql.SimpleSnakeCase, "firstName", "first_name"},
+		{"Simple snake acronym", odatasql.SimpleSnakeCase, "HTTPStatus", "httpstatus"},
+
+		// --- KebabCase ---
+		{"Kebab camelCase", odatasql.KebabCase, "firstName", "first-name"},
+		{"Kebab acronym", odatasql.KebabCase, "HTTPStatus", "http-status"},
+
+		// --- LowerCase and Identity ---
+		{"Lower", odatasql.LowerCase, "HTTPStatus", "httpstatus"},
+		{"Identity camelCase", odatasql.Identity, "firstName", "firstName"},
+		{"Identity PascalCase", odatasql.Identity, "HTTPStatus", "HTTPStatus"},
+
+		// --- NamingFunc ---
+		{"Custom func", odatasql.NamingFunc(func(p string) string { return "col_" + strings.ToLower(p) }), "Age", "col_age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, tt.strategy.ColumnName(tt.input))
+		})
+	}
+}
+
+func TestConverter_NamingStrategy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []odatasql.Option
+		input    string
+		expected string
+	}{
+		{"Default is acronym-aware snake case", nil, "HTTPStatus eq 200", "http_status = 200"},
+		{"Simple snake case", []odatasql.Option{odatasql.WithNamingStrategy(odatasql.SimpleSnakeCase)}, "HTTPStatus eq 200", "httpstatus = 200"},
+		{"Identity for camelCase columns", []odatasql.Option{odatasql.WithNamingStrategy(odatasql.Identity), odatasql.WithDialect(odatasql.DialectPostgres)}, "firstName eq 'Bob'", `"firstName" = 'Bob'`},
+		{"Kebab with quoting dialect", []odatasql.Option{odatasql.WithNamingStrategy(odatasql.KebabCase), odatasql.WithDialect(odatasql.DialectMySQL)}, "firstName eq 'Bob'", "`first-name` = 'Bob'"},
+		{"Path is qualified", nil, "homeAddress/zipCode eq '1'", "home_address.zip_code = '1'"},
+		{"Path is quoted part by part", []odatasql.Option{odatasql.WithDialect(odatasql.DialectPostgres)}, "homeAddress/zipCode eq '1'", `"home_address"."zip_code" = '1'`},
+		{"Path segments use the strategy", []odatasql.Option{odatasql.WithNamingStrategy(odatasql.Identity)}, "homeAddress/zipCode eq '1'", "homeAddress.zipCode = '1'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, err := odatasql.NewConverter(tt.opts...).FilterToSQL(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sql)
+		})
+	}
+}
+
+func TestConverter_KebabCaseRequiresQuoting(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithNamingStrategy(odatasql.KebabCase))
+	require.Error(t, conv.Err())
+
+	_, err := conv.FilterToSQL("firstName eq 'Bob'")
+	assert.ErrorIs(t, err, conv.Err())
+	_, err = conv.FilterToSQL("")
+	assert.Error(t, err)
+	_, err = conv.CompileFilter("firstName eq 'Bob'")
+	assert.Error(t, err)
+
+	conv = odatasql.NewConverter(odatasql.WithNamingStrategy(odatasql.KebabCase), odatasql.WithDialect(odatasql.DialectPostgres))
+	assert.NoError(t, conv.Err())
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterToDNF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter string
+		dnf    string
+		cnf    string
+	}{
+		{"", "", ""},
+		{"a eq 1", "a eq 1", "a eq 1"},
+		{"a eq 1 and (b eq 2 or c eq 3)", "a eq 1 and b eq 2 or a eq 1 and c eq 3", "a eq 1 and (b eq 2 or c eq 3)"},
+		{"a eq 1 or b eq 2 and c eq 3", "a eq 1 or b eq 2 and c eq 3", "(a eq 1 or b eq 2) and (a eq 1 or c eq 3)"},
+		{"(a eq 1 or b eq 2) and a eq 1", "a eq 1", "a eq 1"},
+		{
+			"not ((a eq 1 or b eq 2) and (c eq 3 or d eq 4))",
+			"not a eq 1 and not b eq 2 or not c eq 3 and not d eq 4",
+			"(not a eq 1 or not c eq 3) and (not a eq 1 or not d eq 4) and (not b eq 2 or not c eq 3) and (not b eq 2 or not d eq 4)",
+		},
+		{
+			"(a eq 1 or a eq 2) and contains(name, 'x') and tags/any(t: t eq 'a' and (t eq 'b' or t eq 'c'))",
+			"a in (1, 2) and contains(name, 'x') and tags/any(t: t eq 'a' and t in ('b', 'c'))",
+			"a in (1, 2) and contains(name, 'x') and tags/any(t: t eq 'a' and t in ('b', 'c'))",
+		},
+	}
+
+	for _, tt := range tests {
+		dnf, err := odatasql.FilterToDNF(tt.filter)
+		require.NoError(t, err, "FilterToDNF(%q)", tt.filter)
+		assert.Equal(t, tt.dnf, dnf, "FilterToDNF(%q)", tt.filter)
+
+		cnf, err := odatasql.FilterToCNF(tt.filter)
+		require.NoError(t, err, "FilterToCNF(%q)", tt.filter)
+		assert.Equal(t, tt.cnf, cnf, "FilterToCNF(%q)", tt.filter)
+	}
+}
+
+func TestFilterToDNF_PreservesSemantics(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithSchema(simplifySchema(t)))
+	filters := []string{
+		"(a eq 1 or b eq 2) and (a eq 2 or not (b eq 1 and age gt 10))",
+		"not ((a lt 2 or name eq 'Al') and (b ge 2 or age le 18)) or a eq null",
+		"(a eq 1 and b eq 1 or a eq 2 and b eq 2) and not (age eq 10 or startswith(name, 'B'))",
+	}
+	rows := []map[string]any{
+		{"a": 1, "b": 1, "age": 10, "name": "Al"},
+		{"a": 2, "b": 2, "age": 30, "name": "Bob"},
+		{"a": 1, "b": 2, "age": 18, "name": nil},
+		{"a": nil, "b": 1, "age": 30, "name": "Al"},
+		{"a": 3, "b": nil, "age": 10, "name": "Bob"},
+	}
+
+	for _, filter := range filters {
+		want, err := conv.CompileFilter(filter)
+		require.NoError(t, err)
+		for _, normalize := range []func(string) (string, error){conv.FilterToDNF, conv.FilterToCNF} {
+			normal, err := normalize(filter)
+			require.NoError(t, err, filter)
+			got, err := conv.CompileFilter(normal)
+			require.NoError(t, err, normal)
+
+			for _, row := range rows {
+				wantMatch, err := want(row)
+				require.NoError(t, err)
+				gotMatch, err := got(row)
+				require.NoError(t, err)
+				assert.Equal(t, wantMatch, gotMatch, "%q normalized to %q on %v", filter, normal, row)
+			}
+		}
+	}
+}
+
+func TestFilterToDNF_MaxClauses(t *testing.T) {
+	t.Parallel()
+
+	// Each "and" of two "or"s doubles the number of clauses in disjunctive normal form.
+	filter := "(a eq 1 or b eq 1) and (a eq 2 or b eq 2) and (a eq 3 or b eq 3) and (a eq 4 or b eq 4)"
+	conv := odatasql.NewConverter(odatasql.WithLimits(odatasql.Limits{MaxClauses: 8}))
+
+	_, err := conv.FilterToDNF(filter)
+	var e *odatasql.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeMaxClausesExceeded, e.Code)
+
+	_, err = conv.FilterToCNF(filter)
+	require.NoError(t, err)
+
+	conv = odatasql.NewConverter(odatasql.WithLimits(odatasql.Limits{MaxClauses: -1}))
+	_, err = conv.FilterToDNF(filter)
+	require.NoError(t, err)
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstrainedValues(t *testing.T) {
+	t.Parallel()
+
+	bounded := func(values ...any) odatasql.FieldValues {
+		return odatasql.FieldValues{Bounded: true, Values: values}
+	}
+	conv := satisfiabilityConverter(t, odatasql.NullAsIsNull)
+	tests := []struct {
+		filter   string
+		expected map[string]odatasql.FieldValues
+	}{
+		{"", map[string]odatasql.FieldValues{}},
+		{"name eq 'eu'", map[string]odatasql.FieldValues{"name": bounded("eu")}},
+		{
+			"name eq 'eu' and (age in (1, 2) or age eq 3)",
+			map[string]odatasql.FieldValues{"name": bounded("eu"), "age": bounded(int64(1), int64(2), int64(3))},
+		},
+		{"name eq 'eu' or age gt 18", map[string]odatasql.FieldValues{"name": {}, "age": {}}},
+		{"name eq 'eu' or name eq 'us' and age gt 18", map[string]odatasql.FieldValues{"name": bounded("eu", "us"), "age": {}}},
+		{"age in (1, 2, 3) and age ne 2", map[string]odatasql.FieldValues{"age": bounded(int64(1), int64(3))}},
+		{"age ge 1 and age lt 4", map[string]odatasql.FieldValues{"age": bounded(int64(1), int64(2), int64(3))}},
+		{"score ge 1 and score le 2", map[string]odatasql.FieldValues{"score": {}}}, // score holds any number
+		{"not (age ne 5)", map[string]odatasql.FieldValues{"age": bounded(int64(5))}},
+		{"not (name ne 'a' and name ne 'b')", map[string]odatasql.FieldValues{"name": bounded("a", "b")}},
+		{"not name in ('a', 'b')", map[string]odatasql.FieldValues{"name": {}}},
+		{"vip ne true", map[string]odatasql.FieldValues{"vip": bounded(false)}},
+		{"name eq 'a' or name eq null", map[string]odatasql.FieldValues{"name": bounded("a", nil)}},
+		{"age eq 1 and age eq 2", map[string]odatasql.FieldValues{}}, // matches no row
+		{"name eq 'a' and contains(name, 'x')", map[string]odatasql.FieldValues{"name": bounded("a")}},
+		{"contains(name, 'x')", map[string]odatasql.FieldValues{"name": {}}},
+		{"name eq 'a' and tags/any(t: t eq 'b')", map[string]odatasql.FieldValues{"name": bounded("a")}},
+	}
+
+	for _, tt := range tests {
+		got, err := conv.ConstrainedValues(tt.filter)
+		require.NoError(t, err, "ConstrainedValues(%q)", tt.filter)
+		assert.Equal(t, tt.expected, got, "ConstrainedValues(%q)", tt.filter)
+	}
+
+	// Zero values of missing fields are unbounded.
+	got, err := odatasql.ConstrainedValues("region eq 'eu' and tenantId in (1, 2)")
+	require.NoError(t, err)
+	assert.Equal(t, bounded("eu"), got["region"])
+	assert.Equal(t, bounded(int64(1), int64(2)), got["tenantId"])
+	assert.False(t, got["missing"].Bounded)
+
+	_, err = odatasql.ConstrainedValues("region eq")
+	assert.Error(t, err)
+}
+
+func TestConstrainedValues_Limits(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(odatasql.Property{Name: "shard", Type: odatasql.EdmInt32})
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithLimits(odatasql.Limits{MaxInValues: 3}))
+
+	got, err := conv.ConstrainedValues("shard ge 1 and shard le 3")
+	require.NoError(t, err)
+	assert.True(t, got["shard"].Bounded)
+
+	got, err = conv.ConstrainedValues("shard ge 1 and shard le 4")
+	require.NoError(t, err)
+	assert.False(t, got["shard"].Bounded)
+
+	conv = odatasql.NewConverter(odatasql.WithLimits(odatasql.Limits{MaxClauses: 2}))
+	_, err = conv.ConstrainedValues("(a eq 1 or b eq 1) and (c eq 1 or d eq 1)")
+	var e *odatasql.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeMaxClausesExceeded, e.Code)
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredicates_Where(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "vip", Type: odatasql.EdmBoolean},
+		odatasql.Property{Name: "deletedAt", Type: odatasql.EdmDateTimeOffset, NonFilterable: true},
+	)
+	require.NoError(t, err)
+
+	conv := odatasql.NewConverter(
+		odatasql.WithDialect(odatasql.DialectPostgres),
+		odatasql.WithSchema(schema),
+		odatasql.WithNullHandling(odatasql.NullAsIsNull),
+		odatasql.WithPredicates(
+			odatasql.SQLPredicate("tenant_id = ?", 42),
+			odatasql.FilterPredicate("deletedAt eq null"),
+		),
+	)
+
+	tests := []struct {
+		name     string
+		filter   string
+		expected string
+	}{
+		{"No filter", "", `(tenant_id = $1) AND ("deleted_at" IS NULL)`},
+		{"Or cannot escape", "age gt 18 or vip eq true", `(tenant_id = $1) AND ("deleted_at" IS NULL) AND ("age" > 18 OR "vip" = TRUE)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query := url.Values{}
+			if tt.filter != "" {
+				query.Set("$filter", tt.filter)
+			}
+			opts, err := conv.ParseQueryOptions(query)
+			require.NoError(t, err)
+
+			where, args := opts.Where()
+			assert.Equal(t, tt.expected, where)
+			assert.Equal(t, []any{42}, args)
+
+			// The predicates are reported apart from the client filter.
+			assert.Equal(t, []odatasql.Predicate{
+				{SQL: "tenant_id = ?", Args: []any{42}},
+				{Filter: "deletedAt eq null", SQL: `"deleted_at" IS NULL`},
+			}, opts.Predicates)
+		})
+	}
+
+	// Clients still cannot filter on the property used by the predicate.
+	_, err = conv.ParseQueryOptions(url.Values{"$filter": {"deletedAt ne null"}})
+	assert.Error(t, err)
+
+	// FilterToSQL only converts the given filter.
+	sql, err := conv.FilterToSQL("age gt 18")
+	require.NoError(t, err)
+	assert.Equal(t, `"age" > 18`, sql)
+
+	// Select numbers its own placeholders before those of the predicates.
+	opts, err := conv.ParseQueryOptions(url.Values{"$filter": {"vip eq true"}})
+	require.NoError(t, err)
+	query, args := conv.Select("users").Where("region = ?", "eu").SQL(opts)
+	assert.Equal(t, `SELECT * FROM "users" WHERE (region = $1) AND (tenant_id = $2) AND ("deleted_at" IS NULL) AND ("vip" = TRUE)`, query)
+	assert.Equal(t, []any{"eu", 42}, args)
+}
+
+func TestPredicates_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range []odatasql.Predicate{odatasql.FilterPredicate("deleted eq"), odatasql.SQLPredicate(" ")} {
+		conv := odatasql.NewConverter(odatasql.WithPredicates(p))
+		assert.Error(t, conv.Err(), "invalid predicates are reported at construction")
+		_, err := conv.ParseQueryOptions(url.Values{})
+		assert.Error(t, err)
+	}
+}
+
+func TestPredicates_InvalidContext(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter()
+	called := false
+	handler := conv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	auth := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := odatasql.ContextWithPredicates(r.Context(), odatasql.FilterPredicate("tenantId eq"))
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users?"+url.Values{"$filter": {"name eq 'a'"}}.Encode(), nil)
+	rec := httptest.NewRecorder()
+	auth.ServeHTTP(rec, req)
+
+	// A broken server-side predicate is not the client's fault, and its text stays private.
+	assert.False(t, called)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error": {"code": "InternalError", "message": "internal server error"}}`, rec.Body.String())
+}
+
+func TestPredicates_Context(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithPredicates(odatasql.FilterPredicate("deleted eq false")))
+
+	var where string
+	var args []any
+	handler := conv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, _ := odatasql.QueryOptionsFromContext(r.Context())
+		where, args = opts.Where()
+	}))
+	auth := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := odatasql.ContextWithPredicates(r.Context(), odatasql.SQLPredicate("tenant_id = ?", "acme"))
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users?"+url.Values{"$filter": {"name eq 'a' or vip eq true"}}.Encode(), nil)
+	rec := httptest.NewRecorder()
+	auth.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "(deleted = false) AND (tenant_id = ?) AND (name = 'a' OR vip = true)", where)
+	assert.Equal(t, []any{"acme"}, args)
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueryOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		query    url.Values
+		expected string
+		wantErr  bool
+	}{
+		{"No filter", url.Values{}, "", false},
+		{"Filter without aliases", url.Values{"$filter": {"name eq 'Bob'"}}, "name = 'Bob'", false},
+
+		// --- Scalar Aliases ---
+		{"Number alias", url.Values{"$filter": {"price gt @min"}, "@min": {"10"}}, "price > 10", false},
+		{"String alias", url.Values{"$filter": {"name eq @n"}, "@n": {"'O''Brien'"}}, "name = 'O''Brien'", false},
+		{"Null alias", url.Values{"$filter": {"deletedAt eq @d"}, "@d": {"null"}}, "deleted_at = null", false},
+		{"Alias used twice", url.Values{"$filter": {"a gt @v or b gt @v"}, "@v": {"5"}}, "a > 5 OR b > 5", false},
+		{"Multiple aliases", url.Values{"$filter": {"price ge @min and price le @max"}, "@min": {"10"}, "@max": {"20"}}, "price >= 10 AND price <= 20", false},
+
+		// --- Collection Aliases ---
+		{"IN with JSON array alias", url.Values{"$filter": {"status in @s"}, "@s": {"['open','pending']"}}, "status IN ('open', 'pending')", false},
+		{"IN with parenthesized alias", url.Values{"$filter": {"age in @a"}, "@a": {"(20, 30)"}}, "age IN (20, 30)", false},
+
+		// --- Error Cases ---
+		{"Undefined alias", url.Values{"$filter": {"price gt @min"}}, "", true},
+		{"Unused alias", url.Values{"$filter": {"price gt 10"}, "@min": {"10"}}, "", true},
+		{"Alias without filter", url.Values{"@min": {"10"}}, "", true},
+		{"Repeated alias", url.Values{"$filter": {"price gt @min"}, "@min": {"10", "20"}}, "", true},
+		{"Empty alias value", url.Values{"$filter": {"price gt @min"}, "@min": {""}}, "", true},
+		{"Alias with expression", url.Values{"$filter": {"price gt @min"}, "@min": {"10 or 1 eq 1"}}, "", true},
+		{"Alias with injection", url.Values{"$filter": {"id eq @id"}, "@id": {"'1; DROP TABLE users --'"}}, "", true},
+		{"Scalar alias for IN", url.Values{"$filter": {"status in @s"}, "@s": {"'open'"}}, "", true},
+		{"Collection alias for comparison", url.Values{"$filter": {"status eq @s"}, "@s": {"['open']"}}, "", true},
+		{"Empty collection alias", url.Values{"$filter": {"status in @s"}, "@s": {"[]"}}, "", true},
+		{"Unclosed collection alias", url.Values{"$filter": {"status in @s"}, "@s": {"['open'"}}, "", true},
+		{"Invalid alias name", url.Values{"$filter": {"price gt @1min"}, "@1min": {"10"}}, "", true},
+		{"Alias as field", url.Values{"$filter": {"@f eq 10"}, "@f": {"price"}}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := odatasql.ParseQueryOptions(tt.query)
+			if tt.wantErr {
+				assert.Error(t, err, "ParseQueryOptions(%v) expected error", tt.query)
+				return
+			}
+
+			require.NoError(t, err, "ParseQueryOptions(%v) did not expect an error", tt.query)
+			assert.Equal(t, tt.expected, opts.Filter, "ParseQueryOptions(%v).Filter = %q, want %q", tt.query, opts.Filter, tt.expected)
+		})
+	}
+}
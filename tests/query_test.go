@@ -25,6 +25,8 @@ func TestFilterToSQL(t *testing.T) {
 		{"Basic ge", "height ge 170", "height >= 170", false},
 		{"Basic lt", "score lt 50", "score < 50", false},
 		{"Basic le", "price le 99.99", "price <= 99.99", false},
+		{"Negative number", "balance lt -10.5", "balance < -10.5", false},
+		{"Float spelling is not a number", "score eq inf", "score = 'inf'", false},
 
 		// --- Logical Operators ---
 		{"AND operator", "age gt 18 and status eq 'active'", "age > 18 AND status = 'active'", false},
@@ -43,9 +45,42 @@ func TestFilterToSQL(t *testing.T) {
 		{"IN with numbers", "age in (20, 25, 30)", "age IN (20, 25, 30)", false},
 		{"IN with single value", "color in ('red')", "color IN ('red')", false},
 
+		// --- IN Operator with JSON Array Syntax ---
+		{"IN with JSON strings", `color in ["red","blue"]`, "color IN ('red', 'blue')", false},
+		{"IN with JSON numbers", "age in [20, 25, 30]", "age IN (20, 25, 30)", false},
+		{"IN with JSON single value", `color in ["red"]`, "color IN ('red')", false},
+		{"IN with OData strings in brackets", "color in ['red', 'blue']", "color IN ('red', 'blue')", false},
+		{"IN with JSON escaped quote", `name in ["say \"hi\""]`, `name IN ('say "hi"')`, false},
+		{"IN with JSON apostrophe", `name in ["O'Brien"]`, "name IN ('O''Brien')", false},
+		{"IN with JSON quoted apostrophes", `name in ["'x'"]`, "name IN ('''x''')", false},
+		{"IN with JSON unicode escape", `name in ["caf\u00e9"]`, "name IN ('café')", false},
+		{"IN with JSON combined with AND", `color in ["red"] and age gt 3`, "color IN ('red') AND age > 3", false},
+		{"IN with JSON empty", "color in []", "", true},
+		{"IN with JSON unclosed", `color in ["red"`, "", true},
+		{"IN with JSON mismatched brackets", `color in ["red")`, "", true},
+		{"IN with JSON invalid escape", `color in ["re\qd"]`, "", true},
+		{"IN with JSON unterminated string", `color in ["red]`, "", true},
+		{"JSON string in parenthesized IN", `color in ("red")`, "", true},
+		{"JSON string in comparison", `color eq "red"`, "", true},
+
+		// --- String Functions ---
+		{"contains", "contains(name, 'ob')", `name LIKE '%ob%' ESCAPE '\'`, false},
+		{"startswith", "startswith(name, 'B')", `name LIKE 'B%' ESCAPE '\'`, false},
+		{"endswith", "endswith(name, 'b')", `name LIKE '%b' ESCAPE '\'`, false},
+		{"Function wildcards escaped", `contains(code, '50%_\')`, `code LIKE '%50\%\_\\%' ESCAPE '\'`, false},
+		{"Function quote escaped", "startswith(name, 'O''B')", `name LIKE 'O''B%' ESCAPE '\'`, false},
+		{"Function is case-insensitive", "Contains(name, 'x')", `name LIKE '%x%' ESCAPE '\'`, false},
+		{"Function with NOT and AND", "not contains(name, 'x') and age gt 1", `(NOT name LIKE '%x%' ESCAPE '\') AND age > 1`, false},
+		{"Function unknown", "length(name, 'x')", "", true},
+		{"Function number argument", "contains(name, 1)", "", true},
+		{"Function missing argument", "contains(name)", "", true},
+		{"Function missing parenthesis", "contains(name, 'x'", "", true},
+		{"Function field not identifier", "contains('x', name)", "", true},
+
 		// --- Quoting and String Literals ---
 		{"String with spaces", "name eq 'John Doe'", "name = 'John Doe'", false},
 		{"Quoted values with single quotes", "nickname eq 'O''Brien'", "nickname = 'O''Brien'", false},
+		{"Quoted values with surrounding single quotes", "nickname eq '''Bob'''", "nickname = '''Bob'''", false},
 
 		// --- Boolean and Null Literals ---
 		{"Boolean true", "isActive eq true", "is_active = true", false},
@@ -85,6 +120,8 @@ func TestFilterToSQL(t *testing.T) {
 		{"Leading OR", "or age gt 30", "", true},
 		{"IN empty set", "color in ()", "", true},
 		{"Reserved word", "drop eq 'value'", "", true},
+		{"Lone opening parenthesis", "(", "", true},
+		{"Trailing opening parenthesis", "age gt 18 and (", "", true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
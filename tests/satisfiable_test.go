@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func satisfiabilityConverter(t *testing.T, nulls odatasql.NullHandling) *odatasql.Converter {
+	t.Helper()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32, NotNull: true},
+		odatasql.Property{Name: "score", Type: odatasql.EdmDouble},
+		odatasql.Property{Name: "vip", Type: odatasql.EdmBoolean, NotNull: true},
+		odatasql.Property{Name: "name", Type: odatasql.EdmString},
+		odatasql.Property{Name: "day", Type: odatasql.EdmDate, NotNull: true},
+		odatasql.Property{Name: "tags", Type: odatasql.EdmString, NotNull: true},
+	)
+	require.NoError(t, err)
+	return odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithNullHandling(nulls))
+}
+
+func TestContradiction(t *testing.T) {
+	t.Parallel()
+
+	conv := satisfiabilityConverter(t, odatasql.NullAsValue)
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{"", false},
+		{"age gt 10", false},
+		{"age gt 10 and age lt 5", true},
+		{"age gt 10 and age lt 11", true}, // age holds integers
+		{"score gt 10 and score lt 11", false},
+		{"score gt 10 and score lt 10", true},
+		{"age ge 10 and age le 10 and age ne 10", true},
+		{"age in (1, 2) and age gt 2", true},
+		{"age in (1, 2) and not age in (2, 1)", true},
+		{"age in (1, 2) and not age in (2, 3)", false},
+		{"vip eq true and vip ne true", true},
+		{"vip ne true and vip ne false", true},
+		{"name eq 'a' and name eq 'b'", true},
+		{"name ge 'b' and name lt 'a'", true},
+		{"day gt '2024-01-01' and day lt '2024-01-01'", true},
+		{"(age lt 5 or age gt 10) and age ge 5 and age le 10", true},
+		{"not (age le 10 or age gt 5)", true},
+		{"contains(name, 'x') and not contains(name, 'x')", true},
+		{"contains(name, 'x') and not contains(name, 'y')", false},
+		{"name eq null", true}, // comparisons with null are unknown
+		{"contains(name, 'x') or name eq null", false},
+		{"tags/any(t: t eq 'a') and not tags/any(t: t eq 'a')", true},
+	}
+
+	for _, tt := range tests {
+		got, err := conv.Contradiction(tt.filter)
+		require.NoError(t, err, "Contradiction(%q)", tt.filter)
+		assert.Equal(t, tt.expected, got, "Contradiction(%q)", tt.filter)
+	}
+
+	conv = satisfiabilityConverter(t, odatasql.NullAsIsNull)
+	for filter, expected := range map[string]bool{
+		"name eq null":                         false,
+		"name eq null and name ne null":        true,
+		"contains(name, 'x') and name eq null": true,
+		"age eq null":                          true, // age is NotNull
+	} {
+		got, err := conv.Contradiction(filter)
+		require.NoError(t, err, filter)
+		assert.Equal(t, expected, got, "Contradiction(%q)", filter)
+	}
+}
+
+func TestTautology(t *testing.T) {
+	t.Parallel()
+
+	conv := satisfiabilityConverter(t, odatasql.NullAsIsNull)
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{"", true},
+		{"age le 10 or age gt 5", true},
+		{"score le 10 or score gt 5", false}, // score may be null
+		{"score le 10 or score gt 5 or score eq null", true},
+		{"vip eq true or vip eq false", true},
+		{"not (age gt 10 and age lt 5)", true},
+		{"name ne 'a' or name ne 'b'", false},
+		{"name eq null or name ne null", true},
+		{"age in (1, 2) or not age in (1, 2)", true},
+		{"contains(name, 'x') or not contains(name, 'x')", false}, // unknown for null
+		{"contains(name, 'x') or not contains(name, 'x') or name eq null", true},
+	}
+
+	for _, tt := range tests {
+		got, err := conv.Tautology(tt.filter)
+		require.NoError(t, err, "Tautology(%q)", tt.filter)
+		assert.Equal(t, tt.expected, got, "Tautology(%q)", tt.filter)
+	}
+
+	got, err := odatasql.Tautology("age le 10 or age gt 5")
+	require.NoError(t, err)
+	assert.False(t, got) // without a schema, age may be null
+}
+
+func TestImplies(t *testing.T) {
+	t.Parallel()
+
+	conv := satisfiabilityConverter(t, odatasql.NullAsValue)
+	tests := []struct {
+		filter   string
+		other    string
+		expected bool
+	}{
+		{"age gt 18 and name eq 'a'", "age ge 10", true},
+		{"age ge 10", "age gt 18", false},
+		{"age gt 18", "age ge 19", true},
+		{"score gt 18", "score ge 19", false},
+		{"age in (1, 2)", "age ge 1 and age le 2", true},
+		{"age in (1, 2)", "age in (1, 2, 3)", true},
+		{"age in (1, 4)", "age in (1, 2, 3)", false},
+		{"age eq 1 or age eq 2", "not age in (3)", true},
+		{"name eq 'a' and contains(name, 'x')", "contains(name, 'x') or age eq 3", true},
+		{"startswith(name, 'a')", "name ne null", false}, // null comparisons are never true
+		{"age gt 5 and age lt 3", "name eq 'x'", true},   // a contradiction implies anything
+		{"name eq 'x'", "", true},
+		{"", "age gt 1", false},
+		{"", "age gt 1 or age le 1", true},
+		{"day ge '2024-03-01'", "day gt '2024-02-28T00:00:00Z'", true},
+	}
+
+	for _, tt := range tests {
+		got, err := conv.Implies(tt.filter, tt.other)
+		require.NoError(t, err, "Implies(%q, %q)", tt.filter, tt.other)
+		assert.Equal(t, tt.expected, got, "Implies(%q, %q)", tt.filter, tt.other)
+	}
+
+	conv = odatasql.NewConverter(odatasql.WithLimits(odatasql.Limits{MaxClauses: 4}))
+	_, err := conv.Implies("(a eq 1 or b eq 1) and (c eq 1 or d eq 1) and (e eq 1 or f eq 1)", "g eq 1")
+	var e *odatasql.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, odatasql.ErrCodeMaxClausesExceeded, e.Code)
+}
+
+func TestContradiction_Sound(t *testing.T) {
+	t.Parallel()
+
+	// Filters proven contradictory match no row, and tautologies match every row.
+	for _, nulls := range []odatasql.NullHandling{odatasql.NullAsValue, odatasql.NullAsIsNull} {
+		conv := satisfiabilityConverter(t, nulls)
+		var rows []map[string]any
+		for _, age := range []any{4, 5, 10, 11} {
+			for _, score := range []any{4.5, 10, nil} {
+				for _, name := range []any{"a", "xb", nil} {
+					rows = append(rows, map[string]any{
+						"age": age, "score": score, "vip": true, "name": name, "day": "2024-01-01",
+					})
+				}
+			}
+		}
+
+		for _, filter := range []string{
+			"age gt 4 and age lt 5",
+			"not (age le 10 or score gt 5) and name eq null",
+			"(score lt 5 or score gt 10) and not (score lt 5) and not (score gt 10)",
+			"contains(name, 'x') and name ne null or not (name eq null or name ne null)",
+			"not (age ge 5 or age lt 10 or score eq null)",
+		} {
+			contradiction, err := conv.Contradiction(filter)
+			require.NoError(t, err)
+			tautology, err := conv.Tautology(filter)
+			require.NoError(t, err)
+			match, err := conv.CompileFilter(filter)
+			require.NoError(t, err)
+
+			for _, row := range rows {
+				ok, err := match(row)
+				require.NoError(t, err)
+				if contradiction {
+					assert.False(t, ok, "%q proven contradictory matches %v", filter, row)
+				}
+				if tautology {
+					assert.True(t, ok, "%q proven a tautology does not match %v", filter, row)
+				}
+			}
+		}
+	}
+}
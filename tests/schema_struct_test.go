@@ -0,0 +1,265 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type uuidLike [16]byte
+
+type status string
+
+type auditFields struct {
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	DeletedAt *time.Time `json:"deletedAt" db:"deleted_at"`
+}
+
+type address struct {
+	City    string `json:"city"`
+	ZipCode string `json:"zipCode" db:"zip"`
+}
+
+type geo struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type account struct {
+	auditFields
+
+	ID       uuidLike         `json:"id" db:"id"`
+	Email    string           `json:"email" db:"email_address"`
+	Name     string           `json:"name,omitempty"`
+	Age      int32            `json:"age" gorm:"column:age_years;not null"`
+	Score    float32          `json:"score"`
+	Balance  sql.NullFloat64  `json:"balance"`
+	Nickname sql.Null[string] `json:"nickname"`
+	Active   *bool            `json:"active"`
+	Status   status           `json:"status" odata:"filterable"`
+	Rank     int              `json:"rank" odata:"sortable"`
+	Address  address          `json:"address" db:"addr"`
+	Location geo              `json:"location" gorm:"embedded;embeddedPrefix:loc_"`
+	Parent   *account         `json:"parent"`
+	Password string           `json:"-"`
+	Internal string           `json:"internal" db:"-"`
+	Secret   string           `json:"secret" odata:"-"`
+	Ignored  string           `gorm:"-"`
+	Tags     []string         `json:"tags"`
+	Meta     map[string]any   `json:"meta"`
+	Callback func()           `json:"callback"`
+	private  string
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.SchemaFromStruct[account]()
+	require.NoError(t, err)
+
+	expected := []odatasql.Property{
+		{Name: "createdAt", Column: "created_at", Type: odatasql.EdmDateTimeOffset},
+		{Name: "deletedAt", Column: "deleted_at", Type: odatasql.EdmDateTimeOffset},
+		{Name: "id", Column: "id", Type: odatasql.EdmGuid},
+		{Name: "email", Column: "email_address", Type: odatasql.EdmString},
+		{Name: "name", Type: odatasql.EdmString},
+		{Name: "age", Column: "age_years", Type: odatasql.EdmInt32, NotNull: true},
+		{Name: "score", Type: odatasql.EdmSingle},
+		{Name: "balance", Type: odatasql.EdmDouble},
+		{Name: "nickname", Type: odatasql.EdmString},
+		{Name: "active", Type: odatasql.EdmBoolean},
+		{Name: "status", Type: odatasql.EdmString, NonSortable: true},
+		{Name: "rank", Type: odatasql.EdmInt64, NonFilterable: true},
+		{Name: "address/city", ColumnPrefix: "addr.", Type: odatasql.EdmString},
+		{Name: "address/zipCode", Column: "addr.zip", Type: odatasql.EdmString},
+		{Name: "location/lat", ColumnPrefix: "loc_", Type: odatasql.EdmDouble},
+		{Name: "location/lng", ColumnPrefix: "loc_", Type: odatasql.EdmDouble},
+	}
+	assert.Equal(t, expected, schema.Properties())
+}
+
+func TestSchemaFromStruct_Pointer(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.SchemaFromStruct[*address]()
+	require.NoError(t, err)
+	assert.Len(t, schema.Properties(), 2)
+}
+
+type requiredCode struct {
+	Code string `json:"code" gorm:"not null"`
+}
+
+type requiredNote struct {
+	Note string `json:"note" gorm:"not null"`
+}
+
+func TestSchemaFromStruct_NotNull(t *testing.T) {
+	t.Parallel()
+
+	type order struct {
+		*requiredNote
+		ID      string        `json:"id" gorm:"not null"`
+		Name    string        `json:"name"`
+		Billing requiredCode  `json:"billing" gorm:"embedded;embeddedPrefix:bill_"`
+		Owner   *requiredCode `json:"owner" gorm:"embedded;embeddedPrefix:owner_"`
+		Account requiredCode  `json:"account" db:"acct"`
+		Parent  requiredCode  `json:"parent"`
+	}
+
+	schema, err := odatasql.SchemaFromStruct[order]()
+	require.NoError(t, err)
+
+	// Only tagged fields reached without a pointer or a join are NotNull.
+	notNull := map[string]bool{}
+	for _, p := range schema.Properties() {
+		notNull[p.Name] = p.NotNull
+	}
+	assert.Equal(t, map[string]bool{
+		"note":         false,
+		"id":           true,
+		"name":         false,
+		"billing/code": true,
+		"owner/code":   false,
+		"account/code": false,
+		"parent/code":  false,
+	}, notNull)
+
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema))
+	for field, want := range notNull {
+		tautology, err := conv.Tautology(field + " eq 'a' or " + field + " ne 'a'")
+		require.NoError(t, err)
+		assert.Equal(t, want, tautology, field)
+	}
+}
+
+func TestSchemaFromStruct_Invalid(t *testing.T) {
+	t.Parallel()
+
+	type badTag struct {
+		Name string `odata:"searchable"`
+	}
+
+	_, err := odatasql.SchemaFromStruct[badTag]()
+	assert.Error(t, err, "unknown odata tag option")
+
+	_, err = odatasql.SchemaFromStruct[string]()
+	assert.Error(t, err, "non-struct type")
+}
+
+func TestSchemaFromStruct_Converter(t *testing.T) {
+	t.Parallel()
+
+	schema, err := odatasql.SchemaFromStruct[account]()
+	require.NoError(t, err)
+	conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithDialect(odatasql.DialectPostgres))
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"Tagged column", "email eq 'a@b.c'", `"email_address" = 'a@b.c'`, false},
+		{"Naming fallback", "name eq 'Bob'", `"name" = 'Bob'`, false},
+		{"Gorm column", "age gt 18", `"age_years" > 18`, false},
+		{"Embedded field", "deletedAt eq null", `"deleted_at" = null`, false},
+		{"Navigation path", "address/city eq 'Paris'", `"addr"."city" = 'Paris'`, false},
+		{"Gorm embedded path", "location/lat gt 1.5", `"loc_lat" > 1.5`, false},
+		{"Guid", "id eq 01234567-89ab-cdef-0123-456789abcdef", `"id" = '01234567-89ab-cdef-0123-456789abcdef'`, false},
+		{"Not filterable", "rank gt 1", "", true},
+		{"Skipped field", "secret eq 'x'", "", true},
+		{"Type mismatch", "age eq 'old'", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, err := conv.FilterToSQL(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err, "FilterToSQL(%q) expected error", tt.input)
+				return
+			}
+
+			require.NoError(t, err, "FilterToSQL(%q) did not expect an error", tt.input)
+			assert.Equal(t, tt.expected, sql)
+		})
+	}
+}
+
+func TestSchemaFromStruct_NamingStrategy(t *testing.T) {
+	t.Parallel()
+
+	type homeAddress struct {
+		ZipCode string `json:"zipCode"`
+	}
+	type person struct {
+		FirstName   string      `json:"firstName"`
+		HomeAddress homeAddress `json:"homeAddress"`
+		WorkAddress address     `json:"workAddress" db:"work"`
+		Location    geo         `json:"location" gorm:"embedded;embeddedPrefix:loc_"`
+	}
+
+	schema, err := odatasql.SchemaFromStruct[person]()
+	require.NoError(t, err)
+
+	// Untagged columns below navigation paths follow the converter's naming strategy, like
+	// top-level ones.
+	tests := []struct {
+		name     string
+		naming   odatasql.NamingStrategy
+		input    string
+		expected string
+	}{
+		{"Top-level snake_case", odatasql.SnakeCase, "firstName eq 'a'", "first_name = 'a'"},
+		{"Top-level identity", odatasql.Identity, "firstName eq 'a'", "firstName = 'a'"},
+		{"Untagged path snake_case", odatasql.SnakeCase, "homeAddress/zipCode eq '1'", "home_address.zip_code = '1'"},
+		{"Untagged path identity", odatasql.Identity, "homeAddress/zipCode eq '1'", "homeAddress.zipCode = '1'"},
+		{"Tagged alias identity", odatasql.Identity, "workAddress/city eq 'x'", "work.city = 'x'"},
+		{"Tagged column identity", odatasql.Identity, "workAddress/zipCode eq '1'", "work.zip = '1'"},
+		{"Embedded prefix lowercase", odatasql.LowerCase, "location/lat gt 1", "loc_lat > 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithNamingStrategy(tt.naming))
+			sql, err := conv.FilterToSQL(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sql)
+		})
+	}
+}
+
+func TestSchemaFromStruct_MixedColumns(t *testing.T) {
+	t.Parallel()
+
+	// A tagged column below an untagged navigation field would need the naming strategy for
+	// the table alias, which is only known when rendering.
+	type taggedLeaf struct {
+		Address address `json:"address"`
+	}
+	_, err := odatasql.SchemaFromStruct[taggedLeaf]()
+	assert.ErrorContains(t, err, `"address/zipCode"`)
+
+	type inner struct {
+		Geo geo `json:"geo"`
+	}
+	type untaggedBelowTagged struct {
+		Inner inner `json:"inner" db:"i"`
+	}
+	_, err = odatasql.SchemaFromStruct[untaggedBelowTagged]()
+	assert.ErrorContains(t, err, `"inner/geo"`)
+
+	type noPrefix struct {
+		Location geo `json:"location" gorm:"embedded"`
+	}
+	_, err = odatasql.SchemaFromStruct[noPrefix]()
+	assert.ErrorContains(t, err, "embeddedPrefix")
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestSelect_SQL(t *testing.T) {
+	t.Parallel()
+
+	users := odatasql.NewConverter().Select("users", "id", "name")
+	active := users.Where("deleted_at IS NULL")
+	tenant := active.Where("tenant_id = ? AND region IN (?, ?)", 7, "eu", "us")
+
+	opts, err := odatasql.ParseQueryOptions(url.Values{"$filter": {"age gt 18 or vip eq true"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    *odatasql.Select
+		opts     *odatasql.QueryOptions
+		expected string
+		args     []any
+	}{
+		{"No conditions", users, nil, "SELECT id, name FROM users", nil},
+		{"Filter only", users, opts, "SELECT id, name FROM users WHERE age > 18 OR vip = true", nil},
+		{"Predicate only", active, &odatasql.QueryOptions{}, "SELECT id, name FROM users WHERE deleted_at IS NULL", nil},
+		{
+			"Predicates and filter",
+			tenant, opts,
+			"SELECT id, name FROM users WHERE (deleted_at IS NULL) AND (tenant_id = ? AND region IN (?, ?)) AND (age > 18 OR vip = true)",
+			[]any{7, "eu", "us"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, args := tt.query.SQL(tt.opts)
+			assert.Equal(t, tt.expected, query)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+
+	// Where does not modify the Select it is called on.
+	query, _ := users.SQL(nil)
+	assert.Equal(t, "SELECT id, name FROM users", query)
+}
+
+func TestSelect_Dialects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dialect  odatasql.Dialect
+		expected string
+	}{
+		{odatasql.DialectGeneric, `SELECT COUNT(*) FROM app.users WHERE (tenant_id = ? AND note != '?') AND (kind = ?)`},
+		{odatasql.DialectPostgres, `SELECT COUNT(*) FROM "app"."users" WHERE (tenant_id = $1 AND note != '?') AND (kind = $2)`},
+		{odatasql.DialectMySQL, "SELECT COUNT(*) FROM `app`.`users` WHERE (tenant_id = ? AND note != '?') AND (kind = ?)"},
+		{odatasql.DialectSQLServer, `SELECT COUNT(*) FROM [app].[users] WHERE (tenant_id = @p1 AND note != '?') AND (kind = @p2)`},
+	}
+
+	for _, tt := range tests {
+		q := odatasql.NewConverter(odatasql.WithDialect(tt.dialect)).
+			Select("app.users").
+			Where("tenant_id = ? AND note != '?'", 1).
+			Where("kind = ?", "a")
+		query, args := q.CountSQL(nil)
+		assert.Equal(t, tt.expected, query)
+		assert.Equal(t, []any{1, "a"}, args)
+	}
+}
+
+func TestSelect_QueryAndCount(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite", "file::memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1) // every connection opens its own in-memory database
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, tenant_id INTEGER, name TEXT, note TEXT);
+		INSERT INTO users VALUES (1, 7, 'Bob', '?'), (2, 7, 'Bob', 'x'), (3, 7, 'Ann', '?'), (4, 8, 'Bob', '?');`)
+	require.NoError(t, err)
+
+	conv := odatasql.NewConverter(odatasql.WithDialect(odatasql.DialectSQLite))
+	users := conv.Select("users", "id").Where("tenant_id = ?", 7)
+	opts, err := conv.ParseQueryOptions(url.Values{"$filter": {"name eq 'Bob' or note eq '?'"}})
+	require.NoError(t, err)
+
+	rows, err := users.Query(context.Background(), db, opts)
+	require.NoError(t, err)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	assert.ElementsMatch(t, []int64{1, 2, 3}, ids)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	n, err := users.Where("id != ?", 2).Count(context.Background(), tx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	require.NoError(t, tx.Commit())
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/maxlambrecht/odatasql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func simplifySchema(t *testing.T) *odatasql.Schema {
+	t.Helper()
+
+	schema, err := odatasql.NewSchema(
+		odatasql.Property{Name: "a", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "b", Type: odatasql.EdmInt32},
+		odatasql.Property{Name: "age", Type: odatasql.EdmInt32, NotNull: true},
+		odatasql.Property{Name: "name", Type: odatasql.EdmString},
+	)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestSimplifyFilter(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithSchema(simplifySchema(t)))
+	tests := []struct {
+		filter   string
+		expected string
+	}{
+		{"", ""},
+		{"((a eq 1))", "a eq 1"},
+		{"not not a eq 1", "a eq 1"},
+		{"not (not (not a eq 1))", "not a eq 1"},
+		{"not age gt 18", "age le 18"},
+		{"not (age ge 18 and age lt 65)", "age lt 18 or age ge 65"},
+		{"not (a gt 1 or b eq 2)", "not a gt 1 and not b eq 2"},
+		{"not (a eq null)", "a ne null"},
+		{"not (a ne null and age eq 3)", "a eq null or age ne 3"},
+		{"a eq 1 or a eq 2 or a eq 3", "a in (1, 2, 3)"},
+		{"a eq 1 or b eq 5 or a in (2, 1) or a eq 3", "a in (1, 2, 3) or b eq 5"},
+		{"a eq 1 or a eq null", "a eq 1 or a eq null"},
+		{"a in (1, 1)", "a eq 1"},
+		{"not a in (2)", "not a eq 2"},
+		{"not age in (2, 3)", "not age in (2, 3)"},
+		{"not (age ne 1 and age ne 2)", "age in (1, 2)"},
+		{"a eq 1 and (b eq 2 and (a eq 1 and age gt 3))", "a eq 1 and b eq 2 and age gt 3"},
+		{"(a eq 1 or b eq 2) and (b eq 2 or a eq 1)", "(a eq 1 or b eq 2) and (b eq 2 or a eq 1)"},
+		{"(a eq 1 or b eq 2) or (a eq 1 or b eq 2)", "a eq 1 or b eq 2"},
+		{"a eq 1 and (b eq 2 or b eq 3)", "a eq 1 and b in (2, 3)"},
+		{"a ne 1 and age gt 3 and not a in (2, 3)", "not a in (1, 2, 3) and age gt 3"},
+		{"not (a eq 1 or a eq 2)", "not a in (1, 2)"},
+		{"not contains(name, 'x') or not not startswith(name, 'y')", "not contains(name, 'x') or startswith(name, 'y')"},
+	}
+
+	for _, tt := range tests {
+		got, err := conv.SimplifyFilter(tt.filter)
+		require.NoError(t, err, "SimplifyFilter(%q)", tt.filter)
+		assert.Equal(t, tt.expected, got, "SimplifyFilter(%q)", tt.filter)
+
+		// The simplified filter is a fixed point.
+		again, err := conv.SimplifyFilter(got)
+		require.NoError(t, err, got)
+		assert.Equal(t, got, again, "SimplifyFilter(%q)", got)
+	}
+
+	// Without a schema, nothing is known about nullability.
+	got, err := odatasql.SimplifyFilter("not (age gt 18 or x eq null)")
+	require.NoError(t, err)
+	assert.Equal(t, "not age gt 18 and x ne null", got)
+
+	got, err = odatasql.SimplifyFilter("tags/any(t: not not (t eq 'a' or t eq 'b')) and tags/any(t: t eq 'a' or t eq 'b')")
+	require.NoError(t, err)
+	assert.Equal(t, "tags/any(t: t in ('a', 'b'))", got)
+}
+
+func TestSimplifyFilter_Limits(t *testing.T) {
+	t.Parallel()
+
+	conv := odatasql.NewConverter(odatasql.WithLimits(odatasql.Limits{MaxInValues: 2}))
+	got, err := conv.SimplifyFilter("a eq 1 or a eq 2 or a eq 3")
+	require.NoError(t, err)
+	assert.Equal(t, "a eq 1 or a eq 2 or a eq 3", got)
+
+	got, err = conv.SimplifyFilter("a eq 1 or a eq 1")
+	require.NoError(t, err)
+	assert.Equal(t, "a eq 1", got)
+}
+
+func TestSimplifyFilter_PreservesSemantics(t *testing.T) {
+	t.Parallel()
+
+	schema := simplifySchema(t)
+	filters := []string{
+		"not (a gt 1 or b eq 2)",
+		"not age gt 18 and not (age le 10)",
+		"not (a ne null and age eq 18)",
+		"not (a eq 1 or a eq 2 or (a eq 3 or b eq 1))",
+		"not (age ne 10 and age ne 18) or not not a eq null",
+		"(a eq 1 or b eq 2) and not (b eq 2 or a eq 1) or age in (10, 10)",
+		"not (a lt 2 and not (b ge 2 or name eq 'Al'))",
+		"not (startswith(name, 'A') or not contains(name, 'o'))",
+		"a in (1, 2) or a eq 3 or b in (1) or not a in (3)",
+		"a ne 1 and not (a eq 2 or b eq 2) and not a in (3, 1)",
+	}
+
+	// Every combination of values, including nulls for the nullable properties.
+	var rows []map[string]any
+	for _, a := range []any{1, 2, 3, nil} {
+		for _, b := range []any{1, 2, nil} {
+			for _, age := range []any{10, 18, 30} {
+				for _, name := range []any{"Al", "Bob", nil} {
+					rows = append(rows, map[string]any{"a": a, "b": b, "age": age, "name": name})
+				}
+			}
+		}
+	}
+
+	for _, nulls := range []odatasql.NullHandling{odatasql.NullAsValue, odatasql.NullAsIsNull} {
+		conv := odatasql.NewConverter(odatasql.WithSchema(schema), odatasql.WithNullHandling(nulls))
+		for _, filter := range filters {
+			// Checking the negation too tells unknown and false apart.
+			for _, f := range []string{filter, "not (" + filter + ")"} {
+				simplified, err := conv.SimplifyFilter(f)
+				require.NoError(t, err, f)
+
+				want, err := conv.CompileFilter(f)
+				require.NoError(t, err, f)
+				got, err := conv.CompileFilter(simplified)
+				require.NoError(t, err, simplified)
+
+				for _, row := range rows {
+					wantMatch, err := want(row)
+					require.NoError(t, err)
+					gotMatch, err := got(row)
+					require.NoError(t, err)
+					assert.Equal(t, wantMatch, gotMatch, "%q simplified to %q on %v", f, simplified, row)
+				}
+			}
+		}
+	}
+}